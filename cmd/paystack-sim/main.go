@@ -0,0 +1,31 @@
+// Command paystack-sim runs the Paystack simulator as a standalone server.
+// Point the wallet service at it with PAYSTACK_BASE_URL=http://localhost:8090
+// and the same PAYSTACK_SECRET_KEY.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"wallet-service/paystacksim"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "address to listen on")
+	publicURL := flag.String("public-url", "http://localhost:8090", "URL the simulator is reachable on, used for checkout links")
+	webhookURL := flag.String("webhook-url", "http://localhost:8080/wallet/paystack/webhook", "where to deliver signed webhooks")
+	flag.Parse()
+
+	secretKey := os.Getenv("PAYSTACK_SECRET_KEY")
+	if secretKey == "" {
+		secretKey = "sk_test_simulator"
+	}
+
+	sim := paystacksim.New(secretKey, *webhookURL, *publicURL)
+
+	log.Printf("Paystack simulator listening on %s (webhooks to %s)", *addr, *webhookURL)
+	if err := http.ListenAndServe(*addr, sim.Handler()); err != nil {
+		log.Fatal("Failed to start simulator:", err)
+	}
+}
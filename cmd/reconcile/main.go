@@ -0,0 +1,122 @@
+// Command reconcile checks a Paystack settlement or transaction export (CSV)
+// against the wallet service's deposits, using the service's configuration to
+// reach the database.
+//
+//	go run ./cmd/reconcile -file settlement.csv [-from 2025-01-01 -to 2025-01-07] [-json]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"wallet-service/config"
+	"wallet-service/database"
+	"wallet-service/reconcile"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	path := flag.String("file", "", "Paystack CSV export to reconcile")
+	from := flag.String("from", "", "first day of the period (YYYY-MM-DD), defaults to the file's first day")
+	to := flag.String("to", "", "last day of the period (YYYY-MM-DD), defaults to the file's last day")
+	inKobo := flag.Bool("kobo", false, "the file holds amounts in kobo instead of naira")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	if *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts, err := reconcile.ParsePeriod(*from, *to)
+	if err != nil {
+		log.Fatal("Invalid period: ", err)
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		log.Fatal("Failed to open file: ", err)
+	}
+	defer file.Close()
+
+	lines, err := reconcile.ParseSettlementCSV(file, *inKobo)
+	if err != nil {
+		log.Fatal("Invalid settlement file: ", err)
+	}
+
+	config.LoadConfig()
+	database.Connect()
+	// Keep SQL logging out of the report
+	db := database.DB.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+
+	report, err := reconcile.Reconcile(db, lines, opts)
+	if err != nil {
+		log.Fatal("Reconciliation failed: ", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	printReport(report)
+}
+
+func printReport(report *reconcile.Report) {
+	if report.From != nil {
+		fmt.Printf("Period:         %s to %s\n", report.From.Format("2006-01-02"), report.To.AddDate(0, 0, -1).Format("2006-01-02"))
+	} else {
+		fmt.Println("Period:         unknown (no dates in file), unsettled deposits not checked")
+	}
+	fmt.Printf("Lines:          %d\n", report.Lines)
+	fmt.Printf("Matched:        %d\n", report.Matched)
+	fmt.Printf("Settled amount: %s\n", naira(report.SettledAmount))
+	fmt.Printf("Fees:           %s\n", naira(report.Fees))
+	fmt.Printf("Net settled:    %s\n", naira(report.NetSettled))
+
+	if len(report.Issues) == 0 {
+		fmt.Println("\nNo issues found.")
+		return
+	}
+
+	kinds := make([]string, 0, len(report.Counts))
+	for kind := range report.Counts {
+		kinds = append(kinds, string(kind))
+	}
+	sort.Strings(kinds)
+
+	fmt.Println("\nIssues:")
+	for _, kind := range kinds {
+		fmt.Printf("  %-18s %d\n", kind, report.Counts[reconcile.IssueKind(kind)])
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tREFERENCE\tLINE\tEXPECTED\tACTUAL\tDETAIL")
+	for _, issue := range report.Issues {
+		line := ""
+		if issue.Line != 0 {
+			line = fmt.Sprint(issue.Line)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", issue.Kind, issue.Reference, line, naira(issue.Expected), naira(issue.Actual), issue.Detail)
+	}
+	w.Flush()
+}
+
+func naira(kobo int64) string {
+	sign := ""
+	if kobo < 0 {
+		sign, kobo = "-", -kobo
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, kobo/100, kobo%100)
+}
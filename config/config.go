@@ -2,23 +2,80 @@ package config
 
 import (
 	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port                  string
-	DatabaseURL           string
-	JWTSecret             string
-	GoogleClientID        string
-	GoogleClientSecret    string
-	GoogleCallbackURL     string
-	PaystackSecretKey     string
-	PaystackPublicKey     string
-	FrontendURL           string
+	Port                   string
+	DatabaseURL            string
+	JWTSecret              string
+	GoogleClientID         string
+	GoogleClientSecret     string
+	GoogleCallbackURL      string
+	PaystackSecretKey      string
+	PaystackPublicKey      string
+	PaystackBaseURL        string
+	PaystackDVABank        string
+	PaystackWebhookSecrets []string
+	PaystackWebhookIPs     []*net.IPNet // Nil unless the IP allowlist is enabled
+	WebhookMaxBodyBytes    int64
+	TrustedProxies         []string // Nil trusts every proxy, gin's default
+	FrontendURL            string
+	DepositCallbackURL     string
+	DepositRedirectURL     string
+	DepositExpiry          time.Duration // Pending deposits older than this are abandoned; 0 disables
+	DepositExpiryInterval  time.Duration
+	DepositMismatchPolicy  string
+	DepositRules           DepositRules
+	DisputePolicy          string
+	PayoutMaxLines         int
+	PayoutTiers            []string // User tiers allowed to make bulk payouts
+	StatementDir           string   // Where statements generated in the background are kept
+	StatementSyncMaxLines  int      // Larger statements are generated in the background
+	MonthlyStatements      bool     // Email users last month's statement on the 1st
+	AnalyticsCacheTTL      time.Duration
+	AttachmentDir          string // Where transaction attachments are stored
+	AttachmentMaxBytes     int64
+	Mailer                 string
+	MailFrom               string
+	MailDropDir            string // Where the file mailer writes emails
+	SMTPHost               string
+	SMTPPort               int
+	SMTPUsername           string
+	SMTPPassword           string
+	FlutterwaveSecretKey   string
+	FlutterwaveWebhookHash string
+	DefaultPaymentProvider string
+	PaymentRoutes          []PaymentRoute
+	AdminEmails            []string
 }
 
+// Policies for deposits whose paid amount differs from the amount requested.
+const (
+	DepositMismatchCreditActual = "credit_actual" // Credit whatever was actually paid
+	DepositMismatchReject       = "reject"        // Mark the deposit failed and credit nothing
+	DepositMismatchHold         = "hold"          // Park the deposit in review for manual handling
+)
+
+// Policies for the wallet a disputed deposit was credited to, applied until
+// the dispute is resolved.
+const (
+	DisputePolicyHold  = "hold"  // Hold the amount back from spending
+	DisputePolicyDebit = "debit" // Debit the amount, credited back if the dispute is won
+)
+
+// Ways of sending email.
+const (
+	MailerSMTP = "smtp" // Through SMTP_HOST
+	MailerFile = "file" // Written to MAIL_DROP_DIR, for local use
+)
+
 var AppConfig *Config
 
 func LoadConfig() {
@@ -27,16 +84,101 @@ func LoadConfig() {
 	}
 
 	AppConfig = &Config{
-		Port:                  getEnv("PORT", "8080"),
-		DatabaseURL:           getEnv("DATABASE_URL", ""),
-		JWTSecret:             getEnv("JWT_SECRET", ""),
-		GoogleClientID:        getEnv("GOOGLE_CLIENT_ID", ""),
-		GoogleClientSecret:    getEnv("GOOGLE_CLIENT_SECRET", ""),
-		GoogleCallbackURL:     getEnv("GOOGLE_CALLBACK_URL", ""),
-		PaystackSecretKey:     getEnv("PAYSTACK_SECRET_KEY", ""),
-		PaystackPublicKey:     getEnv("PAYSTACK_PUBLIC_KEY", ""),
-		FrontendURL:           getEnv("FRONTEND_URL", "http://localhost:3000"),
+		Port:                   getEnv("PORT", "8080"),
+		DatabaseURL:            getEnv("DATABASE_URL", ""),
+		JWTSecret:              getEnv("JWT_SECRET", ""),
+		GoogleClientID:         getEnv("GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret:     getEnv("GOOGLE_CLIENT_SECRET", ""),
+		GoogleCallbackURL:      getEnv("GOOGLE_CALLBACK_URL", ""),
+		PaystackSecretKey:      getEnv("PAYSTACK_SECRET_KEY", ""),
+		PaystackPublicKey:      getEnv("PAYSTACK_PUBLIC_KEY", ""),
+		PaystackBaseURL:        strings.TrimSuffix(getEnv("PAYSTACK_BASE_URL", "https://api.paystack.co"), "/"),
+		PaystackDVABank:        getEnv("PAYSTACK_DVA_BANK", "wema-bank"),
+		PaystackWebhookSecrets: splitList(getEnv("PAYSTACK_WEBHOOK_SECRETS", "")),
+		FrontendURL:            getEnv("FRONTEND_URL", "http://localhost:3000"),
+		DepositCallbackURL:     getEnv("DEPOSIT_CALLBACK_URL", "http://localhost:8080/wallet/deposit/callback"),
+		DepositMismatchPolicy:  getEnv("DEPOSIT_MISMATCH_POLICY", DepositMismatchHold),
+		DisputePolicy:          getEnv("DISPUTE_POLICY", DisputePolicyHold),
+		FlutterwaveSecretKey:   getEnv("FLUTTERWAVE_SECRET_KEY", ""),
+		FlutterwaveWebhookHash: getEnv("FLUTTERWAVE_WEBHOOK_HASH", ""),
+		DefaultPaymentProvider: getEnv("DEFAULT_PAYMENT_PROVIDER", "paystack"),
+		AdminEmails:            splitList(getEnv("ADMIN_EMAILS", "")),
+	}
+
+	AppConfig.DepositRedirectURL = getEnv("DEPOSIT_REDIRECT_URL", strings.TrimSuffix(AppConfig.FrontendURL, "/")+"/wallet/deposit/complete")
+
+	routes, err := ParsePaymentRoutes(getEnv("PAYMENT_ROUTES", ""))
+	if err != nil {
+		log.Fatal("Invalid PAYMENT_ROUTES: ", err)
+	}
+	AppConfig.PaymentRoutes = routes
+
+	// Unset keeps trusting every proxy, as before the setting existed
+	switch proxies := getEnv("TRUSTED_PROXIES", ""); proxies {
+	case "":
+	case "none":
+		AppConfig.TrustedProxies = []string{}
+	default:
+		AppConfig.TrustedProxies = splitList(proxies)
+	}
+
+	if getEnv("PAYSTACK_WEBHOOK_IP_ALLOWLIST", "false") == "true" {
+		// Trusting every proxy would let a forged X-Forwarded-For through
+		if AppConfig.TrustedProxies == nil {
+			log.Fatal("PAYSTACK_WEBHOOK_IP_ALLOWLIST needs TRUSTED_PROXIES: set it to the load balancer's addresses, or to none when clients connect directly")
+		}
+		ranges, err := ParseIPRanges(getEnv("PAYSTACK_WEBHOOK_IPS", PaystackWebhookIPs))
+		if err != nil {
+			log.Fatal("Invalid PAYSTACK_WEBHOOK_IPS: ", err)
+		}
+		AppConfig.PaystackWebhookIPs = ranges
+	}
+
+	AppConfig.DepositRules = loadDepositRules()
+
+	AppConfig.DepositExpiry = getEnvDuration("DEPOSIT_EXPIRY", 24*time.Hour)
+	AppConfig.DepositExpiryInterval = getEnvDuration("DEPOSIT_EXPIRY_INTERVAL", 15*time.Minute)
+	AppConfig.AnalyticsCacheTTL = getEnvDuration("ANALYTICS_CACHE_TTL", 5*time.Minute)
+
+	maxLines, err := strconv.Atoi(getEnv("PAYOUT_MAX_LINES", "500"))
+	if err != nil || maxLines <= 0 {
+		log.Fatal("PAYOUT_MAX_LINES must be a positive number")
+	}
+	AppConfig.PayoutMaxLines = maxLines
+	AppConfig.PayoutTiers = splitList(strings.ToLower(getEnv("PAYOUT_TIERS", "business")))
+
+	AppConfig.StatementDir = getEnv("STATEMENT_DIR", "data/statements")
+	syncLines, err := strconv.Atoi(getEnv("STATEMENT_SYNC_MAX_LINES", "1000"))
+	if err != nil || syncLines < 0 {
+		log.Fatal("STATEMENT_SYNC_MAX_LINES must be a number")
+	}
+	AppConfig.StatementSyncMaxLines = syncLines
+
+	AppConfig.AttachmentDir = getEnv("ATTACHMENT_DIR", "data/attachments")
+	attachmentBytes, err := strconv.ParseInt(getEnv("ATTACHMENT_MAX_BYTES", strconv.Itoa(10<<20)), 10, 64)
+	if err != nil || attachmentBytes <= 0 {
+		log.Fatal("ATTACHMENT_MAX_BYTES must be a positive number of bytes")
+	}
+	AppConfig.AttachmentMaxBytes = attachmentBytes
+
+	AppConfig.MonthlyStatements = getEnv("MONTHLY_STATEMENTS", "true") == "true"
+	AppConfig.Mailer = getEnv("MAILER", MailerFile)
+	AppConfig.MailFrom = getEnv("MAIL_FROM", "Wallet Service <statements@localhost>")
+	AppConfig.MailDropDir = getEnv("MAIL_DROP_DIR", "data/mail")
+	AppConfig.SMTPHost = getEnv("SMTP_HOST", "")
+	AppConfig.SMTPUsername = getEnv("SMTP_USERNAME", "")
+	AppConfig.SMTPPassword = getEnv("SMTP_PASSWORD", "")
+	smtpPort, err := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	if err != nil || smtpPort <= 0 || smtpPort > 65535 {
+		log.Fatal("SMTP_PORT must be a port number")
+	}
+	AppConfig.SMTPPort = smtpPort
+
+	maxBody, err := strconv.ParseInt(getEnv("WEBHOOK_MAX_BODY_BYTES", strconv.Itoa(DefaultWebhookMaxBodyBytes)), 10, 64)
+	if err != nil || maxBody <= 0 {
+		log.Fatal("WEBHOOK_MAX_BODY_BYTES must be a positive number of bytes")
 	}
+	AppConfig.WebhookMaxBodyBytes = maxBody
 
 	validateConfig()
 }
@@ -48,6 +190,46 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func loadDepositRules() DepositRules {
+	min, err := parseOptionalAmount(getEnv("DEPOSIT_MIN_AMOUNT", ""))
+	if err != nil {
+		log.Fatal("Invalid DEPOSIT_MIN_AMOUNT: ", err)
+	}
+	max, err := parseOptionalAmount(getEnv("DEPOSIT_MAX_AMOUNT", ""))
+	if err != nil || (max != 0 && max < min) {
+		log.Fatal("DEPOSIT_MAX_AMOUNT must be empty or at least DEPOSIT_MIN_AMOUNT")
+	}
+
+	currencyLimits, err := ParseCurrencyLimits(getEnv("DEPOSIT_CURRENCY_LIMITS", ""))
+	if err != nil {
+		log.Fatal("Invalid DEPOSIT_CURRENCY_LIMITS: ", err)
+	}
+
+	tiers, err := ParseTierLimits(getEnv("DEPOSIT_TIER_LIMITS", ""))
+	if err != nil {
+		log.Fatal("Invalid DEPOSIT_TIER_LIMITS: ", err)
+	}
+
+	return DepositRules{
+		Limits:         DepositLimits{Min: min, Max: max},
+		CurrencyLimits: currencyLimits,
+		Channels:       splitList(getEnv("DEPOSIT_CHANNELS", "")),
+		Tiers:          tiers,
+	}
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		log.Fatalf("%s must be a duration such as 30m or 24h", key)
+	}
+	return duration
+}
+
 func validateConfig() {
 	if AppConfig.DatabaseURL == "" {
 		log.Fatal("DATABASE_URL is required")
@@ -64,4 +246,29 @@ func validateConfig() {
 	if AppConfig.PaystackSecretKey == "" {
 		log.Fatal("PAYSTACK_SECRET_KEY is required")
 	}
+	switch AppConfig.DepositMismatchPolicy {
+	case DepositMismatchCreditActual, DepositMismatchReject, DepositMismatchHold:
+	default:
+		log.Fatal("DEPOSIT_MISMATCH_POLICY must be one of credit_actual, reject or hold")
+	}
+	switch AppConfig.DisputePolicy {
+	case DisputePolicyHold, DisputePolicyDebit:
+	default:
+		log.Fatal("DISPUTE_POLICY must be one of hold or debit")
+	}
+	if AppConfig.DepositExpiry > 0 && AppConfig.DepositExpiryInterval <= 0 {
+		log.Fatal("DEPOSIT_EXPIRY_INTERVAL must be greater than 0")
+	}
+	switch AppConfig.Mailer {
+	case MailerSMTP:
+		if AppConfig.SMTPHost == "" {
+			log.Fatal("SMTP_HOST is required when MAILER is smtp")
+		}
+	case MailerFile:
+	default:
+		log.Fatal("MAILER must be one of smtp or file")
+	}
+	if AppConfig.FlutterwaveSecretKey != "" && AppConfig.FlutterwaveWebhookHash == "" {
+		log.Fatal("FLUTTERWAVE_WEBHOOK_HASH is required when FLUTTERWAVE_SECRET_KEY is set")
+	}
 }
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultUserTier is the tier new users start on.
+const DefaultUserTier = "basic"
+
+// DepositLimits bound a single deposit, in the currency's smallest unit.
+// Zero means no bound.
+type DepositLimits struct {
+	Min int64
+	Max int64
+}
+
+// TierLimits cap deposits for users on a tier, in the deposit currency's
+// smallest unit. Zero means no cap.
+type TierLimits struct {
+	MaxAmount  int64 // Per deposit
+	DailyLimit int64 // Over the last 24 hours, per currency
+}
+
+// DepositRules are checked before a deposit checkout is started.
+type DepositRules struct {
+	Limits         DepositLimits            // For currencies without their own limits
+	CurrencyLimits map[string]DepositLimits // Replace Limits for their currency
+	Channels       []string                 // Allowed checkout channels; all when empty
+	Tiers          map[string]TierLimits
+}
+
+// LimitsFor returns the amount bounds that apply to a currency.
+func (r DepositRules) LimitsFor(currency string) DepositLimits {
+	if limits, ok := r.CurrencyLimits[strings.ToUpper(currency)]; ok {
+		return limits
+	}
+	return r.Limits
+}
+
+// ParseCurrencyLimits parses a comma separated list of CURRENCY:MIN:MAX
+// entries, e.g. "USD:100:1000000,GHS:500:". An empty bound means none.
+func ParseCurrencyLimits(value string) (map[string]DepositLimits, error) {
+	limits := make(map[string]DepositLimits)
+	for _, entry := range splitList(value) {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("limit %q must look like CURRENCY:MIN:MAX", entry)
+		}
+
+		min, minErr := parseOptionalAmount(parts[1])
+		max, maxErr := parseOptionalAmount(parts[2])
+		if minErr != nil || maxErr != nil || (max != 0 && max < min) {
+			return nil, fmt.Errorf("limit %q has invalid amounts", entry)
+		}
+
+		limits[strings.ToUpper(strings.TrimSpace(parts[0]))] = DepositLimits{Min: min, Max: max}
+	}
+	return limits, nil
+}
+
+// ParseTierLimits parses a comma separated list of TIER:MAX_AMOUNT:DAILY_LIMIT
+// entries, e.g. "basic:5000000:20000000,verified::500000000". An empty cap
+// means none.
+func ParseTierLimits(value string) (map[string]TierLimits, error) {
+	tiers := make(map[string]TierLimits)
+	for _, entry := range splitList(value) {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("tier %q must look like TIER:MAX_AMOUNT:DAILY_LIMIT", entry)
+		}
+
+		maxAmount, maxErr := parseOptionalAmount(parts[1])
+		dailyLimit, dailyErr := parseOptionalAmount(parts[2])
+		if maxErr != nil || dailyErr != nil {
+			return nil, fmt.Errorf("tier %q has invalid amounts", entry)
+		}
+
+		tiers[strings.ToLower(strings.TrimSpace(parts[0]))] = TierLimits{MaxAmount: maxAmount, DailyLimit: dailyLimit}
+	}
+	return tiers, nil
+}
+
+func parseOptionalAmount(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return amount, nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PaymentRoute sends deposits in a currency, optionally above a minimum
+// amount, to a specific payment provider.
+type PaymentRoute struct {
+	Currency  string // "*" matches any currency
+	MinAmount int64  // In kobo, 0 for no minimum
+	Provider  string
+}
+
+func (r PaymentRoute) Matches(currency string, amount int64) bool {
+	if r.Currency != "*" && !strings.EqualFold(r.Currency, currency) {
+		return false
+	}
+	return amount >= r.MinAmount
+}
+
+// ParsePaymentRoutes parses a comma separated list of CURRENCY[:MIN_AMOUNT]=PROVIDER
+// entries, e.g. "USD=flutterwave,NGN:50000000=flutterwave". Routes are
+// checked in the order given.
+func ParsePaymentRoutes(value string) ([]PaymentRoute, error) {
+	var routes []PaymentRoute
+	for _, entry := range splitList(value) {
+		match, provider, ok := strings.Cut(entry, "=")
+		if !ok || provider == "" {
+			return nil, fmt.Errorf("route %q must look like CURRENCY[:MIN_AMOUNT]=PROVIDER", entry)
+		}
+
+		route := PaymentRoute{Provider: strings.TrimSpace(provider)}
+		currency, minAmount, hasMin := strings.Cut(match, ":")
+		route.Currency = strings.ToUpper(strings.TrimSpace(currency))
+		if hasMin {
+			amount, err := strconv.ParseInt(strings.TrimSpace(minAmount), 10, 64)
+			if err != nil || amount < 0 {
+				return nil, fmt.Errorf("route %q has an invalid minimum amount", entry)
+			}
+			route.MinAmount = amount
+		}
+
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// splitList splits a comma separated value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
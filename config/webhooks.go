@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// PaystackWebhookIPs are the addresses Paystack documents sending webhooks
+// from.
+const PaystackWebhookIPs = "52.31.139.75,52.49.173.169,52.214.14.220"
+
+// DefaultWebhookMaxBodyBytes is far above any real provider payload.
+const DefaultWebhookMaxBodyBytes = 256 << 10
+
+// ParseIPRanges parses a comma separated list of IP addresses and CIDR
+// ranges. Bare addresses match only themselves.
+func ParseIPRanges(value string) ([]*net.IPNet, error) {
+	var ranges []*net.IPNet
+	for _, item := range splitList(value) {
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", item)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			ranges = append(ranges, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP range %q", item)
+		}
+		ranges = append(ranges, ipNet)
+	}
+	return ranges, nil
+}
+
+// IPAllowed reports whether ip falls in one of ranges.
+func IPAllowed(ranges []*net.IPNet, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range ranges {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -33,6 +33,19 @@ func Migrate() {
 		&models.Transaction{},
 		&models.APIKey{},
 		&models.IdempotencyKey{},
+		&models.SavedCard{},
+		&models.VirtualAccount{},
+		&models.Refund{},
+		&models.Dispute{},
+		&models.PayoutBatch{},
+		&models.PayoutLine{},
+		&models.BalanceSnapshot{},
+		&models.Statement{},
+		&models.StatementPreference{},
+		&models.StatementDelivery{},
+		&models.Category{},
+		&models.CategoryRule{},
+		&models.TransactionAttachment{},
 	)
 	
 	if err != nil {
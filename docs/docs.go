@@ -23,70 +23,59 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/google": {
-            "get": {
-                "description": "Returns Google OAuth URL. For normal flow: open URL and sign in, you'll get token automatically. For testing in Swagger: add debug=true parameter to see the code first.",
-                "produces": [
+        "/admin/deposits/{reference}/refund": {
+            "post": {
+                "description": "Refund a successful deposit on behalf of its owner. The owner's wallet is debited straight away and credited back automatically if the refund fails.",
+                "consumes": [
                     "application/json"
                 ],
-                "tags": [
-                    "Authentication"
-                ],
-                "summary": "Initiate Google OAuth login",
-                "parameters": [
-                    {
-                        "type": "boolean",
-                        "description": "Set to true to get authorization code for manual testing",
-                        "name": "debug",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Google OAuth URL",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    }
-                }
-            }
-        },
-        "/auth/google/callback": {
-            "get": {
-                "description": "This is automatically called by Google after sign-in. Add '?debug=true' to see the code without processing: /auth/google/callback?code=...\u0026debug=true",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Admin"
                 ],
-                "summary": "Google OAuth callback",
+                "summary": "Refund any deposit (admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Authorization code from Google",
-                        "name": "code",
-                        "in": "query",
+                        "description": "Deposit reference",
+                        "name": "reference",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "boolean",
-                        "description": "Set to true to return code without processing",
-                        "name": "debug",
-                        "in": "query"
+                        "description": "Amount in kobo (defaults to the full refundable amount) and reason",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefundRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "JWT token and user details (or code if debug=true)",
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefundResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Deposit not refundable or disputed, amount too large or insufficient balance",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "403": {
+                        "description": "Admin access required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Deposit not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -98,54 +87,52 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "502": {
+                        "description": "Payment provider error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
-                }
-            }
-        },
-        "/keys/create": {
-            "post": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Create a new API key with specific permissions and expiry (max 5 active keys per user)",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/admin/disputes": {
+            "get": {
+                "description": "Retrieve chargeback disputes raised against deposits, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "API Keys"
+                    "Admin"
                 ],
-                "summary": "Create a new API key",
+                "summary": "List disputes (admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Idempotency key to prevent duplicate key creation (optional but recommended)",
-                        "name": "X-Idempotency-Key",
-                        "in": "header"
-                    },
-                    {
-                        "description": "API key details. Expiry: 1H, 1D, 1M, 1Y",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.CreateAPIKeyRequest"
-                        }
+                        "description": "Only disputes with this status (open, under_review, won, lost)",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateAPIKeyResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.DisputeResponse"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad request or max keys reached",
+                    "403": {
+                        "description": "Admin access required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -158,52 +145,65 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     }
-                }
-            }
-        },
-        "/keys/list": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Get all API keys for the authenticated user (actual key values are not exposed)",
+                ]
+            }
+        },
+        "/admin/disputes/{id}": {
+            "get": {
+                "description": "Retrieve one dispute with the evidence submitted for it",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "API Keys"
+                    "Admin"
+                ],
+                "summary": "Get a dispute (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dispute ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "List all API keys",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/handlers.APIKeyResponse"
-                            }
+                            "$ref": "#/definitions/handlers.DisputeResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Admin access required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Dispute not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
-                }
-            }
-        },
-        "/keys/rollover": {
-            "post": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Create a new API key with the same permissions as an expired key",
+                ]
+            }
+        },
+        "/admin/disputes/{id}/evidence": {
+            "post": {
+                "description": "Send evidence to the payment provider for contesting an open dispute. The dispute can then be declined.",
                 "consumes": [
                     "application/json"
                 ],
@@ -211,184 +211,233 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "API Keys"
+                    "Admin"
                 ],
-                "summary": "Rollover an expired API key",
+                "summary": "Submit dispute evidence (admin)",
                 "parameters": [
                     {
-                        "description": "Expired key ID and new expiry",
+                        "type": "string",
+                        "description": "Dispute ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Evidence",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.RolloverAPIKeyRequest"
+                            "$ref": "#/definitions/handlers.DisputeEvidenceRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateAPIKeyResponse"
+                            "$ref": "#/definitions/handlers.DisputeResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request or key not expired",
+                        "description": "Invalid evidence or dispute already resolved",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin access required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "API key not found",
+                        "description": "Dispute not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "502": {
+                        "description": "Payment provider error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
-                }
-            }
-        },
-        "/keys/{id}": {
-            "delete": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
+                ]
+            }
+        },
+        "/admin/disputes/{id}/resolve": {
+            "post": {
+                "description": "Accept a dispute, refunding the customer, or decline it with the evidence already submitted. The dispute moves to under_review until the provider reports the final outcome.",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Deactivate an API key by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "API Keys"
+                    "Admin"
                 ],
-                "summary": "Revoke an API key",
+                "summary": "Accept or decline a dispute (admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "API Key ID",
+                        "description": "Dispute ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Resolution",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResolveDisputeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DisputeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid resolution, no evidence or dispute already resolved",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin access required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "API key not found",
+                        "description": "Dispute not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "502": {
+                        "description": "Payment provider error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
-                }
-            }
-        },
-        "/wallet/balance": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
-                    },
-                    {
-                        "ApiKeyAuth": []
                     }
-                ],
-                "description": "Retrieve the current balance of the authenticated user's wallet",
+                ]
+            }
+        },
+        "/admin/metrics": {
+            "get": {
+                "description": "Runtime and webhook counters in expvar format. Webhook counters are keyed by provider and outcome, e.g. paystack.accepted or paystack.rejected.invalid_signature.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Wallet"
+                    "Admin"
                 ],
-                "summary": "Get wallet balance",
+                "summary": "Service metrics (admin)",
                 "responses": {
                     "200": {
-                        "description": "Balance in kobo",
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Wallet not found",
+                    "403": {
+                        "description": "Admin access required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
-                }
-            }
-        },
-        "/wallet/deposit": {
-            "post": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
-                    },
-                    {
-                        "ApiKeyAuth": []
                     }
-                ],
-                "description": "Initialize a Paystack transaction for depositing money into wallet",
+                ]
+            }
+        },
+        "/admin/reconciliation/paystack": {
+            "post": {
+                "description": "Match a Paystack settlement or transaction export (CSV) against our deposits by reference. Reports settled payments with no deposit or an uncredited one, amount and fee mismatches, total fees, and deposits we credited that the file does not settle within the period.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Wallet"
+                    "Admin"
                 ],
-                "summary": "Initiate wallet deposit",
+                "summary": "Reconcile a Paystack export (admin)",
                 "parameters": [
                     {
-                        "description": "Deposit amount in kobo (100 kobo = ₦1)",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.DepositRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.DepositResponse"
-                        }
+                        "type": "file",
+                        "description": "Paystack CSV export",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day of the period (YYYY-MM-DD), defaults to the file's first day",
+                        "name": "from",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day of the period (YYYY-MM-DD), defaults to the file's last day",
+                        "name": "to",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "The file holds amounts in kobo instead of naira",
+                        "name": "amounts_in_kobo",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reconcile.Report"
+                        }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Missing or unreadable file, or invalid period",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Wallet not found",
+                    "403": {
+                        "description": "Admin access required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -401,27 +450,87 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
-        "/wallet/deposit/{reference}/status": {
+        "/admin/statements/deliveries": {
             "get": {
-                "security": [
+                "description": "Retrieve monthly statement deliveries across users, newest first, e.g. to find the ones that failed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List monthly statement emails (admin)",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "type": "string",
+                        "description": "Only this month, YYYY-MM",
+                        "name": "period",
+                        "in": "query"
                     },
                     {
-                        "ApiKeyAuth": []
+                        "type": "string",
+                        "description": "Only deliveries with this status (pending, sending, sent, failed, skipped)",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
-                "description": "Manually check the status of a deposit transaction by reference",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.StatementDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid period",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin access required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/admin/transactions/{reference}": {
+            "get": {
+                "description": "Retrieve any user's transaction in full, so a transfer can be traced from either side. The user's private notes, tags and attachments are left out. Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Wallet"
+                    "Admin"
                 ],
-                "summary": "Get deposit transaction status",
+                "summary": "Get any transaction",
                 "parameters": [
                     {
                         "type": "string",
@@ -435,8 +544,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.TransactionDetail"
                         }
                     },
                     "404": {
@@ -445,13 +553,25 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
-        "/wallet/paystack/webhook": {
-            "post": {
-                "description": "Receives and processes payment notifications from Paystack (signature verified)",
+        "/admin/users/{id}/tier": {
+            "put": {
+                "description": "Move a user to another tier, which decides the deposit caps that apply to them and whether they may make bulk payouts. The tier must be the default tier or one configured in DEPOSIT_TIER_LIMITS or PAYOUT_TIERS.",
                 "consumes": [
                     "application/json"
                 ],
@@ -459,16 +579,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Wallet"
+                    "Admin"
                 ],
-                "summary": "Paystack webhook handler",
+                "summary": "Set a user's tier (admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Paystack signature",
-                        "name": "x-paystack-signature",
-                        "in": "header",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "New tier",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SetUserTierRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -480,55 +609,101 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Unknown tier",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Invalid signature",
+                    "403": {
+                        "description": "Admin access required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "User not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
-                }
-            }
-        },
-        "/wallet/transactions": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
-                    },
+                    }
+                ]
+            }
+        },
+        "/auth/google": {
+            "get": {
+                "description": "Returns Google OAuth URL. For normal flow: open URL and sign in, you'll get token automatically. For testing in Swagger: add debug=true parameter to see the code first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Initiate Google OAuth login",
+                "parameters": [
                     {
-                        "ApiKeyAuth": []
+                        "type": "boolean",
+                        "description": "Set to true to get authorization code for manual testing",
+                        "name": "debug",
+                        "in": "query"
                     }
                 ],
-                "description": "Retrieve all transactions for the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "Google OAuth URL",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/google/callback": {
+            "get": {
+                "description": "This is automatically called by Google after sign-in. Add '?debug=true' to see the code without processing: /auth/google/callback?code=...\u0026debug=true",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Wallet"
+                    "Authentication"
+                ],
+                "summary": "Google OAuth callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization code from Google",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Set to true to return code without processing",
+                        "name": "debug",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get transaction history",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "JWT token and user details (or code if debug=true)",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/handlers.TransactionResponse"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
@@ -541,17 +716,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/wallet/transfer": {
+        "/keys/create": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    },
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Transfer money from authenticated user's wallet to another user's wallet",
+                "description": "Create a new API key with specific permissions and expiry (max 5 active keys per user)",
                 "consumes": [
                     "application/json"
                 ],
@@ -559,47 +726,74 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Wallet"
+                    "API Keys"
                 ],
-                "summary": "Transfer funds to another wallet",
+                "summary": "Create a new API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Idempotency key to prevent duplicate transfers (optional but recommended)",
+                        "description": "Idempotency key to prevent duplicate key creation (optional but recommended)",
                         "name": "X-Idempotency-Key",
                         "in": "header"
                     },
                     {
-                        "description": "Transfer details",
+                        "description": "API key details. Expiry: 1H, 1D, 1M, 1Y",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.TransferRequest"
+                            "$ref": "#/definitions/handlers.CreateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.CreateAPIKeyResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request or insufficient balance",
+                        "description": "Bad request or max keys reached",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Recipient wallet not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/keys/list": {
+            "get": {
+                "description": "Get all API keys for the authenticated user (actual key values are not exposed)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "List all API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.APIKeyResponse"
+                            }
+                        }
                     },
                     "500": {
                         "description": "Internal server error",
@@ -608,155 +802,4536 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
-        }
-    },
-    "definitions": {
+        },
+        "/keys/rollover": {
+            "post": {
+                "description": "Create a new API key with the same permissions as an expired key",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Rollover an expired API key",
+                "parameters": [
+                    {
+                        "description": "Expired key ID and new expiry",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RolloverAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or key not expired",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/keys/{id}": {
+            "delete": {
+                "description": "Deactivate an API key by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/analytics": {
+            "get": {
+                "description": "Money in and out of the authenticated user's wallet between two dates (inclusive, UTC): totals and averages, a series by day, week or month, the wallets most money moved with, and a breakdown by category. Counted from the balance changes transactions made, so failed and pending transactions are left out. Results are cached briefly and recomputed once the balance changes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Get spending analytics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD (default 29 days before to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD (default today)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "day, week or month (default day)",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of top counterparties (default 5, max 50)",
+                        "name": "top",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AnalyticsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid dates or interval, or too many periods",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/balance": {
+            "get": {
+                "description": "Retrieve the current balance of the authenticated user's wallet. held_balance is held back by open disputes; available is what can be spent. With as_of, returns only the balance at that time instead: a date means its closing balance (end of day, UTC).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Get wallet balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Past date (YYYY-MM-DD) or time (RFC 3339)",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Balance, held balance and available balance in kobo; as_of and balance for past balances",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid as_of",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "No balance history for as_of",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/cards": {
+            "get": {
+                "description": "Cards saved from previous successful card deposits, usable for one-click top-ups",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cards"
+                ],
+                "summary": "List saved cards",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.SavedCardResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/cards/{id}": {
+            "delete": {
+                "description": "Delete a saved card so it can no longer be charged",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cards"
+                ],
+                "summary": "Remove a saved card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved card ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Card not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/cards/{id}/charge": {
+            "post": {
+                "description": "Charge a saved card without going through checkout. A 202 response means the bank wants more: submit the OTP for action send_otp, or wait for the customer to approve on their phone for action pay_offline. A 202 without an action means the provider did not answer in time; the deposit stays pending until its webhook arrives or its status is checked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cards"
+                ],
+                "summary": "Top up with a saved card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved card ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idempotency key to prevent duplicate charges (optional but recommended)",
+                        "name": "X-Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Amount in kobo",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CardTopUpRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CardTopUpResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CardTopUpResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, card expired or deposit rule broken (see DepositRuleError)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "402": {
+                        "description": "Card declined",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Card not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Payment provider unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/categories": {
+            "get": {
+                "description": "Retrieve the authenticated user's transaction categories, by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Categories"
+                ],
+                "summary": "List categories",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Category"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Add a category transactions can be filed under, by hand or by rules",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Categories"
+                ],
+                "summary": "Create a category",
+                "parameters": [
+                    {
+                        "description": "Category",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateCategoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid name",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Category already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/categories/{id}": {
+            "delete": {
+                "description": "Delete a category and the rules filing transactions under it. Its transactions are run through the remaining rules again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Categories"
+                ],
+                "summary": "Delete a category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/category-rules": {
+            "get": {
+                "description": "Retrieve the authenticated user's rules for filing transactions under categories, in the order they are tried",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Categories"
+                ],
+                "summary": "List categorization rules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CategoryRule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Automatically file transactions under a category by type, counterparty wallet and/or text in the narration. A rule matches when all the conditions it sets do; when several match, the lowest priority wins (the oldest rule on a tie). Existing transactions not categorized by hand are run through the rules again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Categories"
+                ],
+                "summary": "Create a categorization rule",
+                "parameters": [
+                    {
+                        "description": "Rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateCategoryRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CategoryRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rule",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/category-rules/{id}": {
+            "delete": {
+                "description": "Delete a rule. Transactions it categorized are run through the remaining rules again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Categories"
+                ],
+                "summary": "Delete a categorization rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Rule not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/deposit": {
+            "post": {
+                "description": "Initialize a checkout for depositing money into wallet. The amount and channels must satisfy the configured deposit rules (minimum, maximum, allowed channels and the user's tier caps). The payment provider is picked from configuration (currency and amount routes) unless one is requested; if the chosen provider fails, the next available one is tried. After checkout the customer comes back through /wallet/deposit/callback and is redirected to the frontend with the outcome.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Initiate wallet deposit",
+                "parameters": [
+                    {
+                        "description": "Deposit amount in kobo (100 kobo = ₦1), optional currency (default NGN), provider, payment channels to offer (card, bank, ussd, qr, mobile_money, bank_transfer, eft, apple_pay), narration (max 100 characters) and metadata: up to 20 string key/value pairs (keys max 40, values max 500 characters), stored with the deposit and passed to the provider",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DepositRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DepositResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Deposit rule broken (other bad requests return only error)",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DepositRuleError"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Payment provider error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Payment provider unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/deposit/callback": {
+            "get": {
+                "description": "Payment providers send the customer here after checkout. The deposit is verified with its provider and applied, then the customer is redirected to DEPOSIT_REDIRECT_URL with reference and status (success, failed, pending, review, abandoned, cancelled or not_found) query parameters.",
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Checkout callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deposit reference (Paystack also sends trxref, Flutterwave tx_ref)",
+                        "name": "reference",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the frontend"
+                    }
+                }
+            }
+        },
+        "/wallet/deposit/{reference}/cancel": {
+            "post": {
+                "description": "Cancel one of your deposits that is still awaiting payment. The payment provider is checked first, so a deposit that was already paid is credited instead of cancelled. If the customer still pays a cancelled checkout, the deposit is credited.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Cancel a pending deposit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deposit reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deposit cancelled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Transaction not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Deposit is no longer pending",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Payment provider unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/deposit/{reference}/otp": {
+            "post": {
+                "description": "Complete a saved card top-up that returned action send_otp",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cards"
+                ],
+                "summary": "Submit OTP for a card top-up",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deposit reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "OTP sent to the customer",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubmitOTPRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CardTopUpResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CardTopUpResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or OTP rejected",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "402": {
+                        "description": "Card declined",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Transaction not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/deposit/{reference}/refund": {
+            "post": {
+                "description": "Pay a successful deposit (or part of it) back to the card or account it came from. The wallet is debited straight away and credited back automatically if the refund fails.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refunds"
+                ],
+                "summary": "Refund a deposit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deposit reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idempotency key to prevent duplicate refunds (optional but recommended)",
+                        "name": "X-Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Amount in kobo (defaults to the full refundable amount) and reason",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefundRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefundResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Deposit not refundable or disputed, amount too large or insufficient balance",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Deposit not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Payment provider error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/deposit/{reference}/status": {
+            "get": {
+                "description": "Check the status of one of your deposits by reference. Pending deposits are verified live with their payment provider and credited if the charge succeeded.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Get deposit transaction status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DepositStatusResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Transaction not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/flutterwave/webhook": {
+            "post": {
+                "description": "Receives and processes payment notifications from Flutterwave (verif-hash verified)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Flutterwave webhook handler",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Flutterwave secret hash",
+                        "name": "verif-hash",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid signature",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Provider not enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/wallet/payouts": {
+            "get": {
+                "description": "Retrieve the authenticated user's payout batches, newest first, without their lines",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payouts"
+                ],
+                "summary": "List bulk payouts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PayoutBatch"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Pay many bank accounts from the wallet with one CSV or JSON file of account_number, bank_code, amount (kobo) and narration. Only users on a tier listed in PAYOUT_TIERS (business accounts) may make payouts. Every account is resolved first; if any line is invalid nothing is paid. The wallet is debited once for the whole batch, the lines are sent as Paystack bulk transfers, and lines that fail are credited back automatically.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payouts"
+                ],
+                "summary": "Create a bulk payout",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV file with a header row, or a JSON array of lines",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Currency of the payouts; only NGN, the currency wallets are held in, is accepted",
+                        "name": "currency",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idempotency key to prevent duplicate batches (optional but recommended)",
+                        "name": "X-Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.PayoutBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid file, invalid lines or insufficient balance",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Bulk payouts are only available to business accounts",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Payment provider unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/payouts/{reference}": {
+            "get": {
+                "description": "Retrieve a payout batch with the status of every line",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payouts"
+                ],
+                "summary": "Get a bulk payout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PayoutBatch"
+                        }
+                    },
+                    "404": {
+                        "description": "Payout batch not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/payouts/{reference}/lines/{id}/retry": {
+            "post": {
+                "description": "Send a failed line of a payout batch again under a new reference. The wallet, which was credited back when the line failed, is debited again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payouts"
+                ],
+                "summary": "Retry a failed payout line",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payout line ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idempotency key to prevent duplicate retries (optional but recommended)",
+                        "name": "X-Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.PayoutLine"
+                        }
+                    },
+                    "400": {
+                        "description": "Insufficient balance",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Bulk payouts are only available to business accounts",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Payout line not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Payout line has not failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/paystack/webhook": {
+            "post": {
+                "description": "Receives and processes payment notifications from Paystack (signature verified, optionally restricted to Paystack's IPs)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Paystack webhook handler",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paystack signature",
+                        "name": "x-paystack-signature",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid signature",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Source IP not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/wallet/refunds": {
+            "get": {
+                "description": "Retrieve refunds of the authenticated user's deposits, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refunds"
+                ],
+                "summary": "List refunds",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.RefundResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/statement": {
+            "get": {
+                "description": "Generate a statement of every balance change between two dates (inclusive, UTC), with opening and closing balances, a running balance per line and totals. Formats: csv, pdf, ofx and qfx (personal finance apps), qif, and camt053 (ISO 20022 XML for ERPs). Statements with more lines than STATEMENT_SYNC_MAX_LINES are generated in the background instead: the response is 202 with a statement to poll, which carries a download_url once ready.",
+                "produces": [
+                    "text/csv",
+                    "application/pdf",
+                    "application/x-ofx",
+                    "application/xml",
+                    "application/json"
+                ],
+                "tags": [
+                    "Statements"
+                ],
+                "summary": "Download an account statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv, pdf, ofx, qfx, qif or camt053 (default pdf)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The statement",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "202": {
+                        "description": "Generating in the background",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid dates or format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "No balance history for the period",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/statements": {
+            "get": {
+                "description": "Retrieve the statements generated in the background for the authenticated user, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Statements"
+                ],
+                "summary": "List background statements",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.StatementResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/statements/deliveries": {
+            "get": {
+                "description": "Retrieve the monthly statements emailed, or due to be emailed, to the authenticated user, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Statements"
+                ],
+                "summary": "List monthly statement emails",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.StatementDelivery"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/statements/preferences": {
+            "get": {
+                "description": "Whether the authenticated user is emailed a statement for each month, and in which format. Users are opted in with PDF statements until they change it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Statements"
+                ],
+                "summary": "Get monthly statement preferences",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.StatementPreference"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Opt in to or out of monthly statement emails, or change their format. Applies from the next month's statement.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Statements"
+                ],
+                "summary": "Update monthly statement preferences",
+                "parameters": [
+                    {
+                        "description": "Preferences to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatementPreferenceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.StatementPreference"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/statements/{id}": {
+            "get": {
+                "description": "Retrieve a statement being generated in the background; download_url is set once it is ready",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Statements"
+                ],
+                "summary": "Get a background statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Statement ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatementResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Statement not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/statements/{id}/download": {
+            "get": {
+                "description": "Download a statement generated in the background",
+                "produces": [
+                    "text/csv",
+                    "application/pdf",
+                    "application/x-ofx",
+                    "application/xml"
+                ],
+                "tags": [
+                    "Statements"
+                ],
+                "summary": "Download a background statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Statement ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The statement",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Statement not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Statement not ready",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/tags": {
+            "get": {
+                "description": "Every tag on the authenticated user's transactions, with how many transactions carry it, most used first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "List tags",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.TagCount"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/transactions": {
+            "get": {
+                "description": "Retrieve the authenticated user's transactions, newest first, one page at a time. Pass the returned next_cursor as cursor to get the next page. Deposits that were abandoned or cancelled without being paid are left out unless include_abandoned is true or status asks for them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Get transaction history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated transaction types, e.g. deposit,transfer",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated statuses, e.g. success,pending",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest creation time, YYYY-MM-DD or RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest creation time, YYYY-MM-DD (inclusive) or RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Smallest amount in kobo",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Largest amount in kobo",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wallet number money was sent to or received from",
+                        "name": "counterparty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction reference",
+                        "name": "reference",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Text the narration contains, ignoring case",
+                        "name": "narration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Metadata value to match, e.g. metadata[order_id]=1042; repeat for more keys",
+                        "name": "metadata[key]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category ID, or none for uncategorized transactions",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated tags the transaction must all carry",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include abandoned and cancelled deposits",
+                        "name": "include_abandoned",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TransactionPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter or cursor",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/transactions/search": {
+            "get": {
+                "description": "Full-text search over the authenticated user's transactions, best matches first. A transaction matches when its reference, narration, note or metadata values match q, or when the name or wallet number of its counterparty does. Words match whole and ignore case; q can use \"quoted phrases\", OR, and -word to exclude. The history filters narrow the results further. Pass the returned next_cursor as cursor to get the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Search transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search text",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated transaction types, e.g. deposit,transfer",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated statuses, e.g. success,pending",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest creation time, YYYY-MM-DD or RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest creation time, YYYY-MM-DD (inclusive) or RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category ID, or none for uncategorized transactions",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated tags the transaction must all carry",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include abandoned and cancelled deposits",
+                        "name": "include_abandoned",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SearchPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing query, invalid filter or cursor",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/transactions/{reference}": {
+            "get": {
+                "description": "Retrieve one of the authenticated user's transactions in full, with the counterparty, the other leg of a transfer and the user's attachments. Both legs of a transfer share transfer_group_id; notes, tags and attachments stay on the user's own leg.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Get a transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TransactionDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Transaction not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "patch": {
+                "description": "Keep a private note and tags on one of the authenticated user's transactions for bookkeeping. Fields left out are unchanged. Tags are lowercased and deduplicated. Neither is ever shown to the other side of a transfer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Add a note or tags to a transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Note and tags",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AnnotateTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TransactionItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid note or tags",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Transaction not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/transactions/{reference}/attachments": {
+            "get": {
+                "description": "The files attached to one of the authenticated user's transactions, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "List a transaction's attachments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TransactionAttachment"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Transaction not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Attach a receipt or other document to one of the authenticated user's transactions. JPEG, PNG, GIF, WebP and PDF files are accepted, up to ATTACHMENT_MAX_BYTES each and 10 per transaction. Attachments are never shown to the other side of a transfer.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Attach a file to a transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Receipt or document",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionAttachment"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing, oversized or unsupported file, or too many attachments",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Transaction not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/transactions/{reference}/attachments/{id}": {
+            "get": {
+                "description": "Download a file attached to one of the authenticated user's transactions",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Download an attachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attachment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Attachment not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Remove a file from one of the authenticated user's transactions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Delete an attachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attachment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Attachment not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/transactions/{reference}/category": {
+            "put": {
+                "description": "File one of the authenticated user's transactions under a category by hand; rules never change it afterwards. A null category_id removes it and lets the rules categorize the transaction again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Categories"
+                ],
+                "summary": "Categorize a transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Category",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SetTransactionCategoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TransactionItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Transaction not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/transfer": {
+            "post": {
+                "description": "Transfer money from authenticated user's wallet to another user's wallet. An optional narration (max 100 characters) and metadata (up to 20 string key/value pairs, keys max 40 and values max 500 characters) are kept on both sides of the transfer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wallet"
+                ],
+                "summary": "Transfer funds to another wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Idempotency key to prevent duplicate transfers (optional but recommended)",
+                        "name": "X-Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Transfer details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or insufficient balance",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Recipient wallet not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/wallet/virtual-account": {
+            "get": {
+                "description": "Retrieve the bank account number that funds this wallet by transfer",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Virtual Accounts"
+                ],
+                "summary": "Get the wallet's dedicated virtual account",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.VirtualAccountResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No virtual account yet",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Assign a dedicated bank account number to the wallet. Bank transfers into it are credited automatically. Calling this again returns the existing account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Virtual Accounts"
+                ],
+                "summary": "Get a dedicated virtual account",
+                "parameters": [
+                    {
+                        "description": "Phone number, required by some banks",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateVirtualAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Existing account",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.VirtualAccountResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "New account",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.VirtualAccountResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Payment provider unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        }
+    },
+    "definitions": {
         "handlers.APIKeyResponse": {
             "type": "object",
             "properties": {
-                "expires_at": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-12-11T12:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "uuid-here"
+                },
+                "is_active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "is_expired": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "type": "string",
+                    "example": "production-api"
+                },
+                "permissions": {
+                    "type": "string",
+                    "example": "[\"deposit\",\"transfer\",\"read\"]"
+                }
+            }
+        },
+        "handlers.AnalyticsResponse": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.CategoryFlow"
+                    }
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "NGN"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2024-03-01"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "week"
+                },
+                "series": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.FlowPeriod"
+                    }
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2024-03-31"
+                },
+                "top_counterparties": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.CounterpartyFlow"
+                    }
+                },
+                "totals": {
+                    "$ref": "#/definitions/handlers.AnalyticsTotals"
+                }
+            }
+        },
+        "handlers.AnalyticsTotals": {
+            "type": "object",
+            "properties": {
+                "average_inflow": {
+                    "type": "integer",
+                    "example": 625000
+                },
+                "average_outflow": {
+                    "type": "integer",
+                    "example": 150000
+                },
+                "average_size": {
+                    "description": "Of all transactions, either way",
+                    "type": "integer",
+                    "example": 268750
+                },
+                "inflow": {
+                    "type": "integer",
+                    "example": 2500000
+                },
+                "inflow_count": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "net": {
+                    "type": "integer",
+                    "example": 700000
+                },
+                "outflow": {
+                    "type": "integer",
+                    "example": 1800000
+                },
+                "outflow_count": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "handlers.AnnotateTransactionRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "description": "Empty removes the note",
+                    "type": "string",
+                    "example": "Printer toner for the shop"
+                },
+                "tags": {
+                    "description": "Replaces every tag; empty removes them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "office",
+                        "supplies"
+                    ]
+                }
+            }
+        },
+        "handlers.CardTopUpRequest": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 5000
+                }
+            }
+        },
+        "handlers.CardTopUpResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "send_otp"
+                },
+                "display_text": {
+                    "type": "string",
+                    "example": "Please enter the OTP sent to your phone"
+                },
+                "reference": {
+                    "type": "string",
+                    "example": "TXN_1234567890"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
+        "handlers.CategoryFlow": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "description": "Null for uncategorized transactions",
+                    "type": "string"
+                },
+                "inflow": {
+                    "type": "integer",
+                    "example": 2500000
+                },
+                "inflow_count": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Groceries"
+                },
+                "net": {
+                    "type": "integer",
+                    "example": 700000
+                },
+                "outflow": {
+                    "type": "integer",
+                    "example": 1800000
+                },
+                "outflow_count": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "handlers.Counterparty": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Ada Obi"
+                },
+                "wallet_number": {
+                    "type": "string",
+                    "example": "1234567890123"
+                }
+            }
+        },
+        "handlers.CounterpartyFlow": {
+            "type": "object",
+            "properties": {
+                "inflow": {
+                    "type": "integer",
+                    "example": 2500000
+                },
+                "inflow_count": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Ada Obi"
+                },
+                "net": {
+                    "type": "integer",
+                    "example": 700000
+                },
+                "outflow": {
+                    "type": "integer",
+                    "example": 1800000
+                },
+                "outflow_count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "wallet_number": {
+                    "type": "string",
+                    "example": "1234567890123"
+                }
+            }
+        },
+        "handlers.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "expiry",
+                "name",
+                "permissions"
+            ],
+            "properties": {
+                "expiry": {
+                    "type": "string",
+                    "example": "1D"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "production-api"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "deposit",
+                        "transfer",
+                        "read"
+                    ]
+                }
+            }
+        },
+        "handlers.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "type": "string",
+                    "example": "sk_live_xxxxx"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-12-11T12:00:00Z"
+                }
+            }
+        },
+        "handlers.CreateCategoryRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Groceries"
+                }
+            }
+        },
+        "handlers.CreateCategoryRuleRequest": {
+            "type": "object",
+            "required": [
+                "category_id"
+            ],
+            "properties": {
+                "category_id": {
+                    "type": "string"
+                },
+                "counterparty": {
+                    "description": "Wallet number",
+                    "type": "string",
+                    "example": "1234567890123"
+                },
+                "narration_contains": {
+                    "type": "string",
+                    "example": "rent"
+                },
+                "priority": {
+                    "description": "Lower wins when several rules match",
+                    "type": "integer",
+                    "example": 10
+                },
+                "type": {
+                    "type": "string",
+                    "example": "transfer"
+                }
+            }
+        },
+        "handlers.CreateVirtualAccountRequest": {
+            "type": "object",
+            "properties": {
+                "phone": {
+                    "type": "string",
+                    "example": "+2348000000000"
+                }
+            }
+        },
+        "handlers.DepositRequest": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 5000
+                },
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "card",
+                        "bank_transfer"
+                    ]
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "NGN"
+                },
+                "metadata": {
+                    "description": "Up to 20 keys, passed to the provider",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "order_id": "1042"
+                    }
+                },
+                "narration": {
+                    "type": "string",
+                    "example": "Top up for order 1042"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "paystack"
+                }
+            }
+        },
+        "handlers.DepositResponse": {
+            "type": "object",
+            "properties": {
+                "authorization_url": {
+                    "type": "string",
+                    "example": "https://checkout.paystack.com/..."
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "paystack"
+                },
+                "reference": {
+                    "type": "string",
+                    "example": "TXN_1234567890"
+                }
+            }
+        },
+        "handlers.DepositRuleError": {
+            "type": "object",
+            "properties": {
+                "allowed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "amount": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "channel": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "NGN"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Amount is below the minimum deposit of 5000 NGN"
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 5000
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "min_amount"
+                },
+                "tier": {
+                    "type": "string",
+                    "example": "basic"
+                }
+            }
+        },
+        "handlers.DepositStatusResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 5000
+                },
+                "channel": {
+                    "type": "string",
+                    "example": "card"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "NGN"
+                },
+                "fees": {
+                    "type": "integer",
+                    "example": 75
+                },
+                "gateway_response": {
+                    "type": "string",
+                    "example": "Approved"
+                },
+                "paid_at": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "paystack"
+                },
+                "reference": {
+                    "type": "string",
+                    "example": "TXN_1234567890"
+                },
+                "review_reason": {
+                    "type": "string",
+                    "example": "amount mismatch: expected 5000, received 4000"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
+        "handlers.DisputeEvidenceRequest": {
+            "type": "object",
+            "required": [
+                "customer_email",
+                "customer_name",
+                "customer_phone",
+                "service_details"
+            ],
+            "properties": {
+                "customer_email": {
+                    "type": "string",
+                    "example": "customer@example.com"
+                },
+                "customer_name": {
+                    "type": "string",
+                    "example": "Jane Doe"
+                },
+                "customer_phone": {
+                    "type": "string",
+                    "example": "08012345678"
+                },
+                "delivery_address": {
+                    "type": "string",
+                    "example": "3 Example Street, Lagos"
+                },
+                "delivery_date": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string",
+                    "example": "2025-01-31"
+                },
+                "service_details": {
+                    "type": "string",
+                    "example": "Wallet top-up, funds spent on transfers"
+                }
+            }
+        },
+        "handlers.DisputeResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "In kobo",
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "deposit_id": {
+                    "type": "string"
+                },
+                "deposit_reference": {
+                    "type": "string"
+                },
+                "due_at": {
+                    "type": "string"
+                },
+                "evidence": {
+                    "$ref": "#/definitions/services.DisputeEvidence"
+                },
+                "evidence_submitted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "policy": {
+                    "description": "hold, debit or none, as applied when opened",
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "provider_dispute_id": {
+                    "type": "string"
+                },
+                "provider_status": {
+                    "type": "string"
+                },
+                "resolution_message": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "responded_by": {
+                    "description": "Admin who submitted evidence or accepted/declined it",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.DisputeStatus"
+                },
+                "transaction_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.FlowPeriod": {
+            "type": "object",
+            "properties": {
+                "inflow": {
+                    "type": "integer",
+                    "example": 2500000
+                },
+                "inflow_count": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "net": {
+                    "type": "integer",
+                    "example": 700000
+                },
+                "outflow": {
+                    "type": "integer",
+                    "example": 1800000
+                },
+                "outflow_count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "start": {
+                    "description": "First day of the day, week (Monday) or month",
+                    "type": "string",
+                    "example": "2024-03-01"
+                }
+            }
+        },
+        "handlers.Pagination": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "next_cursor": {
+                    "description": "Pass as cursor to get the next page",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RefundRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Defaults to everything not refunded yet",
+                    "type": "integer",
+                    "example": 5000
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Customer request"
+                }
+            }
+        },
+        "handlers.RefundResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 5000
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "NGN"
+                },
+                "deposit_reference": {
+                    "type": "string",
+                    "example": "TXN_1234567880"
+                },
+                "failure_reason": {
+                    "type": "string"
+                },
+                "processed_at": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "paystack"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Customer request"
+                },
+                "reference": {
+                    "type": "string",
+                    "example": "TXN_1234567890"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                }
+            }
+        },
+        "handlers.ResolveDisputeRequest": {
+            "type": "object",
+            "required": [
+                "message",
+                "resolution"
+            ],
+            "properties": {
+                "message": {
                     "type": "string",
-                    "example": "2025-12-11T12:00:00Z"
+                    "example": "Customer received the funds"
                 },
-                "id": {
+                "refund_amount": {
+                    "description": "Defaults to the disputed amount",
+                    "type": "integer",
+                    "example": 5000
+                },
+                "resolution": {
+                    "description": "accept refunds the customer, decline contests the dispute",
+                    "type": "string",
+                    "enum": [
+                        "accept",
+                        "decline"
+                    ],
+                    "example": "decline"
+                },
+                "uploaded_filename": {
+                    "description": "Proof of refund uploaded to the provider, for accept",
+                    "type": "string",
+                    "example": "qesp8a4df1xejihd9x5q"
+                }
+            }
+        },
+        "handlers.RolloverAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "expired_key_id",
+                "expiry"
+            ],
+            "properties": {
+                "expired_key_id": {
                     "type": "string",
                     "example": "uuid-here"
                 },
-                "is_active": {
-                    "type": "boolean",
-                    "example": true
+                "expiry": {
+                    "type": "string",
+                    "example": "1M"
+                }
+            }
+        },
+        "handlers.SavedCardResponse": {
+            "type": "object",
+            "properties": {
+                "bank": {
+                    "type": "string",
+                    "example": "TEST BANK"
+                },
+                "brand": {
+                    "type": "string",
+                    "example": "visa"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-01T12:00:00Z"
+                },
+                "exp_month": {
+                    "type": "string",
+                    "example": "12"
+                },
+                "exp_year": {
+                    "type": "string",
+                    "example": "2030"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "uuid-here"
                 },
                 "is_expired": {
                     "type": "boolean",
                     "example": false
                 },
-                "name": {
+                "last4": {
+                    "type": "string",
+                    "example": "4081"
+                }
+            }
+        },
+        "handlers.SearchPage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SearchResult"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/handlers.Pagination"
+                }
+            }
+        },
+        "handlers.SearchResult": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "In kobo",
+                    "type": "integer",
+                    "example": 5000
+                },
+                "balance_after": {
+                    "type": "integer",
+                    "example": 125000
+                },
+                "balance_before": {
+                    "description": "Unset until the transaction moves the balance",
+                    "type": "integer",
+                    "example": 120000
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "counterparty": {
+                    "$ref": "#/definitions/handlers.Counterparty"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "NGN"
+                },
+                "fees": {
+                    "description": "In kobo",
+                    "type": "integer",
+                    "example": 75
+                },
+                "id": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "order_id": "1042"
+                    }
+                },
+                "narration": {
+                    "type": "string",
+                    "example": "Bank transfer from ADA OBI"
+                },
+                "note": {
+                    "description": "Private to the owner",
+                    "type": "string",
+                    "example": "Printer toner for the shop"
+                },
+                "rank": {
+                    "description": "Higher is a better match",
+                    "type": "number",
+                    "example": 0.6079
+                },
+                "reference": {
+                    "type": "string",
+                    "example": "TXN_1700000000_ab12cd34"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "success"
+                },
+                "tags": {
+                    "description": "Private to the owner",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "office",
+                        "supplies"
+                    ]
+                },
+                "transfer_group_id": {
+                    "description": "Shared by both legs of a transfer",
+                    "type": "string",
+                    "example": "TRF_9f86d081884c7d659a2feaa0"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "deposit"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.SetTransactionCategoryRequest": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "description": "Null hands the transaction back to the rules",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.SetUserTierRequest": {
+            "type": "object",
+            "required": [
+                "tier"
+            ],
+            "properties": {
+                "tier": {
+                    "type": "string",
+                    "example": "verified"
+                }
+            }
+        },
+        "handlers.StatementPreferenceRequest": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "description": "Any statement format; unchanged when empty",
+                    "type": "string",
+                    "example": "pdf"
+                },
+                "monthly": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "handlers.StatementResponse": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "download_url": {
+                    "description": "Set once ready",
+                    "type": "string",
+                    "example": "/wallet/statements/3f2c.../download"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "format": {
+                    "description": "csv, pdf, ofx, qfx, qif or camt053",
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "line_count": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.StatementStatus"
+                },
+                "to": {
+                    "description": "Inclusive",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.SubmitOTPRequest": {
+            "type": "object",
+            "required": [
+                "otp"
+            ],
+            "properties": {
+                "otp": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "handlers.TagCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "tag": {
+                    "type": "string",
+                    "example": "supplies"
+                }
+            }
+        },
+        "handlers.TransactionDetail": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "In kobo",
+                    "type": "integer",
+                    "example": 5000
+                },
+                "applied_at": {
+                    "description": "When it moved the balance",
+                    "type": "string"
+                },
+                "attachments": {
+                    "description": "Private to the owner",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TransactionAttachment"
+                    }
+                },
+                "balance_after": {
+                    "type": "integer",
+                    "example": 125000
+                },
+                "balance_before": {
+                    "description": "Unset until the transaction moves the balance",
+                    "type": "integer",
+                    "example": 120000
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "channel": {
+                    "type": "string",
+                    "example": "card"
+                },
+                "counterparty": {
+                    "$ref": "#/definitions/handlers.Counterparty"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "NGN"
+                },
+                "fees": {
+                    "description": "In kobo",
+                    "type": "integer",
+                    "example": 75
+                },
+                "gateway_response": {
+                    "type": "string",
+                    "example": "Approved"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "order_id": "1042"
+                    }
+                },
+                "narration": {
+                    "type": "string",
+                    "example": "Bank transfer from ADA OBI"
+                },
+                "note": {
+                    "description": "Private to the owner",
+                    "type": "string",
+                    "example": "Printer toner for the shop"
+                },
+                "paid_at": {
+                    "type": "string"
+                },
+                "paired_leg": {
+                    "$ref": "#/definitions/handlers.TransferLeg"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "paystack"
+                },
+                "reference": {
+                    "type": "string",
+                    "example": "TXN_1700000000_ab12cd34"
+                },
+                "review_reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "success"
+                },
+                "tags": {
+                    "description": "Private to the owner",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "office",
+                        "supplies"
+                    ]
+                },
+                "transfer_group_id": {
+                    "description": "Shared by both legs of a transfer",
+                    "type": "string",
+                    "example": "TRF_9f86d081884c7d659a2feaa0"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "deposit"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Only shown to admins",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.TransactionItem": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "In kobo",
+                    "type": "integer",
+                    "example": 5000
+                },
+                "balance_after": {
+                    "type": "integer",
+                    "example": 125000
+                },
+                "balance_before": {
+                    "description": "Unset until the transaction moves the balance",
+                    "type": "integer",
+                    "example": 120000
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "counterparty": {
+                    "$ref": "#/definitions/handlers.Counterparty"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "NGN"
+                },
+                "fees": {
+                    "description": "In kobo",
+                    "type": "integer",
+                    "example": 75
+                },
+                "id": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "order_id": "1042"
+                    }
+                },
+                "narration": {
                     "type": "string",
-                    "example": "production-api"
+                    "example": "Bank transfer from ADA OBI"
                 },
-                "permissions": {
+                "note": {
+                    "description": "Private to the owner",
                     "type": "string",
-                    "example": "[\"deposit\",\"transfer\",\"read\"]"
-                }
-            }
-        },
-        "handlers.CreateAPIKeyRequest": {
-            "type": "object",
-            "required": [
-                "expiry",
-                "name",
-                "permissions"
-            ],
-            "properties": {
-                "expiry": {
+                    "example": "Printer toner for the shop"
+                },
+                "reference": {
                     "type": "string",
-                    "example": "1D"
+                    "example": "TXN_1700000000_ab12cd34"
                 },
-                "name": {
+                "status": {
                     "type": "string",
-                    "example": "production-api"
+                    "example": "success"
                 },
-                "permissions": {
+                "tags": {
+                    "description": "Private to the owner",
                     "type": "array",
                     "items": {
                         "type": "string"
                     },
                     "example": [
-                        "deposit",
-                        "transfer",
-                        "read"
+                        "office",
+                        "supplies"
                     ]
+                },
+                "transfer_group_id": {
+                    "description": "Shared by both legs of a transfer",
+                    "type": "string",
+                    "example": "TRF_9f86d081884c7d659a2feaa0"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "deposit"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.CreateAPIKeyResponse": {
+        "handlers.TransactionPage": {
             "type": "object",
             "properties": {
-                "api_key": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.TransactionItem"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/handlers.Pagination"
+                }
+            }
+        },
+        "handlers.TransferLeg": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "reference": {
                     "type": "string",
-                    "example": "sk_live_xxxxx"
+                    "example": "TXN_1700000000_ab12cd35"
                 },
-                "expires_at": {
+                "status": {
                     "type": "string",
-                    "example": "2025-12-11T12:00:00Z"
+                    "example": "success"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "credit"
                 }
             }
         },
-        "handlers.DepositRequest": {
+        "handlers.TransferRequest": {
             "type": "object",
             "required": [
-                "amount"
+                "amount",
+                "wallet_number"
             ],
             "properties": {
                 "amount": {
                     "type": "integer",
-                    "example": 5000
+                    "example": 3000
+                },
+                "metadata": {
+                    "description": "Up to 20 keys, kept on both sides",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "order_id": "1042"
+                    }
+                },
+                "narration": {
+                    "description": "Shown to both sides",
+                    "type": "string",
+                    "example": "Payment for order 1042"
+                },
+                "wallet_number": {
+                    "type": "string",
+                    "example": "1234567890123"
                 }
             }
         },
-        "handlers.DepositResponse": {
+        "handlers.VirtualAccountResponse": {
             "type": "object",
             "properties": {
-                "authorization_url": {
+                "account_name": {
                     "type": "string",
-                    "example": "https://checkout.paystack.com/..."
+                    "example": "WALLET/JOHN DOE"
                 },
-                "reference": {
+                "account_number": {
                     "type": "string",
-                    "example": "TXN_1234567890"
+                    "example": "9930000000"
+                },
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "bank_name": {
+                    "type": "string",
+                    "example": "Wema Bank"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "NGN"
                 }
             }
         },
-        "handlers.RolloverAPIKeyRequest": {
+        "models.Category": {
             "type": "object",
-            "required": [
-                "expired_key_id",
-                "expiry"
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CategoryRule": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "string"
+                },
+                "counterparty": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "narration_contains": {
+                    "description": "Ignoring case",
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "Transaction type",
+                    "type": "string"
+                }
+            }
+        },
+        "models.DisputeStatus": {
+            "type": "string",
+            "enum": [
+                "open",
+                "under_review",
+                "won",
+                "lost"
             ],
+            "x-enum-comments": {
+                "DisputeStatusLost": "The customer got the money back",
+                "DisputeStatusOpen": "Waiting on us to accept or contest it",
+                "DisputeStatusUnderReview": "Responded to, waiting on the customer's bank",
+                "DisputeStatusWon": "Hold released or debit reversed"
+            },
+            "x-enum-descriptions": [
+                "Waiting on us to accept or contest it",
+                "Responded to, waiting on the customer's bank",
+                "Hold released or debit reversed",
+                "The customer got the money back"
+            ],
+            "x-enum-varnames": [
+                "DisputeStatusOpen",
+                "DisputeStatusUnderReview",
+                "DisputeStatusWon",
+                "DisputeStatusLost"
+            ]
+        },
+        "models.PayoutBatch": {
+            "type": "object",
             "properties": {
-                "expired_key_id": {
-                    "type": "string",
-                    "example": "uuid-here"
+                "completed_at": {
+                    "type": "string"
                 },
-                "expiry": {
-                    "type": "string",
-                    "example": "1M"
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "line_count": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PayoutLine"
+                    }
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "reference": {
+                    "description": "Same as the debit transaction's",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.PayoutBatchStatus"
+                },
+                "total_amount": {
+                    "description": "In kobo",
+                    "type": "integer"
+                },
+                "transaction_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.TransactionResponse": {
+        "models.PayoutBatchStatus": {
+            "type": "string",
+            "enum": [
+                "processing",
+                "completed",
+                "partially_failed",
+                "failed"
+            ],
+            "x-enum-comments": {
+                "PayoutBatchStatusCompleted": "Every line was paid",
+                "PayoutBatchStatusFailed": "Every line failed and was credited back",
+                "PayoutBatchStatusPartiallyFailed": "Some lines failed and were credited back",
+                "PayoutBatchStatusProcessing": "Some lines are not final yet"
+            },
+            "x-enum-descriptions": [
+                "Some lines are not final yet",
+                "Every line was paid",
+                "Some lines failed and were credited back",
+                "Every line failed and was credited back"
+            ],
+            "x-enum-varnames": [
+                "PayoutBatchStatusProcessing",
+                "PayoutBatchStatusCompleted",
+                "PayoutBatchStatusPartiallyFailed",
+                "PayoutBatchStatusFailed"
+            ]
+        },
+        "models.PayoutLine": {
             "type": "object",
             "properties": {
+                "account_name": {
+                    "description": "As resolved by the provider",
+                    "type": "string"
+                },
+                "account_number": {
+                    "type": "string"
+                },
                 "amount": {
-                    "type": "integer",
-                    "example": 5000
+                    "description": "In kobo",
+                    "type": "integer"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "bank_code": {
+                    "type": "string"
+                },
+                "batch_id": {
+                    "type": "string"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "failure_reason": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "line_number": {
+                    "description": "Line in the uploaded file",
+                    "type": "integer"
+                },
+                "narration": {
+                    "type": "string"
+                },
+                "reference": {
+                    "description": "Transfer reference of the latest attempt",
+                    "type": "string"
                 },
                 "status": {
-                    "type": "string",
-                    "example": "success"
+                    "$ref": "#/definitions/models.PayoutLineStatus"
                 },
-                "type": {
-                    "type": "string",
-                    "example": "deposit"
+                "transfer_code": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.TransferRequest": {
+        "models.PayoutLineStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "processing",
+                "success",
+                "failed"
+            ],
+            "x-enum-comments": {
+                "PayoutLineStatusFailed": "Credited back to the wallet, can be retried",
+                "PayoutLineStatusPending": "Not sent to the provider yet",
+                "PayoutLineStatusProcessing": "Sent, waiting on the transfer outcome"
+            },
+            "x-enum-descriptions": [
+                "Not sent to the provider yet",
+                "Sent, waiting on the transfer outcome",
+                "",
+                "Credited back to the wallet, can be retried"
+            ],
+            "x-enum-varnames": [
+                "PayoutLineStatusPending",
+                "PayoutLineStatusProcessing",
+                "PayoutLineStatusSuccess",
+                "PayoutLineStatusFailed"
+            ]
+        },
+        "models.StatementDelivery": {
             "type": "object",
-            "required": [
-                "amount",
-                "wallet_number"
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Where it was last sent",
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "line_count": {
+                    "type": "integer"
+                },
+                "period": {
+                    "description": "First day of the month covered",
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.StatementDeliveryStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StatementDeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "sending",
+                "sent",
+                "failed",
+                "skipped"
+            ],
+            "x-enum-comments": {
+                "StatementDeliveryFailed": "Retried on later runs until out of attempts",
+                "StatementDeliveryPending": "Waiting to be sent",
+                "StatementDeliverySkipped": "The user had opted out"
+            },
+            "x-enum-descriptions": [
+                "Waiting to be sent",
+                "",
+                "",
+                "Retried on later runs until out of attempts",
+                "The user had opted out"
             ],
+            "x-enum-varnames": [
+                "StatementDeliveryPending",
+                "StatementDeliverySending",
+                "StatementDeliverySent",
+                "StatementDeliveryFailed",
+                "StatementDeliverySkipped"
+            ]
+        },
+        "models.StatementPreference": {
+            "type": "object",
             "properties": {
-                "amount": {
-                    "type": "integer",
-                    "example": 3000
+                "format": {
+                    "type": "string"
                 },
-                "wallet_number": {
-                    "type": "string",
-                    "example": "1234567890123"
+                "monthly": {
+                    "description": "Email a statement for each month",
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StatementStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "ready",
+                "failed"
+            ],
+            "x-enum-comments": {
+                "StatementStatusPending": "Waiting to be generated in the background"
+            },
+            "x-enum-descriptions": [
+                "Waiting to be generated in the background",
+                "",
+                ""
+            ],
+            "x-enum-varnames": [
+                "StatementStatusPending",
+                "StatementStatusReady",
+                "StatementStatusFailed"
+            ]
+        },
+        "models.TransactionAttachment": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "In bytes",
+                    "type": "integer"
+                },
+                "transaction_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "reconcile.Issue": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "description": "What Paystack reported, in kobo",
+                    "type": "integer"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "expected": {
+                    "description": "What we recorded, in kobo",
+                    "type": "integer"
+                },
+                "kind": {
+                    "$ref": "#/definitions/reconcile.IssueKind"
+                },
+                "line": {
+                    "description": "Line in the file, when the issue comes from one",
+                    "type": "integer"
+                },
+                "reference": {
+                    "type": "string"
+                }
+            }
+        },
+        "reconcile.IssueKind": {
+            "type": "string",
+            "enum": [
+                "missing_deposit",
+                "not_credited",
+                "amount_mismatch",
+                "fee_mismatch",
+                "unsettled",
+                "duplicate_in_file"
+            ],
+            "x-enum-comments": {
+                "IssueAmountMismatch": "Settled amount or currency differs from what we credited",
+                "IssueDuplicateInFile": "The same reference appears more than once",
+                "IssueFeeMismatch": "Fees differ from what the webhook reported",
+                "IssueMissingDeposit": "Paystack settled a reference we have no deposit for",
+                "IssueNotCredited": "Paystack settled it, but we did not credit the wallet",
+                "IssueUnsettled": "We credited it, but Paystack never settled it"
+            },
+            "x-enum-descriptions": [
+                "Paystack settled a reference we have no deposit for",
+                "Paystack settled it, but we did not credit the wallet",
+                "Settled amount or currency differs from what we credited",
+                "Fees differ from what the webhook reported",
+                "We credited it, but Paystack never settled it",
+                "The same reference appears more than once"
+            ],
+            "x-enum-varnames": [
+                "IssueMissingDeposit",
+                "IssueNotCredited",
+                "IssueAmountMismatch",
+                "IssueFeeMismatch",
+                "IssueUnsettled",
+                "IssueDuplicateInFile"
+            ]
+        },
+        "reconcile.Report": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "fees": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "issues": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/reconcile.Issue"
+                    }
+                },
+                "lines": {
+                    "type": "integer"
+                },
+                "matched": {
+                    "type": "integer"
+                },
+                "net_settled": {
+                    "type": "integer"
+                },
+                "settled_amount": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.DisputeEvidence": {
+            "type": "object",
+            "properties": {
+                "customer_email": {
+                    "type": "string"
+                },
+                "customer_name": {
+                    "type": "string"
+                },
+                "customer_phone": {
+                    "type": "string"
+                },
+                "delivery_address": {
+                    "type": "string"
+                },
+                "delivery_date": {
+                    "type": "string"
+                },
+                "service_details": {
+                    "type": "string"
                 }
             }
         }
@@ -784,7 +5359,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "Wallet Service API",
-	Description:      "Backend wallet service with Paystack integration, Google OAuth, and API key management",
+	Description:      "Backend wallet service with Paystack and Flutterwave integration, Google OAuth, and API key management",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"wallet-service/config"
+	"wallet-service/database"
+	"wallet-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAnalyticsDays   = 30
+	defaultTopCounterparty = 5
+	maxTopCounterparty     = 50
+	maxAnalyticsPeriods    = 1000
+)
+
+// flowColumns sum the money into and out of the wallet over the rows they
+// aggregate, from the balance each transaction moved.
+const flowColumns = `COALESCE(SUM(GREATEST(balance_after - balance_before, 0)), 0) AS inflow,
+	COALESCE(SUM(GREATEST(balance_before - balance_after, 0)), 0) AS outflow,
+	COUNT(*) FILTER (WHERE balance_after > balance_before) AS inflow_count,
+	COUNT(*) FILTER (WHERE balance_after < balance_before) AS outflow_count`
+
+// Flow is money in and out over a set of transactions, in kobo.
+type Flow struct {
+	Inflow       int64 `json:"inflow" example:"2500000"`
+	Outflow      int64 `json:"outflow" example:"1800000"`
+	Net          int64 `json:"net" example:"700000"`
+	InflowCount  int64 `json:"inflow_count" example:"4"`
+	OutflowCount int64 `json:"outflow_count" example:"12"`
+}
+
+type AnalyticsTotals struct {
+	Flow
+	AverageInflow  int64 `json:"average_inflow" example:"625000"`
+	AverageOutflow int64 `json:"average_outflow" example:"150000"`
+	AverageSize    int64 `json:"average_size" example:"268750"` // Of all transactions, either way
+}
+
+type FlowPeriod struct {
+	Start string `json:"start" example:"2024-03-01"` // First day of the day, week (Monday) or month
+	Flow
+}
+
+type CounterpartyFlow struct {
+	Counterparty
+	Flow
+}
+
+type CategoryFlow struct {
+	CategoryID *string `json:"category_id"` // Null for uncategorized transactions
+	Name       string  `json:"name" example:"Groceries"`
+	Flow
+}
+
+type AnalyticsResponse struct {
+	From              string             `json:"from" example:"2024-03-01"`
+	To                string             `json:"to" example:"2024-03-31"`
+	Interval          string             `json:"interval" example:"week"`
+	Currency          string             `json:"currency" example:"NGN"`
+	Totals            AnalyticsTotals    `json:"totals"`
+	Series            []FlowPeriod       `json:"series"`
+	TopCounterparties []CounterpartyFlow `json:"top_counterparties"`
+	Categories        []CategoryFlow     `json:"categories"`
+	GeneratedAt       time.Time          `json:"generated_at"`
+}
+
+// FlowSums is what the analytics queries scan into.
+type FlowSums struct {
+	Inflow       int64
+	Outflow      int64
+	InflowCount  int64
+	OutflowCount int64
+}
+
+func (r FlowSums) flow() Flow {
+	return Flow{
+		Inflow:       r.Inflow,
+		Outflow:      r.Outflow,
+		Net:          r.Inflow - r.Outflow,
+		InflowCount:  r.InflowCount,
+		OutflowCount: r.OutflowCount,
+	}
+}
+
+// analyticsCache keeps computed analytics for ANALYTICS_CACHE_TTL. Entries
+// are keyed on the wallet's last update, so any balance change misses.
+var analyticsCache = struct {
+	sync.Mutex
+	entries map[string]analyticsCacheEntry
+}{entries: make(map[string]analyticsCacheEntry)}
+
+type analyticsCacheEntry struct {
+	userID    string
+	response  *AnalyticsResponse
+	expiresAt time.Time
+}
+
+// GetAnalytics godoc
+// @Summary Get spending analytics
+// @Description Money in and out of the authenticated user's wallet between two dates (inclusive, UTC): totals and averages, a series by day, week or month, the wallets most money moved with, and a breakdown by category. Counted from the balance changes transactions made, so failed and pending transactions are left out. Results are cached briefly and recomputed once the balance changes.
+// @Tags Analytics
+// @Produce json
+// @Param from query string false "First day, YYYY-MM-DD (default 29 days before to)"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Param interval query string false "day, week or month (default day)"
+// @Param top query int false "Number of top counterparties (default 5, max 50)"
+// @Success 200 {object} AnalyticsResponse
+// @Failure 400 {object} map[string]interface{} "Invalid dates or interval, or too many periods"
+// @Failure 404 {object} map[string]interface{} "Wallet not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/analytics [get]
+func GetAnalytics(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	to := startOfDay(time.Now())
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date (YYYY-MM-DD)"})
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, 1-defaultAnalyticsDays)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date (YYYY-MM-DD)"})
+			return
+		}
+		from = parsed
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+
+	interval := c.DefaultQuery("interval", "day")
+	if interval != "day" && interval != "week" && interval != "month" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be day, week or month"})
+		return
+	}
+
+	if analyticsPeriods(from, to, interval) > maxAnalyticsPeriods {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d periods at a time; use a longer interval", maxAnalyticsPeriods)})
+		return
+	}
+
+	top := defaultTopCounterparty
+	if value := c.Query("top"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "top must be a positive number"})
+			return
+		}
+		top = min(n, maxTopCounterparty)
+	}
+
+	var wallet models.Wallet
+	if err := database.DB.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+		return
+	}
+
+	key := strings.Join([]string{wallet.UserID, from.Format(time.DateOnly), to.Format(time.DateOnly),
+		interval, strconv.Itoa(top), wallet.UpdatedAt.Format(time.RFC3339Nano)}, "|")
+	if response := cachedAnalytics(key); response != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	categorizeForRead(wallet.UserID)
+	response, err := computeAnalytics(wallet.UserID, from, to, interval, top)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute analytics"})
+		return
+	}
+	cacheAnalytics(key, wallet.UserID, response)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func computeAnalytics(userID string, from, to time.Time, interval string, top int) (*AnalyticsResponse, error) {
+	response := &AnalyticsResponse{
+		From:              from.Format(time.DateOnly),
+		To:                to.Format(time.DateOnly),
+		Interval:          interval,
+		Currency:          defaultCurrency,
+		Series:            []FlowPeriod{},
+		TopCounterparties: []CounterpartyFlow{},
+		Categories:        []CategoryFlow{},
+		GeneratedAt:       time.Now(),
+	}
+	applied := database.DB.Model(&models.Transaction{}).
+		Where("user_id = ? AND applied_at >= ? AND applied_at < ?", userID, from, to.AddDate(0, 0, 1))
+
+	var totals FlowSums
+	if err := applied.Session(&gorm.Session{}).Select(flowColumns).Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+	response.Totals.Flow = totals.flow()
+	if totals.InflowCount > 0 {
+		response.Totals.AverageInflow = totals.Inflow / totals.InflowCount
+	}
+	if totals.OutflowCount > 0 {
+		response.Totals.AverageOutflow = totals.Outflow / totals.OutflowCount
+	}
+	if count := totals.InflowCount + totals.OutflowCount; count > 0 {
+		response.Totals.AverageSize = (totals.Inflow + totals.Outflow) / count
+	}
+
+	// interval is one of day, week or month, checked by the caller
+	var periods []struct {
+		Period time.Time
+		FlowSums
+	}
+	if err := applied.Session(&gorm.Session{}).
+		Select(fmt.Sprintf("date_trunc('%s', applied_at AT TIME ZONE 'UTC') AS period, %s", interval, flowColumns)).
+		Group("period").Order("period").Scan(&periods).Error; err != nil {
+		return nil, err
+	}
+	byPeriod := make(map[string]FlowSums, len(periods))
+	for _, row := range periods {
+		byPeriod[row.Period.Format(time.DateOnly)] = row.FlowSums
+	}
+	// Every period is listed, so charts need not fill in gaps
+	for start := truncateToInterval(from, interval); !start.After(to); start = nextInterval(start, interval) {
+		day := start.Format(time.DateOnly)
+		response.Series = append(response.Series, FlowPeriod{Start: day, Flow: byPeriod[day].flow()})
+	}
+
+	var counterpartyRows []struct {
+		WalletID string
+		FlowSums
+	}
+	if err := applied.Session(&gorm.Session{}).
+		Select("COALESCE(recipient_wallet_id, sender_wallet_id) AS wallet_id, " + flowColumns).
+		Where("COALESCE(recipient_wallet_id, sender_wallet_id) IS NOT NULL").
+		Group("COALESCE(recipient_wallet_id, sender_wallet_id)").
+		Order("SUM(ABS(balance_after - balance_before)) DESC").Limit(top).
+		Scan(&counterpartyRows).Error; err != nil {
+		return nil, err
+	}
+	walletIDs := make([]string, 0, len(counterpartyRows))
+	for _, row := range counterpartyRows {
+		walletIDs = append(walletIDs, row.WalletID)
+	}
+	counterparties, err := counterpartiesByWalletID(walletIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range counterpartyRows {
+		response.TopCounterparties = append(response.TopCounterparties, CounterpartyFlow{
+			Counterparty: counterparties[row.WalletID],
+			Flow:         row.flow(),
+		})
+	}
+
+	var categoryRows []struct {
+		CategoryID *string
+		FlowSums
+	}
+	if err := applied.Session(&gorm.Session{}).Select("category_id, " + flowColumns).
+		Group("category_id").Order("SUM(ABS(balance_after - balance_before)) DESC").
+		Scan(&categoryRows).Error; err != nil {
+		return nil, err
+	}
+	var categories []models.Category
+	if err := database.DB.Where("user_id = ?", userID).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+	for _, row := range categoryRows {
+		name := "Uncategorized"
+		if row.CategoryID != nil {
+			name = names[*row.CategoryID]
+		}
+		response.Categories = append(response.Categories, CategoryFlow{
+			CategoryID: row.CategoryID,
+			Name:       name,
+			Flow:       row.flow(),
+		})
+	}
+
+	return response, nil
+}
+
+// truncateToInterval is the start of the day, week (Monday, as Postgres'
+// date_trunc has it) or month t falls in.
+func truncateToInterval(t time.Time, interval string) time.Time {
+	t = startOfDay(t)
+	switch interval {
+	case "week":
+		return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	case "month":
+		return startOfMonth(t)
+	}
+	return t
+}
+
+// analyticsPeriods is how many days, weeks or months the series from from to
+// to has.
+func analyticsPeriods(from, to time.Time, interval string) int {
+	switch interval {
+	case "week":
+		return int(truncateToInterval(to, interval).Sub(truncateToInterval(from, interval)).Hours()/24)/7 + 1
+	case "month":
+		return (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	}
+	return int(to.Sub(from).Hours()/24) + 1
+}
+
+func nextInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "month":
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+func cachedAnalytics(key string) *AnalyticsResponse {
+	analyticsCache.Lock()
+	defer analyticsCache.Unlock()
+
+	entry, ok := analyticsCache.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil
+	}
+	return entry.response
+}
+
+func cacheAnalytics(key, userID string, response *AnalyticsResponse) {
+	ttl := config.AppConfig.AnalyticsCacheTTL
+	if ttl <= 0 {
+		return
+	}
+
+	analyticsCache.Lock()
+	defer analyticsCache.Unlock()
+
+	now := time.Now()
+	for k, entry := range analyticsCache.entries {
+		if now.After(entry.expiresAt) {
+			delete(analyticsCache.entries, k)
+		}
+	}
+	analyticsCache.entries[key] = analyticsCacheEntry{userID: userID, response: response, expiresAt: now.Add(ttl)}
+}
+
+// invalidateAnalytics drops a user's cached analytics after their categories
+// change, which the cache key does not cover.
+func invalidateAnalytics(userID string) {
+	analyticsCache.Lock()
+	defer analyticsCache.Unlock()
+
+	for key, entry := range analyticsCache.entries {
+		if entry.userID == userID {
+			delete(analyticsCache.entries, key)
+		}
+	}
+}
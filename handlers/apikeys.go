@@ -52,6 +52,7 @@ func CreateAPIKey(c *gin.Context) {
 		"deposit":  true,
 		"transfer": true,
 		"read":     true,
+		"write":    true,
 	}
 	
 	for _, perm := range req.Permissions {
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"wallet-service/config"
+	"wallet-service/database"
+	"wallet-service/models"
+	"wallet-service/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxAttachments bounds the files kept on one transaction.
+const maxAttachments = 10
+
+var errTooManyAttachments = errors.New("too many attachments")
+
+// attachmentTypes are the receipt formats users can upload, by the content
+// type sniffed from the file itself.
+var attachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// attachmentStorage keeps the files attached to transactions.
+var attachmentStorage storage.Storage
+
+// InitAttachmentStorage sets up where transaction attachments are kept.
+func InitAttachmentStorage() {
+	attachmentStorage = storage.NewLocalStorage(config.AppConfig.AttachmentDir)
+}
+
+// UploadAttachment godoc
+// @Summary Attach a file to a transaction
+// @Description Attach a receipt or other document to one of the authenticated user's transactions. JPEG, PNG, GIF, WebP and PDF files are accepted, up to ATTACHMENT_MAX_BYTES each and 10 per transaction. Attachments are never shown to the other side of a transfer.
+// @Tags Wallet
+// @Accept multipart/form-data
+// @Produce json
+// @Param reference path string true "Transaction reference"
+// @Param file formData file true "Receipt or document"
+// @Success 201 {object} models.TransactionAttachment
+// @Failure 400 {object} map[string]interface{} "Missing, oversized or unsupported file, or too many attachments"
+// @Failure 404 {object} map[string]interface{} "Transaction not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/transactions/{reference}/attachments [post]
+func UploadAttachment(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	// Leaves room for the multipart framing around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.AppConfig.AttachmentMaxBytes+1<<20)
+
+	var transaction models.Transaction
+	if err := database.DB.Where("reference = ? AND user_id = ?", c.Param("reference"), userID).
+		First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required"})
+		return
+	}
+	if fileHeader.Size > config.AppConfig.AttachmentMaxBytes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Files can be at most %d bytes", config.AppConfig.AttachmentMaxBytes)})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read file"})
+		return
+	}
+	defer file.Close()
+
+	// The declared type is not trusted; the content decides
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read file"})
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if !attachmentTypes[contentType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only JPEG, PNG, GIF, WebP and PDF files can be attached"})
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read file"})
+		return
+	}
+
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to attach file"})
+		return
+	}
+	attachment := models.TransactionAttachment{
+		TransactionID: transaction.ID,
+		UserID:        transaction.UserID,
+		Filename:      attachmentFilename(fileHeader.Filename),
+		ContentType:   contentType,
+		Size:          fileHeader.Size,
+		StorageKey:    transaction.UserID + "/" + hex.EncodeToString(suffix),
+	}
+
+	if err := attachmentStorage.Put(c.Request.Context(), attachment.StorageKey, file); err != nil {
+		log.Printf("Failed to store attachment for transaction %s: %v", transaction.Reference, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to attach file"})
+		return
+	}
+
+	// The transaction row is locked so concurrent uploads cannot both pass
+	// the limit
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			First(&models.Transaction{}, "id = ?", transaction.ID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.TransactionAttachment{}).
+			Where("transaction_id = ?", transaction.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxAttachments {
+			return errTooManyAttachments
+		}
+
+		return tx.Create(&attachment).Error
+	})
+	if err != nil {
+		deleteAttachmentFile(attachment.StorageKey)
+		if errors.Is(err, errTooManyAttachments) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A transaction can have at most %d attachments", maxAttachments)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to attach file"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// ListAttachments godoc
+// @Summary List a transaction's attachments
+// @Description The files attached to one of the authenticated user's transactions, oldest first
+// @Tags Wallet
+// @Produce json
+// @Param reference path string true "Transaction reference"
+// @Success 200 {array} models.TransactionAttachment
+// @Failure 404 {object} map[string]interface{} "Transaction not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/transactions/{reference}/attachments [get]
+func ListAttachments(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var transaction models.Transaction
+	if err := database.DB.Select("id").Where("reference = ? AND user_id = ?", c.Param("reference"), userID).
+		First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	attachments, err := loadAttachments(transaction.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment godoc
+// @Summary Download an attachment
+// @Description Download a file attached to one of the authenticated user's transactions
+// @Tags Wallet
+// @Produce application/octet-stream
+// @Param reference path string true "Transaction reference"
+// @Param id path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]interface{} "Attachment not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/transactions/{reference}/attachments/{id} [get]
+func DownloadAttachment(c *gin.Context) {
+	attachment, ok := findAttachment(c)
+	if !ok {
+		return
+	}
+
+	file, err := attachmentStorage.Open(c.Request.Context(), attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachment"})
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, file, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment godoc
+// @Summary Delete an attachment
+// @Description Remove a file from one of the authenticated user's transactions
+// @Tags Wallet
+// @Produce json
+// @Param reference path string true "Transaction reference"
+// @Param id path string true "Attachment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{} "Attachment not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/transactions/{reference}/attachments/{id} [delete]
+func DeleteAttachment(c *gin.Context) {
+	attachment, ok := findAttachment(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	deleteAttachmentFile(attachment.StorageKey)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted"})
+}
+
+// findAttachment loads the attachment in the path, answering 404 when it is
+// not on one of the caller's transactions.
+func findAttachment(c *gin.Context) (models.TransactionAttachment, bool) {
+	userID, _ := c.Get("user_id")
+
+	var attachment models.TransactionAttachment
+	err := database.DB.
+		Joins("JOIN transactions ON transactions.id = transaction_attachments.transaction_id").
+		Where("transaction_attachments.id = ? AND transactions.reference = ? AND transaction_attachments.user_id = ?",
+			c.Param("id"), c.Param("reference"), userID).
+		First(&attachment).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return attachment, false
+	}
+	return attachment, true
+}
+
+func loadAttachments(transactionID string) ([]models.TransactionAttachment, error) {
+	attachments := []models.TransactionAttachment{}
+	err := database.DB.Where("transaction_id = ?", transactionID).Order("created_at").Find(&attachments).Error
+	return attachments, err
+}
+
+// deleteAttachmentFile removes a stored file; a failure only leaves an
+// orphaned file behind, so it is logged rather than returned.
+func deleteAttachmentFile(key string) {
+	if err := attachmentStorage.Delete(context.Background(), key); err != nil {
+		log.Printf("Failed to delete attachment file %s: %v", key, err)
+	}
+}
+
+// attachmentFilename keeps the base name of an uploaded file without
+// control characters, falling back to a generic name.
+func attachmentFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "attachment"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[len(runes)-255:])
+	}
+	return name
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttachmentFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "receipt.pdf", want: "receipt.pdf"},
+		{name: "unix path", in: "/home/ada/receipts/receipt.pdf", want: "receipt.pdf"},
+		{name: "windows path", in: `C:\Users\ada\receipt.pdf`, want: "receipt.pdf"},
+		{name: "traversal", in: "../../etc/passwd", want: "passwd"},
+		{name: "control characters", in: "rec\r\neipt\x00.pdf", want: "receipt.pdf"},
+		{name: "surrounding spaces", in: "  receipt.pdf  ", want: "receipt.pdf"},
+		{name: "unicode", in: "reçu été.pdf", want: "reçu été.pdf"},
+		{name: "empty", in: "", want: "attachment"},
+		{name: "only spaces", in: "   ", want: "attachment"},
+		{name: "dot", in: ".", want: "attachment"},
+		{name: "dot dot", in: "..", want: "attachment"},
+		{name: "parent directory", in: "../", want: "attachment"},
+		{name: "root", in: "/", want: "attachment"},
+		{name: "too long keeps the extension", in: strings.Repeat("é", 300) + ".pdf", want: strings.Repeat("é", 251) + ".pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attachmentFilename(tt.in); got != tt.want {
+				t.Errorf("attachmentFilename(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
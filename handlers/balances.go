@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"time"
+	"wallet-service/database"
+	"wallet-service/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// balanceSnapshotInterval is how often the snapshot job checks for a newly
+// closed day; each wallet is snapshotted once per day.
+const balanceSnapshotInterval = time.Hour
+
+var errBalanceHistoryUnavailable = errors.New("balance history unavailable")
+
+// StartBalanceSnapshots records every wallet's closing balance for each day
+// (UTC) once the day is over.
+func StartBalanceSnapshots() {
+	go func() {
+		ticker := time.NewTicker(balanceSnapshotInterval)
+		defer ticker.Stop()
+
+		for {
+			takeBalanceSnapshots(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func takeBalanceSnapshots(now time.Time) {
+	day := startOfDay(now).AddDate(0, 0, -1)
+	closesAt := day.AddDate(0, 0, 1)
+
+	taken := database.DB.Model(&models.BalanceSnapshot{}).Select("wallet_id").Where("date = ?", day)
+
+	var wallets []models.Wallet
+	err := database.DB.Where("created_at < ? AND id NOT IN (?)", closesAt, taken).
+		FindInBatches(&wallets, 500, func(tx *gorm.DB, batch int) error {
+			for _, wallet := range wallets {
+				if err := snapshotWallet(wallet, day); err != nil {
+					// Picked up again on the next run
+					log.Printf("Failed to snapshot balance of wallet %s: %v", wallet.WalletNumber, err)
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		log.Println("Failed to load wallets for balance snapshots:", err)
+	}
+}
+
+// snapshotWallet records a wallet's closing balance for day, filling in any
+// days missed since its last snapshot. A wallet's first snapshot is worked
+// back from its current balance.
+func snapshotWallet(wallet models.Wallet, day time.Time) error {
+	var last models.BalanceSnapshot
+	err := database.DB.Where("wallet_id = ? AND date < ?", wallet.ID, day).Order("date DESC").First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return database.DB.Transaction(func(tx *gorm.DB) error {
+			// Keep the balance still while the later changes are summed
+			var current models.Wallet
+			if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+				Where("id = ?", wallet.ID).First(&current).Error; err != nil {
+				return err
+			}
+			since, err := balanceChanges(tx, wallet.UserID, day.AddDate(0, 0, 1), time.Time{})
+			if err != nil {
+				return err
+			}
+			return saveSnapshot(tx, wallet, day, current.Balance-since)
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	balance := last.Balance
+	for d := startOfDay(last.Date).AddDate(0, 0, 1); !d.After(day); d = d.AddDate(0, 0, 1) {
+		changes, err := balanceChanges(database.DB, wallet.UserID, d, d.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+		balance += changes
+		if err := saveSnapshot(database.DB, wallet, d, balance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func saveSnapshot(tx *gorm.DB, wallet models.Wallet, day time.Time, balance int64) error {
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.BalanceSnapshot{
+		WalletID: wallet.ID,
+		UserID:   wallet.UserID,
+		Date:     day,
+		Balance:  balance,
+	}).Error
+}
+
+// balanceChanges sums how much a user's transactions applied in [from, until)
+// moved their balance. A zero until means up to now.
+func balanceChanges(tx *gorm.DB, userID string, from, until time.Time) (int64, error) {
+	query := tx.Model(&models.Transaction{}).
+		Where("user_id = ? AND applied_at IS NOT NULL AND applied_at >= ?", userID, from)
+	if !until.IsZero() {
+		query = query.Where("applied_at < ?", until)
+	}
+
+	var total int64
+	err := query.Select("COALESCE(SUM(balance_after - balance_before), 0)").Scan(&total).Error
+	return total, err
+}
+
+// balanceAt works out a wallet's balance just before until from the last
+// snapshot that closed by then plus the changes since. Without one it works
+// back from the current balance. It fails with errBalanceHistoryUnavailable
+// when until predates the balance changes being recorded on transactions.
+func balanceAt(wallet models.Wallet, until time.Time) (int64, error) {
+	// Transactions from before balance changes were recorded moved the
+	// balance when they were paid or created. Later edits, such as notes or
+	// categories, must not hide history, so updated_at is not used.
+	var legacy struct{ LastMove *time.Time }
+	if err := database.DB.Model(&models.Transaction{}).
+		Select("MAX(COALESCE(paid_at, created_at)) AS last_move").
+		Where("user_id = ? AND status = ? AND applied_at IS NULL", wallet.UserID, models.TransactionStatusSuccess).
+		Scan(&legacy).Error; err != nil {
+		return 0, err
+	}
+	if legacy.LastMove != nil && until.Before(*legacy.LastMove) {
+		return 0, errBalanceHistoryUnavailable
+	}
+
+	var snapshot models.BalanceSnapshot
+	err := database.DB.Where("wallet_id = ? AND date <= ?", wallet.ID, startOfDay(until).AddDate(0, 0, -1)).
+		Order("date DESC").First(&snapshot).Error
+	if err == nil {
+		changes, err := balanceChanges(database.DB, wallet.UserID, startOfDay(snapshot.Date).AddDate(0, 0, 1), until)
+		if err != nil {
+			return 0, err
+		}
+		return snapshot.Balance + changes, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	since, err := balanceChanges(database.DB, wallet.UserID, until, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	return wallet.Balance - since, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
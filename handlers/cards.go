@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"wallet-service/database"
+	"wallet-service/models"
+	"wallet-service/services"
+	"wallet-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SavedCardResponse struct {
+	ID        string `json:"id" example:"uuid-here"`
+	Brand     string `json:"brand" example:"visa"`
+	Last4     string `json:"last4" example:"4081"`
+	Bank      string `json:"bank" example:"TEST BANK"`
+	ExpMonth  string `json:"exp_month" example:"12"`
+	ExpYear   string `json:"exp_year" example:"2030"`
+	IsExpired bool   `json:"is_expired" example:"false"`
+	CreatedAt string `json:"created_at" example:"2025-01-01T12:00:00Z"`
+}
+
+type CardTopUpRequest struct {
+	Amount int64 `json:"amount" binding:"required,gt=0" example:"5000"`
+}
+
+type SubmitOTPRequest struct {
+	OTP string `json:"otp" binding:"required" example:"123456"`
+}
+
+type CardTopUpResponse struct {
+	Reference   string `json:"reference" example:"TXN_1234567890"`
+	Status      string `json:"status" example:"success"`
+	Action      string `json:"action,omitempty" example:"send_otp"`
+	DisplayText string `json:"display_text,omitempty" example:"Please enter the OTP sent to your phone"`
+}
+
+// saveCard stores or refreshes a reusable card authorization for the user.
+// Paystack issues a new authorization code for the same card on every
+// payment, so cards are keyed by signature.
+func saveCard(tx *gorm.DB, userID, provider string, card *services.CardAuthorization) error {
+	savedCard := models.SavedCard{
+		UserID:            userID,
+		Provider:          provider,
+		AuthorizationCode: card.AuthorizationCode,
+		Signature:         card.Signature,
+		Email:             card.Email,
+		Last4:             card.Last4,
+		Brand:             card.Brand,
+		Bank:              card.Bank,
+		ExpMonth:          card.ExpMonth,
+		ExpYear:           card.ExpYear,
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "signature"}},
+		DoUpdates: clause.AssignmentColumns([]string{"authorization_code", "email", "exp_month", "exp_year", "updated_at"}),
+	}).Create(&savedCard).Error
+}
+
+// ListSavedCards godoc
+// @Summary List saved cards
+// @Description Cards saved from previous successful card deposits, usable for one-click top-ups
+// @Tags Cards
+// @Produce json
+// @Success 200 {array} SavedCardResponse
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/cards [get]
+func ListSavedCards(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var cards []models.SavedCard
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&cards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved cards"})
+		return
+	}
+
+	response := []SavedCardResponse{}
+	for _, card := range cards {
+		response = append(response, SavedCardResponse{
+			ID:        card.ID,
+			Brand:     card.Brand,
+			Last4:     card.Last4,
+			Bank:      card.Bank,
+			ExpMonth:  card.ExpMonth,
+			ExpYear:   card.ExpYear,
+			IsExpired: card.IsExpired(),
+			CreatedAt: card.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RemoveSavedCard godoc
+// @Summary Remove a saved card
+// @Description Delete a saved card so it can no longer be charged
+// @Tags Cards
+// @Produce json
+// @Param id path string true "Saved card ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{} "Card not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/cards/{id} [delete]
+func RemoveSavedCard(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	result := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.SavedCard{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove card"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Card removed successfully"})
+}
+
+// TopUpWithSavedCard godoc
+// @Summary Top up with a saved card
+// @Description Charge a saved card without going through checkout. A 202 response means the bank wants more: submit the OTP for action send_otp, or wait for the customer to approve on their phone for action pay_offline. A 202 without an action means the provider did not answer in time; the deposit stays pending until its webhook arrives or its status is checked.
+// @Tags Cards
+// @Accept json
+// @Produce json
+// @Param id path string true "Saved card ID"
+// @Param X-Idempotency-Key header string false "Idempotency key to prevent duplicate charges (optional but recommended)"
+// @Param request body CardTopUpRequest true "Amount in kobo"
+// @Success 200 {object} CardTopUpResponse
+// @Success 202 {object} CardTopUpResponse
+// @Failure 400 {object} map[string]interface{} "Bad request, card expired or deposit rule broken (see DepositRuleError)"
+// @Failure 402 {object} map[string]interface{} "Card declined"
+// @Failure 404 {object} map[string]interface{} "Card not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 503 {object} map[string]interface{} "Payment provider unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/cards/{id}/charge [post]
+func TopUpWithSavedCard(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req CardTopUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount is required and must be greater than 0"})
+		return
+	}
+
+	var card models.SavedCard
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&card).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
+		return
+	}
+
+	if card.IsExpired() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Card has expired"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	reference := utils.GenerateReference()
+
+	transaction := models.Transaction{
+		UserID:    userID.(string),
+		Type:      models.TransactionTypeDeposit,
+		Amount:    req.Amount,
+		Currency:  defaultCurrency,
+		Status:    models.TransactionStatusPending,
+		Reference: reference,
+		Provider:  card.Provider,
+		Channel:   "card",
+	}
+
+	if !createDeposit(c, user, &transaction, []string{"card"}) {
+		return
+	}
+
+	result, err := paystack.ChargeAuthorization(c.Request.Context(), services.AuthorizationChargeRequest{
+		AuthorizationCode: card.AuthorizationCode,
+		Email:             card.Email,
+		Amount:            req.Amount,
+		Currency:          defaultCurrency,
+		Reference:         reference,
+	})
+	if err != nil {
+		log.Printf("Charge authorization error for %s: %v", reference, err)
+
+		if outcomeUnknown(err) {
+			// The card may have been charged; the webhook or a status
+			// check settles the deposit
+			log.Printf("ALERT: card top-up %s left pending, provider outcome unknown", reference)
+			c.JSON(http.StatusAccepted, CardTopUpResponse{
+				Reference:   reference,
+				Status:      string(models.TransactionStatusPending),
+				DisplayText: "The charge is still being confirmed",
+			})
+			return
+		}
+
+		if failErr := markDepositFailed(reference, err.Error()); failErr != nil {
+			log.Println("Failed to mark deposit failed:", failErr)
+		}
+		respondPaymentError(c, err)
+		return
+	}
+
+	respondCardCharge(c, reference, result)
+}
+
+// SubmitDepositOTP godoc
+// @Summary Submit OTP for a card top-up
+// @Description Complete a saved card top-up that returned action send_otp
+// @Tags Cards
+// @Accept json
+// @Produce json
+// @Param reference path string true "Deposit reference"
+// @Param request body SubmitOTPRequest true "OTP sent to the customer"
+// @Success 200 {object} CardTopUpResponse
+// @Success 202 {object} CardTopUpResponse
+// @Failure 400 {object} map[string]interface{} "Bad request or OTP rejected"
+// @Failure 402 {object} map[string]interface{} "Card declined"
+// @Failure 404 {object} map[string]interface{} "Transaction not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/deposit/{reference}/otp [post]
+func SubmitDepositOTP(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	reference := c.Param("reference")
+
+	var req SubmitOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "OTP is required"})
+		return
+	}
+
+	var transaction models.Transaction
+	if err := database.DB.Where("reference = ? AND user_id = ? AND type = ? AND status = ?",
+		reference, userID, models.TransactionTypeDeposit, models.TransactionStatusPending).
+		First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	if transaction.Provider != paystack.Name() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "OTP is not supported for this deposit"})
+		return
+	}
+
+	result, err := paystack.SubmitOTP(c.Request.Context(), reference, req.OTP)
+	if err != nil {
+		log.Printf("Submit OTP error for %s: %v", reference, err)
+		respondPaymentError(c, err)
+		return
+	}
+
+	respondCardCharge(c, reference, result)
+}
+
+// respondCardCharge applies the outcome of a saved card charge and tells the
+// caller what, if anything, still has to happen.
+func respondCardCharge(c *gin.Context, reference string, result *services.AuthorizationChargeResult) {
+	switch result.Status {
+	case "success":
+		if err := processSuccessfulDeposit(*result.Charge); err != nil {
+			log.Println("Failed to process deposit:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process deposit"})
+			return
+		}
+
+		var transaction models.Transaction
+		if err := database.DB.Where("reference = ?", reference).First(&transaction).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, CardTopUpResponse{
+			Reference: reference,
+			Status:    string(transaction.Status),
+		})
+	case "failed":
+		if err := markDepositFailed(reference, result.Charge.GatewayResponse); err != nil {
+			log.Println("Failed to mark deposit failed:", err)
+		}
+		c.JSON(http.StatusPaymentRequired, gin.H{
+			"error":   "Card was declined",
+			"details": result.Charge.GatewayResponse,
+		})
+	default:
+		// send_otp, pay_offline or still processing: the webhook finishes the job
+		c.JSON(http.StatusAccepted, CardTopUpResponse{
+			Reference:   reference,
+			Status:      string(models.TransactionStatusPending),
+			Action:      result.Status,
+			DisplayText: result.DisplayText,
+		})
+	}
+}
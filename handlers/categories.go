@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+	"wallet-service/database"
+	"wallet-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxCategoryNameLength = 50
+
+// categorizableTypes are the transaction types a rule can match on.
+var categorizableTypes = map[models.TransactionType]bool{
+	models.TransactionTypeDeposit:            true,
+	models.TransactionTypeTransfer:           true,
+	models.TransactionTypeCredit:             true,
+	models.TransactionTypeRefund:             true,
+	models.TransactionTypeChargeback:         true,
+	models.TransactionTypePayout:             true,
+	models.TransactionTypePayoutReversal:     true,
+	models.TransactionTypeRefundReversal:     true,
+	models.TransactionTypeChargebackReversal: true,
+}
+
+type CreateCategoryRequest struct {
+	Name string `json:"name" binding:"required" example:"Groceries"`
+}
+
+type CreateCategoryRuleRequest struct {
+	CategoryID        string `json:"category_id" binding:"required"`
+	Priority          int    `json:"priority" example:"10"` // Lower wins when several rules match
+	Type              string `json:"type" example:"transfer"`
+	Counterparty      string `json:"counterparty" example:"1234567890123"` // Wallet number
+	NarrationContains string `json:"narration_contains" example:"rent"`
+}
+
+type SetTransactionCategoryRequest struct {
+	CategoryID *string `json:"category_id"` // Null hands the transaction back to the rules
+}
+
+// ListCategories godoc
+// @Summary List categories
+// @Description Retrieve the authenticated user's transaction categories, by name
+// @Tags Categories
+// @Produce json
+// @Success 200 {array} models.Category
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/categories [get]
+func ListCategories(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var categories []models.Category
+	if err := database.DB.Where("user_id = ?", userID).Order("name").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// CreateCategory godoc
+// @Summary Create a category
+// @Description Add a category transactions can be filed under, by hand or by rules
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param request body CreateCategoryRequest true "Category"
+// @Success 201 {object} models.Category
+// @Failure 400 {object} map[string]interface{} "Invalid name"
+// @Failure 409 {object} map[string]interface{} "Category already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/categories [post]
+func CreateCategory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxCategoryNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Name must be 1 to %d characters", maxCategoryNameLength)})
+		return
+	}
+
+	var existing int64
+	if err := database.DB.Model(&models.Category{}).Where("user_id = ? AND name = ?", userID, name).Count(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category already exists"})
+		return
+	}
+
+	category := models.Category{UserID: userID.(string), Name: name}
+	if err := database.DB.Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// DeleteCategory godoc
+// @Summary Delete a category
+// @Description Delete a category and the rules filing transactions under it. Its transactions are run through the remaining rules again.
+// @Tags Categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/categories/{id} [delete]
+func DeleteCategory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var category models.Category
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&category).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("category_id = ?", category.ID).Delete(&models.CategoryRule{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Transaction{}).Where("category_id = ?", category.ID).UpdateColumns(map[string]interface{}{
+			"category_id":     nil,
+			"category_source": models.CategorySourcePending,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&category).Error; err != nil {
+			return err
+		}
+		return recategorizeTransactions(tx, category.UserID)
+	})
+	if err != nil {
+		log.Printf("Failed to delete category %s: %v", category.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		return
+	}
+	invalidateAnalytics(category.UserID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
+}
+
+// ListCategoryRules godoc
+// @Summary List categorization rules
+// @Description Retrieve the authenticated user's rules for filing transactions under categories, in the order they are tried
+// @Tags Categories
+// @Produce json
+// @Success 200 {array} models.CategoryRule
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/category-rules [get]
+func ListCategoryRules(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var rules []models.CategoryRule
+	if err := database.DB.Where("user_id = ?", userID).Order("priority, created_at").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateCategoryRule godoc
+// @Summary Create a categorization rule
+// @Description Automatically file transactions under a category by type, counterparty wallet and/or text in the narration. A rule matches when all the conditions it sets do; when several match, the lowest priority wins (the oldest rule on a tie). Existing transactions not categorized by hand are run through the rules again.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param request body CreateCategoryRuleRequest true "Rule"
+// @Success 201 {object} models.CategoryRule
+// @Failure 400 {object} map[string]interface{} "Invalid rule"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/category-rules [post]
+func CreateCategoryRule(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req CreateCategoryRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category_id is required"})
+		return
+	}
+
+	rule := models.CategoryRule{
+		UserID:            userID.(string),
+		CategoryID:        req.CategoryID,
+		Priority:          req.Priority,
+		Type:              strings.TrimSpace(req.Type),
+		NarrationContains: strings.TrimSpace(req.NarrationContains),
+	}
+	if rule.Type == "" && req.Counterparty == "" && rule.NarrationContains == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A rule needs a type, counterparty or narration_contains"})
+		return
+	}
+	if rule.Type != "" && !categorizableTypes[models.TransactionType(rule.Type)] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown transaction type: " + rule.Type})
+		return
+	}
+	if utf8.RuneCountInString(rule.NarrationContains) > maxNarrationLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("narration_contains must be at most %d characters", maxNarrationLength)})
+		return
+	}
+
+	var category models.Category
+	if err := database.DB.Where("id = ? AND user_id = ?", req.CategoryID, userID).First(&category).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+		return
+	}
+
+	if req.Counterparty != "" {
+		var wallet models.Wallet
+		if err := database.DB.Select("id", "wallet_number").Where("wallet_number = ?", req.Counterparty).First(&wallet).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Counterparty wallet not found"})
+			return
+		}
+		rule.CounterpartyWalletID = &wallet.ID
+		rule.CounterpartyWalletNumber = wallet.WalletNumber
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rule).Error; err != nil {
+			return err
+		}
+		return recategorizeTransactions(tx, rule.UserID)
+	})
+	if err != nil {
+		log.Printf("Failed to create category rule for user %s: %v", rule.UserID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create rule"})
+		return
+	}
+	invalidateAnalytics(rule.UserID)
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// DeleteCategoryRule godoc
+// @Summary Delete a categorization rule
+// @Description Delete a rule. Transactions it categorized are run through the remaining rules again.
+// @Tags Categories
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/category-rules/{id} [delete]
+func DeleteCategoryRule(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var rule models.CategoryRule
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&rule).Error; err != nil {
+			return err
+		}
+		return recategorizeTransactions(tx, rule.UserID)
+	})
+	if err != nil {
+		log.Printf("Failed to delete category rule %s: %v", rule.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete rule"})
+		return
+	}
+	invalidateAnalytics(rule.UserID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rule deleted"})
+}
+
+// SetTransactionCategory godoc
+// @Summary Categorize a transaction
+// @Description File one of the authenticated user's transactions under a category by hand; rules never change it afterwards. A null category_id removes it and lets the rules categorize the transaction again.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param reference path string true "Transaction reference"
+// @Param request body SetTransactionCategoryRequest true "Category"
+// @Success 200 {object} TransactionItem
+// @Failure 400 {object} map[string]interface{} "Category not found"
+// @Failure 404 {object} map[string]interface{} "Transaction not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/transactions/{reference}/category [put]
+func SetTransactionCategory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req SetTransactionCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var transaction models.Transaction
+	if err := database.DB.Where("reference = ? AND user_id = ?", c.Param("reference"), userID).
+		First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"category_id":     nil,
+		"category_source": models.CategorySourcePending,
+	}
+	if req.CategoryID != nil {
+		var category models.Category
+		if err := database.DB.Where("id = ? AND user_id = ?", *req.CategoryID, userID).First(&category).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+			return
+		}
+		updates["category_id"] = category.ID
+		updates["category_source"] = models.CategorySourceUser
+	}
+
+	if err := database.DB.Model(&transaction).UpdateColumns(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to categorize transaction"})
+		return
+	}
+	categorizeForRead(transaction.UserID)
+	invalidateAnalytics(transaction.UserID)
+
+	if err := database.DB.First(&transaction, "id = ?", transaction.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to categorize transaction"})
+		return
+	}
+	counterparties, err := loadCounterparties([]models.Transaction{transaction})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to categorize transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toTransactionItem(transaction, counterparties))
+}
+
+// categorizeTransactions runs a user's rules over the transactions they have
+// not seen yet, in one statement so that transactions recorded meanwhile
+// wait for the next run rather than being skipped. Categories leave
+// updated_at alone.
+func categorizeTransactions(tx *gorm.DB, userID string) error {
+	var rules []models.CategoryRule
+	if err := tx.Where("user_id = ?", userID).Order("priority, created_at").Find(&rules).Error; err != nil {
+		return err
+	}
+
+	categoryID := "NULL"
+	source := "'" + string(models.CategorySourceNone) + "'"
+	var idArgs, sourceArgs []interface{}
+	if len(rules) > 0 {
+		var ids, sources strings.Builder
+		ids.WriteString("CASE")
+		sources.WriteString("CASE")
+		for _, rule := range rules {
+			condition, args := categoryRuleCondition(rule)
+			ids.WriteString(" WHEN " + condition + " THEN CAST(? AS uuid)")
+			idArgs = append(append(idArgs, args...), rule.CategoryID)
+			sources.WriteString(" WHEN " + condition + " THEN ?")
+			sourceArgs = append(append(sourceArgs, args...), models.CategorySourceRule)
+		}
+		ids.WriteString(" END")
+		sources.WriteString(" ELSE ? END")
+		sourceArgs = append(sourceArgs, models.CategorySourceNone)
+		categoryID, source = ids.String(), sources.String()
+	}
+
+	return tx.Model(&models.Transaction{}).
+		Where("user_id = ? AND category_source = ?", userID, models.CategorySourcePending).
+		UpdateColumns(map[string]interface{}{
+			"category_id":     gorm.Expr(categoryID, idArgs...),
+			"category_source": gorm.Expr(source, sourceArgs...),
+		}).Error
+}
+
+// recategorizeTransactions runs all of a user's transactions not categorized
+// by hand through the rules again, after the rules changed.
+func recategorizeTransactions(tx *gorm.DB, userID string) error {
+	if err := tx.Model(&models.Transaction{}).
+		Where("user_id = ? AND category_source IN ?", userID,
+			[]models.CategorySource{models.CategorySourceRule, models.CategorySourceNone}).
+		UpdateColumns(map[string]interface{}{
+			"category_id":     nil,
+			"category_source": models.CategorySourcePending,
+		}).Error; err != nil {
+		return err
+	}
+	return categorizeTransactions(tx, userID)
+}
+
+// categoryRuleCondition is the SQL condition matching a rule's transactions.
+func categoryRuleCondition(rule models.CategoryRule) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if rule.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, rule.Type)
+	}
+	if rule.CounterpartyWalletID != nil {
+		conditions = append(conditions, "COALESCE(recipient_wallet_id, sender_wallet_id) = ?")
+		args = append(args, *rule.CounterpartyWalletID)
+	}
+	if rule.NarrationContains != "" {
+		conditions = append(conditions, "narration ILIKE ?")
+		args = append(args, "%"+escapeLike(rule.NarrationContains)+"%")
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")", args
+}
+
+// categorizeForRead brings a user's categories up to date before they are
+// shown. A failure only leaves the newest transactions uncategorized.
+func categorizeForRead(userID string) {
+	if err := categorizeTransactions(database.DB, userID); err != nil {
+		log.Printf("Failed to categorize transactions for user %s: %v", userID, err)
+	}
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-service/config"
+	"wallet-service/database"
+	"wallet-service/models"
+	"wallet-service/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Deposit rules, as reported in DepositRuleError.Rule.
+const (
+	RuleMinAmount      = "min_amount"
+	RuleMaxAmount      = "max_amount"
+	RuleChannel        = "channel"
+	RuleTierMaxAmount  = "tier_max_amount"
+	RuleTierDailyLimit = "tier_daily_limit"
+)
+
+// DepositRuleError is returned (400) when a deposit breaks one of the
+// configured deposit rules.
+type DepositRuleError struct {
+	Error    string   `json:"error" example:"Amount is below the minimum deposit of 5000 NGN"`
+	Rule     string   `json:"rule" example:"min_amount"`
+	Limit    int64    `json:"limit,omitempty" example:"5000"`
+	Amount   int64    `json:"amount,omitempty" example:"100"`
+	Currency string   `json:"currency,omitempty" example:"NGN"`
+	Tier     string   `json:"tier,omitempty" example:"basic"`
+	Channel  string   `json:"channel,omitempty"`
+	Allowed  []string `json:"allowed,omitempty"`
+}
+
+// allowedDepositChannels are the checkout channels deposits may use.
+func allowedDepositChannels() []string {
+	var allowed []string
+	for _, channel := range config.AppConfig.DepositRules.Channels {
+		if services.IsCheckoutChannel(channel) {
+			allowed = append(allowed, channel)
+		}
+	}
+	if len(allowed) == 0 {
+		return services.CheckoutChannels
+	}
+	return allowed
+}
+
+// checkDepositRules applies the configured deposit rules to a deposit of
+// amount in currency through channels. It returns the first rule broken, or
+// nil. Deposits still pending or already credited count towards the daily
+// limit, so it cannot be dodged by opening many checkouts.
+func checkDepositRules(db *gorm.DB, user models.User, currency string, amount int64, channels []string) (*DepositRuleError, error) {
+	rules := config.AppConfig.DepositRules
+
+	allowed := allowedDepositChannels()
+	for _, channel := range channels {
+		if !containsString(allowed, channel) {
+			return &DepositRuleError{
+				Error:   "Payment channel not allowed: " + channel,
+				Rule:    RuleChannel,
+				Channel: channel,
+				Allowed: allowed,
+			}, nil
+		}
+	}
+
+	limits := rules.LimitsFor(currency)
+	if limits.Min > 0 && amount < limits.Min {
+		return &DepositRuleError{
+			Error:    fmt.Sprintf("Amount is below the minimum deposit of %d %s", limits.Min, currency),
+			Rule:     RuleMinAmount,
+			Limit:    limits.Min,
+			Amount:   amount,
+			Currency: currency,
+		}, nil
+	}
+	if limits.Max > 0 && amount > limits.Max {
+		return &DepositRuleError{
+			Error:    fmt.Sprintf("Amount is above the maximum deposit of %d %s", limits.Max, currency),
+			Rule:     RuleMaxAmount,
+			Limit:    limits.Max,
+			Amount:   amount,
+			Currency: currency,
+		}, nil
+	}
+
+	tier := strings.ToLower(user.Tier)
+	if tier == "" {
+		tier = config.DefaultUserTier
+	}
+	tierLimits, ok := rules.Tiers[tier]
+	if !ok {
+		return nil, nil
+	}
+
+	if tierLimits.MaxAmount > 0 && amount > tierLimits.MaxAmount {
+		return &DepositRuleError{
+			Error:    fmt.Sprintf("Amount is above the %d %s per-deposit cap for the %s tier", tierLimits.MaxAmount, currency, tier),
+			Rule:     RuleTierMaxAmount,
+			Limit:    tierLimits.MaxAmount,
+			Amount:   amount,
+			Currency: currency,
+			Tier:     tier,
+		}, nil
+	}
+
+	if tierLimits.DailyLimit > 0 {
+		var deposited int64
+		if err := db.Model(&models.Transaction{}).
+			Where("user_id = ? AND type = ? AND currency = ? AND created_at > ?", user.ID, models.TransactionTypeDeposit, currency, time.Now().Add(-24*time.Hour)).
+			Where("status IN ?", []models.TransactionStatus{models.TransactionStatusPending, models.TransactionStatusSuccess, models.TransactionStatusReview}).
+			Select("COALESCE(SUM(amount), 0)").Scan(&deposited).Error; err != nil {
+			return nil, err
+		}
+
+		if deposited+amount > tierLimits.DailyLimit {
+			return &DepositRuleError{
+				Error:    fmt.Sprintf("Deposit would exceed the %d %s daily limit for the %s tier (%d used)", tierLimits.DailyLimit, currency, tier, deposited),
+				Rule:     RuleTierDailyLimit,
+				Limit:    tierLimits.DailyLimit,
+				Amount:   amount,
+				Currency: currency,
+				Tier:     tier,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// createDeposit records a pending deposit, through channels, once it passes
+// the deposit rules. The user's wallet is locked meanwhile, so deposits made
+// at the same time are checked one after the other and cannot together go
+// over the daily limit. It responds with the broken rule, or an internal
+// error, and returns false when the deposit was not created.
+func createDeposit(c *gin.Context, user models.User, transaction *models.Transaction, channels []string) bool {
+	var violation *DepositRuleError
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", user.ID).First(&models.Wallet{}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		violation, err = checkDepositRules(tx, user, transaction.Currency, transaction.Amount, channels)
+		if err != nil || violation != nil {
+			return err
+		}
+		return tx.Create(transaction).Error
+	})
+	if err != nil {
+		log.Println("Failed to create deposit:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+		return false
+	}
+	if violation != nil {
+		c.JSON(http.StatusBadRequest, violation)
+		return false
+	}
+	return true
+}
+
+type SetUserTierRequest struct {
+	Tier string `json:"tier" binding:"required" example:"verified"`
+}
+
+// SetUserTier godoc
+// @Summary Set a user's tier (admin)
+// @Description Move a user to another tier, which decides the deposit caps that apply to them and whether they may make bulk payouts. The tier must be the default tier or one configured in DEPOSIT_TIER_LIMITS or PAYOUT_TIERS.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body SetUserTierRequest true "New tier"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "Unknown tier"
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Security BearerAuth
+// @Router /admin/users/{id}/tier [put]
+func SetUserTier(c *gin.Context) {
+	var req SetUserTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tier is required"})
+		return
+	}
+
+	tier := strings.ToLower(strings.TrimSpace(req.Tier))
+	_, ok := config.AppConfig.DepositRules.Tiers[tier]
+	if !ok && tier != config.DefaultUserTier && !containsString(config.AppConfig.PayoutTiers, tier) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tier: " + tier})
+		return
+	}
+
+	result := database.DB.Model(&models.User{}).Where("id = ?", c.Param("id")).Update("tier", tier)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tier"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "tier": tier})
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,521 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"wallet-service/config"
+	"wallet-service/database"
+	"wallet-service/models"
+	"wallet-service/services"
+	"wallet-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DisputeResponse is a dispute with the evidence submitted for it.
+type DisputeResponse struct {
+	models.Dispute
+	Evidence *services.DisputeEvidence `json:"evidence,omitempty"`
+}
+
+func toDisputeResponse(dispute models.Dispute) DisputeResponse {
+	response := DisputeResponse{Dispute: dispute}
+	if dispute.Evidence != nil {
+		var evidence services.DisputeEvidence
+		if err := json.Unmarshal([]byte(*dispute.Evidence), &evidence); err == nil {
+			response.Evidence = &evidence
+		}
+	}
+	return response
+}
+
+// processDisputeEvent applies a dispute webhook. Disputes are opened on the
+// first event seen for them, so a missed create does not lose the case.
+func processDisputeEvent(providerName, eventType string, event services.Dispute) error {
+	dispute, err := openDispute(providerName, event)
+	if err != nil || dispute == nil {
+		return err
+	}
+
+	if eventType != services.EventDisputeResolved {
+		return nil
+	}
+	if event.Outcome == "" {
+		// The hold stays until someone settles it by hand
+		log.Printf("ALERT: %s dispute %s on %s resolved as %q, which is not understood; left %s",
+			providerName, dispute.ProviderDisputeID, dispute.DepositReference, event.Resolution, dispute.Status)
+		return nil
+	}
+	return settleDispute(dispute.ID, event.Outcome, event.Status)
+}
+
+// openDispute records a dispute against the deposit it was raised on and
+// holds or debits the wallet according to DISPUTE_POLICY. For a dispute
+// already on record it only refreshes the provider's status and due date.
+// It returns nil when no deposit matches the disputed charge.
+func openDispute(providerName string, event services.Dispute) (*models.Dispute, error) {
+	var dispute models.Dispute
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("provider = ? AND provider_dispute_id = ?", providerName, event.ID).First(&dispute).Error
+		if err == nil {
+			if dispute.Status == models.DisputeStatusWon || dispute.Status == models.DisputeStatusLost {
+				return nil
+			}
+			if event.DueAt != nil && (dispute.DueAt == nil || !event.DueAt.Equal(*dispute.DueAt)) {
+				log.Printf("ALERT: dispute %s on %s now due %s", dispute.ProviderDisputeID, dispute.DepositReference, event.DueAt.Format(time.RFC3339))
+			}
+			return tx.Model(&dispute).Updates(map[string]interface{}{
+				"provider_status": event.Status,
+				"due_at":          event.DueAt,
+			}).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var deposit models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference = ? AND type = ? AND provider = ?", event.Reference, models.TransactionTypeDeposit, providerName).
+			First(&deposit).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("ALERT: %s dispute %s for %s (%d) matches no deposit", providerName, event.ID, event.Reference, event.Amount)
+				return nil
+			}
+			return err
+		}
+
+		// What was refunded already cannot be taken back a second time
+		refunded, err := refundedAmount(tx, deposit.ID)
+		if err != nil {
+			return err
+		}
+		amount := event.Amount
+		if amount <= 0 || amount > deposit.Amount-refunded {
+			amount = deposit.Amount - refunded
+		}
+
+		dispute = models.Dispute{
+			UserID:            deposit.UserID,
+			DepositID:         deposit.ID,
+			DepositReference:  deposit.Reference,
+			Provider:          providerName,
+			ProviderDisputeID: event.ID,
+			Amount:            amount,
+			Currency:          deposit.Currency,
+			Category:          event.Category,
+			Policy:            config.AppConfig.DisputePolicy,
+			Status:            models.DisputeStatusOpen,
+			ProviderStatus:    event.Status,
+			DueAt:             event.DueAt,
+		}
+
+		if deposit.Status != models.TransactionStatusSuccess {
+			// Nothing was credited, so there is nothing to take back
+			dispute.Policy = models.DisputePolicyNone
+			log.Printf("ALERT: dispute opened on %s deposit %s, Amount: %d", deposit.Status, deposit.Reference, amount)
+			return tx.Create(&dispute).Error
+		}
+		if amount <= 0 {
+			// Everything was refunded already, so the wallet is left alone and
+			// the dispute keeps the amount the customer claims
+			dispute.Policy = models.DisputePolicyNone
+			dispute.Amount = min(max(event.Amount, 0), deposit.Amount)
+			if dispute.Amount == 0 {
+				dispute.Amount = deposit.Amount
+			}
+			log.Printf("ALERT: dispute opened on refunded deposit %s, Amount: %d", deposit.Reference, dispute.Amount)
+			return tx.Create(&dispute).Error
+		}
+
+		var wallet models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", deposit.UserID).First(&wallet).Error; err != nil {
+			return err
+		}
+
+		if dispute.Policy == config.DisputePolicyDebit {
+			wallet.Balance -= amount
+		} else {
+			wallet.HeldBalance += amount
+		}
+		if err := tx.Save(&wallet).Error; err != nil {
+			return err
+		}
+
+		chargeback := models.Transaction{
+			UserID:    deposit.UserID,
+			Type:      models.TransactionTypeChargeback,
+			Amount:    amount,
+			Currency:  deposit.Currency,
+			Status:    models.TransactionStatusPending,
+			Reference: utils.GenerateReference(),
+			Provider:  providerName,
+			Narration: "Chargeback on deposit " + deposit.Reference,
+		}
+		if dispute.Policy == config.DisputePolicyDebit {
+			applyBalance(&chargeback, wallet, -amount)
+		}
+		if err := tx.Create(&chargeback).Error; err != nil {
+			return err
+		}
+		dispute.TransactionID = &chargeback.ID
+
+		if err := tx.Create(&dispute).Error; err != nil {
+			return err
+		}
+
+		log.Printf("ALERT: dispute opened on deposit %s, Amount: %d, Policy: %s", deposit.Reference, amount, dispute.Policy)
+		if wallet.Available() < 0 {
+			// The money has already left the wallet; the shortfall is ours
+			// unless the user deposits again
+			log.Printf("ALERT: wallet %s is %d short of disputed deposit %s", wallet.WalletNumber, -wallet.Available(), deposit.Reference)
+		}
+		return nil
+	})
+	if err != nil || dispute.ID == "" {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+// settleDispute closes a dispute. A won dispute releases the hold or credits
+// the debit back; a lost one turns the hold into a debit. It is safe to call
+// more than once.
+func settleDispute(disputeID string, outcome services.DisputeOutcome, providerStatus string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var dispute models.Dispute
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&dispute, "id = ?", disputeID).Error; err != nil {
+			return err
+		}
+
+		if dispute.Status == models.DisputeStatusWon || dispute.Status == models.DisputeStatusLost {
+			log.Printf("Dispute already resolved: %s (%s)", dispute.ProviderDisputeID, dispute.Status)
+			return nil
+		}
+
+		now := time.Now()
+		dispute.Status = models.DisputeStatusWon
+		if outcome == services.DisputeOutcomeLost {
+			dispute.Status = models.DisputeStatusLost
+		}
+		dispute.ResolvedAt = &now
+		if providerStatus != "" {
+			dispute.ProviderStatus = providerStatus
+		}
+		if err := tx.Save(&dispute).Error; err != nil {
+			return err
+		}
+
+		if dispute.Policy == models.DisputePolicyNone {
+			log.Printf("Dispute %s: %s on uncredited deposit %s", dispute.Status, dispute.ProviderDisputeID, dispute.DepositReference)
+			return nil
+		}
+
+		var wallet models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", dispute.UserID).First(&wallet).Error; err != nil {
+			return err
+		}
+
+		lost := dispute.Status == models.DisputeStatusLost
+		switch dispute.Policy {
+		case config.DisputePolicyDebit:
+			if !lost {
+				wallet.Balance += dispute.Amount
+			}
+		default:
+			wallet.HeldBalance -= dispute.Amount
+			if lost {
+				wallet.Balance -= dispute.Amount
+			}
+		}
+		if err := tx.Save(&wallet).Error; err != nil {
+			return err
+		}
+
+		transactionStatus := models.TransactionStatusSuccess
+		gatewayResponse := "Dispute lost"
+		if !lost {
+			transactionStatus = models.TransactionStatusFailed
+			gatewayResponse = "Dispute won"
+		}
+		var chargeback models.Transaction
+		if err := tx.Where("id = ?", dispute.TransactionID).First(&chargeback).Error; err != nil {
+			return err
+		}
+		chargeback.Status = transactionStatus
+		chargeback.GatewayResponse = gatewayResponse
+		if lost && dispute.Policy != config.DisputePolicyDebit {
+			// Held disputes only move the balance now
+			applyBalance(&chargeback, wallet, -dispute.Amount)
+		}
+		if err := tx.Save(&chargeback).Error; err != nil {
+			return err
+		}
+
+		if !lost && dispute.Policy == config.DisputePolicyDebit {
+			reversal := models.Transaction{
+				UserID:          dispute.UserID,
+				Type:            models.TransactionTypeChargebackReversal,
+				Amount:          dispute.Amount,
+				Currency:        chargeback.Currency,
+				Status:          models.TransactionStatusSuccess,
+				Reference:       chargeback.Reference + "_rev",
+				Provider:        chargeback.Provider,
+				GatewayResponse: gatewayResponse,
+				Narration:       "Chargeback on deposit " + dispute.DepositReference + " reversed",
+			}
+			applyBalance(&reversal, wallet, dispute.Amount)
+			if err := tx.Create(&reversal).Error; err != nil {
+				return err
+			}
+		}
+
+		if lost {
+			log.Printf("ALERT: dispute lost on deposit %s, Amount: %d charged back, New Balance: %d", dispute.DepositReference, dispute.Amount, wallet.Balance)
+		} else {
+			log.Printf("Dispute won on deposit %s, Amount: %d released, New Balance: %d", dispute.DepositReference, dispute.Amount, wallet.Balance)
+		}
+		return nil
+	})
+}
+
+// ListDisputes godoc
+// @Summary List disputes (admin)
+// @Description Retrieve chargeback disputes raised against deposits, newest first
+// @Tags Admin
+// @Produce json
+// @Param status query string false "Only disputes with this status (open, under_review, won, lost)"
+// @Success 200 {array} DisputeResponse
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /admin/disputes [get]
+func ListDisputes(c *gin.Context) {
+	query := database.DB.Order("created_at DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var disputes []models.Dispute
+	if err := query.Find(&disputes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch disputes"})
+		return
+	}
+
+	response := make([]DisputeResponse, 0, len(disputes))
+	for _, dispute := range disputes {
+		response = append(response, toDisputeResponse(dispute))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDispute godoc
+// @Summary Get a dispute (admin)
+// @Description Retrieve one dispute with the evidence submitted for it
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} DisputeResponse
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Failure 404 {object} map[string]interface{} "Dispute not found"
+// @Security BearerAuth
+// @Router /admin/disputes/{id} [get]
+func GetDispute(c *gin.Context) {
+	var dispute models.Dispute
+	if err := database.DB.First(&dispute, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dispute not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toDisputeResponse(dispute))
+}
+
+type DisputeEvidenceRequest struct {
+	CustomerEmail   string `json:"customer_email" binding:"required,email" example:"customer@example.com"`
+	CustomerName    string `json:"customer_name" binding:"required" example:"Jane Doe"`
+	CustomerPhone   string `json:"customer_phone" binding:"required" example:"08012345678"`
+	ServiceDetails  string `json:"service_details" binding:"required" example:"Wallet top-up, funds spent on transfers"`
+	DeliveryAddress string `json:"delivery_address" example:"3 Example Street, Lagos"`
+	DeliveryDate    string `json:"delivery_date" example:"2025-01-31"` // YYYY-MM-DD
+}
+
+// SubmitDisputeEvidence godoc
+// @Summary Submit dispute evidence (admin)
+// @Description Send evidence to the payment provider for contesting an open dispute. The dispute can then be declined.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Param request body DisputeEvidenceRequest true "Evidence"
+// @Success 200 {object} DisputeResponse
+// @Failure 400 {object} map[string]interface{} "Invalid evidence or dispute already resolved"
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Failure 404 {object} map[string]interface{} "Dispute not found"
+// @Failure 502 {object} map[string]interface{} "Payment provider error"
+// @Security BearerAuth
+// @Router /admin/disputes/{id}/evidence [post]
+func SubmitDisputeEvidence(c *gin.Context) {
+	adminID, _ := c.Get("user_id")
+
+	var req DisputeEvidenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "customer_email, customer_name, customer_phone and service_details are required"})
+		return
+	}
+	if req.DeliveryDate != "" {
+		if _, err := time.Parse("2006-01-02", req.DeliveryDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "delivery_date must be YYYY-MM-DD"})
+			return
+		}
+	}
+
+	dispute, ok := loadRespondableDispute(c)
+	if !ok {
+		return
+	}
+
+	evidence := services.DisputeEvidence{
+		CustomerEmail:   req.CustomerEmail,
+		CustomerName:    req.CustomerName,
+		CustomerPhone:   req.CustomerPhone,
+		ServiceDetails:  req.ServiceDetails,
+		DeliveryAddress: req.DeliveryAddress,
+		DeliveryDate:    req.DeliveryDate,
+	}
+
+	evidenceID, err := paystack.AddDisputeEvidence(c.Request.Context(), dispute.ProviderDisputeID, evidence)
+	if err != nil {
+		log.Printf("Dispute evidence error for %s: %v", dispute.ProviderDisputeID, err)
+		respondPaymentError(c, err)
+		return
+	}
+
+	encoded, _ := json.Marshal(evidence)
+	evidenceJSON := string(encoded)
+	now := time.Now()
+	dispute.Evidence = &evidenceJSON
+	dispute.ProviderEvidenceID = evidenceID
+	dispute.EvidenceSubmittedAt = &now
+	dispute.RespondedBy = adminID.(string)
+	if err := database.DB.Save(&dispute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record evidence"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toDisputeResponse(dispute))
+}
+
+type ResolveDisputeRequest struct {
+	Resolution       string `json:"resolution" binding:"required,oneof=accept decline" example:"decline"` // accept refunds the customer, decline contests the dispute
+	Message          string `json:"message" binding:"required" example:"Customer received the funds"`
+	RefundAmount     int64  `json:"refund_amount" binding:"omitempty,gt=0" example:"5000"` // Defaults to the disputed amount
+	UploadedFilename string `json:"uploaded_filename" example:"qesp8a4df1xejihd9x5q"`      // Proof of refund uploaded to the provider, for accept
+}
+
+// ResolveDispute godoc
+// @Summary Accept or decline a dispute (admin)
+// @Description Accept a dispute, refunding the customer, or decline it with the evidence already submitted. The dispute moves to under_review until the provider reports the final outcome.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Param request body ResolveDisputeRequest true "Resolution"
+// @Success 200 {object} DisputeResponse
+// @Failure 400 {object} map[string]interface{} "Invalid resolution, no evidence or dispute already resolved"
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Failure 404 {object} map[string]interface{} "Dispute not found"
+// @Failure 502 {object} map[string]interface{} "Payment provider error"
+// @Security BearerAuth
+// @Router /admin/disputes/{id}/resolve [post]
+func ResolveDispute(c *gin.Context) {
+	adminID, _ := c.Get("user_id")
+
+	var req ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resolution must be accept or decline, and a message is required"})
+		return
+	}
+
+	dispute, ok := loadRespondableDispute(c)
+	if !ok {
+		return
+	}
+
+	resolution := services.DisputeResolutionRequest{
+		Message:      req.Message,
+		RefundAmount: req.RefundAmount,
+	}
+	if resolution.RefundAmount == 0 {
+		resolution.RefundAmount = dispute.Amount
+	}
+	if req.Resolution == "accept" {
+		resolution.Resolution = services.PaystackDisputeAccept
+		resolution.UploadedFilename = req.UploadedFilename
+	} else {
+		if dispute.ProviderEvidenceID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Submit evidence before declining a dispute"})
+			return
+		}
+		resolution.Resolution = services.PaystackDisputeDecline
+		resolution.Evidence = dispute.ProviderEvidenceID
+	}
+
+	result, err := paystack.ResolveDispute(c.Request.Context(), dispute.ProviderDisputeID, resolution)
+	if err != nil {
+		log.Printf("Dispute resolution error for %s: %v", dispute.ProviderDisputeID, err)
+		respondPaymentError(c, err)
+		return
+	}
+
+	if err := database.DB.Model(&dispute).Updates(map[string]interface{}{
+		"status":             models.DisputeStatusUnderReview,
+		"provider_status":    result.Status,
+		"resolution_message": req.Message,
+		"responded_by":       adminID.(string),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record resolution"})
+		return
+	}
+
+	if result.Outcome != "" {
+		if err := settleDispute(dispute.ID, result.Outcome, result.Status); err != nil {
+			log.Println("Failed to settle dispute:", err)
+		}
+	}
+
+	if err := database.DB.First(&dispute, "id = ?", dispute.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dispute"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toDisputeResponse(dispute))
+}
+
+// loadRespondableDispute loads the dispute named in the path, responding with
+// an error unless it is still open to a response through the provider's API.
+func loadRespondableDispute(c *gin.Context) (models.Dispute, bool) {
+	var dispute models.Dispute
+	if err := database.DB.First(&dispute, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dispute not found"})
+		return dispute, false
+	}
+
+	if dispute.Status == models.DisputeStatusWon || dispute.Status == models.DisputeStatusLost {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dispute is already resolved"})
+		return dispute, false
+	}
+	if dispute.Provider != paystack.Name() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Disputes with " + dispute.Provider + " must be handled on the provider's dashboard"})
+		return dispute, false
+	}
+	return dispute, true
+}
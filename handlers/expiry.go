@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"wallet-service/config"
+	"wallet-service/database"
+	"wallet-service/models"
+	"wallet-service/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// depositExpiryBatchSize bounds the provider calls made per sweep.
+const depositExpiryBatchSize = 100
+
+// StartDepositExpiry periodically abandons deposits left pending for longer
+// than DEPOSIT_EXPIRY. It does nothing when expiry is disabled.
+func StartDepositExpiry() {
+	if config.AppConfig.DepositExpiry <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(config.AppConfig.DepositExpiryInterval)
+		defer ticker.Stop()
+
+		for {
+			expireStaleDeposits(context.Background())
+			<-ticker.C
+		}
+	}()
+}
+
+func expireStaleDeposits(ctx context.Context) {
+	cutoff := time.Now().Add(-config.AppConfig.DepositExpiry)
+
+	// Deposits that could not be verified go to the back of the queue, so a
+	// provider failing on some cannot keep the rest from expiring
+	var deposits []models.Transaction
+	if err := database.DB.Where("type = ? AND status = ? AND created_at < ?", models.TransactionTypeDeposit, models.TransactionStatusPending, cutoff).
+		Order("expiry_checked_at NULLS FIRST, created_at").Limit(depositExpiryBatchSize).Find(&deposits).Error; err != nil {
+		log.Println("Failed to load stale deposits:", err)
+		return
+	}
+
+	for _, deposit := range deposits {
+		if err := closePendingDeposit(ctx, deposit, models.TransactionStatusAbandoned); err != nil {
+			// Left pending and retried on a later sweep
+			log.Printf("Failed to expire deposit %s: %v", deposit.Reference, err)
+			if err := database.DB.Model(&deposit).UpdateColumn("expiry_checked_at", time.Now()).Error; err != nil {
+				log.Printf("Failed to record expiry check of deposit %s: %v", deposit.Reference, err)
+			}
+		}
+	}
+}
+
+// closePendingDeposit gives up on a pending deposit, moving it to status. The
+// provider is asked first: a charge that did go through is credited (or
+// failed) instead. A provider that has never seen the reference, e.g. because
+// checkout initialization failed, does not block closing it.
+func closePendingDeposit(ctx context.Context, deposit models.Transaction, status models.TransactionStatus) error {
+	if provider, ok := paymentProviders.Get(deposit.Provider); ok {
+		charge, err := provider.VerifyCharge(ctx, deposit.Reference)
+
+		var validationErr *services.ValidationError
+		switch {
+		case err == nil:
+			switch charge.Status {
+			case services.ChargeStatusSuccess:
+				return processSuccessfulDeposit(*charge)
+			case services.ChargeStatusFailed:
+				return markDepositFailed(deposit.Reference, charge.GatewayResponse)
+			}
+		case errors.As(err, &validationErr):
+		default:
+			return err
+		}
+	}
+
+	result := database.DB.Model(&models.Transaction{}).
+		Where("id = ? AND status = ?", deposit.ID, models.TransactionStatusPending).
+		Update("status", status)
+	if result.Error == nil && result.RowsAffected > 0 {
+		log.Printf("Deposit %s %s", deposit.Reference, status)
+	}
+	return result.Error
+}
+
+// CancelDeposit godoc
+// @Summary Cancel a pending deposit
+// @Description Cancel one of your deposits that is still awaiting payment. The payment provider is checked first, so a deposit that was already paid is credited instead of cancelled. If the customer still pays a cancelled checkout, the deposit is credited.
+// @Tags Wallet
+// @Produce json
+// @Param reference path string true "Deposit reference"
+// @Success 200 {object} map[string]interface{} "Deposit cancelled"
+// @Failure 404 {object} map[string]interface{} "Transaction not found"
+// @Failure 409 {object} map[string]interface{} "Deposit is no longer pending"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 503 {object} map[string]interface{} "Payment provider unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/deposit/{reference}/cancel [post]
+func CancelDeposit(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	reference := c.Param("reference")
+
+	var transaction models.Transaction
+	if err := database.DB.Where("reference = ? AND user_id = ? AND type = ?", reference, userID, models.TransactionTypeDeposit).
+		First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	if transaction.Status == models.TransactionStatusPending {
+		if err := closePendingDeposit(c.Request.Context(), transaction, models.TransactionStatusCancelled); err != nil {
+			log.Println("Failed to cancel deposit:", err)
+			if services.IsUnavailable(err) {
+				respondPaymentError(c, err)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel deposit"})
+			return
+		}
+		if err := database.DB.First(&transaction, "id = ?", transaction.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction"})
+			return
+		}
+	}
+
+	if transaction.Status != models.TransactionStatusCancelled {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "Deposit is no longer pending",
+			"status": transaction.Status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reference": transaction.Reference,
+		"status":    transaction.Status,
+	})
+}
@@ -0,0 +1,509 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-service/database"
+	"wallet-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// Counterparty is the other wallet in a transfer.
+type Counterparty struct {
+	WalletNumber string `json:"wallet_number" example:"1234567890123"`
+	Name         string `json:"name,omitempty" example:"Ada Obi"`
+}
+
+type TransactionItem struct {
+	ID              string          `json:"id"`
+	Reference       string          `json:"reference" example:"TXN_1700000000_ab12cd34"`
+	Type            string          `json:"type" example:"deposit"`
+	Amount          int64           `json:"amount" example:"5000"` // In kobo
+	Currency        string          `json:"currency" example:"NGN"`
+	Status          string          `json:"status" example:"success"`
+	Fees            int64           `json:"fees" example:"75"` // In kobo
+	Narration       string          `json:"narration,omitempty" example:"Bank transfer from ADA OBI"`
+	BalanceBefore   *int64          `json:"balance_before,omitempty" example:"120000"` // Unset until the transaction moves the balance
+	BalanceAfter    *int64          `json:"balance_after,omitempty" example:"125000"`
+	Counterparty    *Counterparty   `json:"counterparty,omitempty"`
+	CategoryID      *string         `json:"category_id,omitempty"`
+	TransferGroupID string          `json:"transfer_group_id,omitempty" example:"TRF_9f86d081884c7d659a2feaa0"` // Shared by both legs of a transfer
+	Metadata        json.RawMessage `json:"metadata,omitempty" swaggertype:"object,string" example:"order_id:1042"`
+	Note            string          `json:"note,omitempty" example:"Printer toner for the shop"` // Private to the owner
+	Tags            []string        `json:"tags,omitempty" example:"office,supplies"`            // Private to the owner
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+type Pagination struct {
+	Limit      int    `json:"limit" example:"50"`
+	NextCursor string `json:"next_cursor,omitempty"` // Pass as cursor to get the next page
+	HasMore    bool   `json:"has_more"`
+}
+
+type TransactionPage struct {
+	Data       []TransactionItem `json:"data"`
+	Pagination Pagination        `json:"pagination"`
+}
+
+// GetTransactionHistory godoc
+// @Summary Get transaction history
+// @Description Retrieve the authenticated user's transactions, newest first, one page at a time. Pass the returned next_cursor as cursor to get the next page. Deposits that were abandoned or cancelled without being paid are left out unless include_abandoned is true or status asks for them.
+// @Tags Wallet
+// @Produce json
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param type query string false "Comma-separated transaction types, e.g. deposit,transfer"
+// @Param status query string false "Comma-separated statuses, e.g. success,pending"
+// @Param from query string false "Earliest creation time, YYYY-MM-DD or RFC 3339"
+// @Param to query string false "Latest creation time, YYYY-MM-DD (inclusive) or RFC 3339"
+// @Param min_amount query int false "Smallest amount in kobo"
+// @Param max_amount query int false "Largest amount in kobo"
+// @Param counterparty query string false "Wallet number money was sent to or received from"
+// @Param reference query string false "Transaction reference"
+// @Param narration query string false "Text the narration contains, ignoring case"
+// @Param metadata[key] query string false "Metadata value to match, e.g. metadata[order_id]=1042; repeat for more keys"
+// @Param category query string false "Category ID, or none for uncategorized transactions"
+// @Param tags query string false "Comma-separated tags the transaction must all carry"
+// @Param include_abandoned query bool false "Include abandoned and cancelled deposits"
+// @Success 200 {object} TransactionPage
+// @Failure 400 {object} map[string]interface{} "Invalid filter or cursor"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/transactions [get]
+func GetTransactionHistory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	limit := defaultHistoryLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = min(n, maxHistoryLimit)
+	}
+
+	categorizeForRead(userID.(string))
+	query, empty, err := filterTransactions(c, database.DB.Where("user_id = ?", userID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page := TransactionPage{Data: []TransactionItem{}, Pagination: Pagination{Limit: limit}}
+	if empty {
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		query = query.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+
+	// One extra row tells us whether there is another page
+	var transactions []models.Transaction
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		page.Pagination.HasMore = true
+		page.Pagination.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	counterparties, err := loadCounterparties(transactions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+
+	for _, tx := range transactions {
+		page.Data = append(page.Data, toTransactionItem(tx, counterparties))
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// filterTransactions applies the history filters in the query string. empty
+// is set when the filters cannot match anything, such as an unknown
+// counterparty wallet.
+func filterTransactions(c *gin.Context, query *gorm.DB) (*gorm.DB, bool, error) {
+	if types := splitQuery(c.Query("type")); len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+
+	if statuses := splitQuery(c.Query("status")); len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	} else if c.Query("include_abandoned") != "true" {
+		query = query.Where("status NOT IN ?", []models.TransactionStatus{models.TransactionStatusAbandoned, models.TransactionStatusCancelled})
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, _, err := parseHistoryTime(value)
+		if err != nil {
+			return nil, false, errors.New("from must be YYYY-MM-DD or RFC 3339")
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if value := c.Query("to"); value != "" {
+		to, dateOnly, err := parseHistoryTime(value)
+		if err != nil {
+			return nil, false, errors.New("to must be YYYY-MM-DD or RFC 3339")
+		}
+		if dateOnly {
+			query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+		} else {
+			query = query.Where("created_at <= ?", to)
+		}
+	}
+
+	for _, bound := range []struct{ param, op string }{{"min_amount", ">="}, {"max_amount", "<="}} {
+		value := c.Query(bound.param)
+		if value == "" {
+			continue
+		}
+		amount, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || amount < 0 {
+			return nil, false, errors.New(bound.param + " must be a whole number of kobo")
+		}
+		query = query.Where("amount "+bound.op+" ?", amount)
+	}
+
+	if walletNumber := c.Query("counterparty"); walletNumber != "" {
+		var wallet models.Wallet
+		err := database.DB.Select("id").Where("wallet_number = ?", walletNumber).First(&wallet).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return query, true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		query = query.Where("recipient_wallet_id = ? OR sender_wallet_id = ?", wallet.ID, wallet.ID)
+	}
+
+	if reference := c.Query("reference"); reference != "" {
+		query = query.Where("reference = ?", reference)
+	}
+
+	switch category := c.Query("category"); category {
+	case "":
+	case "none":
+		query = query.Where("category_id IS NULL")
+	default:
+		// Someone else's category, or not an ID at all, matches nothing
+		userID, _ := c.Get("user_id")
+		if err := database.DB.Select("id").Where("id = ? AND user_id = ?", category, userID).
+			First(&models.Category{}).Error; err != nil {
+			return query, true, nil
+		}
+		query = query.Where("category_id = ?", category)
+	}
+
+	if tags := splitQuery(c.Query("tags")); len(tags) > 0 {
+		for i, tag := range tags {
+			tags[i] = strings.ToLower(tag)
+		}
+		// Containment is answered from the GIN index on tags
+		filter, err := json.Marshal(tags)
+		if err != nil {
+			return nil, false, errors.New("invalid tags filter")
+		}
+		query = query.Where("tags @> ?", string(filter))
+	}
+
+	if narration := c.Query("narration"); narration != "" {
+		query = query.Where("narration ILIKE ?", "%"+escapeLike(narration)+"%")
+	}
+
+	if metadata := c.QueryMap("metadata"); len(metadata) > 0 {
+		// Containment is answered from the GIN index on metadata
+		filter, err := json.Marshal(metadata)
+		if err != nil {
+			return nil, false, errors.New("invalid metadata filter")
+		}
+		query = query.Where("metadata @> ?", string(filter))
+	}
+
+	return query, false, nil
+}
+
+// escapeLike makes user text match literally inside a LIKE pattern.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func splitQuery(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parseHistoryTime accepts a date (midnight UTC) or an RFC 3339 time.
+func parseHistoryTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// Cursors point at the last transaction of a page. They are opaque to
+// clients but just the row's creation time and ID.
+func encodeCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", errInvalidCursor
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return time.Time{}, "", errInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, "", errInvalidCursor
+	}
+	return t, id, nil
+}
+
+// loadCounterparties looks up the other wallet of every transfer on a page,
+// keyed by wallet ID.
+func loadCounterparties(transactions []models.Transaction) (map[string]Counterparty, error) {
+	var walletIDs []string
+	for _, tx := range transactions {
+		if id := counterpartyWalletID(tx); id != "" {
+			walletIDs = append(walletIDs, id)
+		}
+	}
+	return counterpartiesByWalletID(walletIDs)
+}
+
+// counterpartiesByWalletID looks up the wallet number and owner of wallets.
+func counterpartiesByWalletID(walletIDs []string) (map[string]Counterparty, error) {
+	counterparties := make(map[string]Counterparty)
+	if len(walletIDs) == 0 {
+		return counterparties, nil
+	}
+
+	var rows []struct {
+		ID           string
+		WalletNumber string
+		Name         string
+	}
+	if err := database.DB.Table("wallets").
+		Select("wallets.id, wallets.wallet_number, users.name").
+		Joins("JOIN users ON users.id = wallets.user_id").
+		Where("wallets.id IN ?", walletIDs).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counterparties[row.ID] = Counterparty{WalletNumber: row.WalletNumber, Name: row.Name}
+	}
+	return counterparties, nil
+}
+
+func counterpartyWalletID(tx models.Transaction) string {
+	switch {
+	case tx.RecipientWalletID != nil:
+		return *tx.RecipientWalletID
+	case tx.SenderWalletID != nil:
+		return *tx.SenderWalletID
+	}
+	return ""
+}
+
+func toTransactionItem(tx models.Transaction, counterparties map[string]Counterparty) TransactionItem {
+	item := TransactionItem{
+		ID:            tx.ID,
+		Reference:     tx.Reference,
+		Type:          string(tx.Type),
+		Amount:        tx.Amount,
+		Currency:      tx.Currency,
+		Status:        string(tx.Status),
+		Fees:          tx.Fees,
+		Narration:     tx.Narration,
+		Note:          tx.Note,
+		BalanceBefore: tx.BalanceBefore,
+		BalanceAfter:  tx.BalanceAfter,
+		CategoryID:    tx.CategoryID,
+		CreatedAt:     tx.CreatedAt,
+		UpdatedAt:     tx.UpdatedAt,
+	}
+	if tx.TransferGroupID != nil {
+		item.TransferGroupID = *tx.TransferGroupID
+	}
+	if tx.Metadata != nil {
+		item.Metadata = json.RawMessage(*tx.Metadata)
+	}
+	if tx.Tags != nil {
+		json.Unmarshal([]byte(*tx.Tags), &item.Tags)
+	}
+	if counterparty, ok := counterparties[counterpartyWalletID(tx)]; ok {
+		item.Counterparty = &counterparty
+	}
+	return item
+}
+
+// TransferLeg is the other side of a wallet transfer: the recipient's credit
+// for a transfer, the sender's transfer for a credit.
+type TransferLeg struct {
+	ID        string    `json:"id"`
+	Reference string    `json:"reference" example:"TXN_1700000000_ab12cd35"`
+	Type      string    `json:"type" example:"credit"`
+	Status    string    `json:"status" example:"success"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TransactionDetail struct {
+	TransactionItem
+	UserID          string       `json:"user_id,omitempty"` // Only shown to admins
+	Provider        string       `json:"provider,omitempty" example:"paystack"`
+	Channel         string       `json:"channel,omitempty" example:"card"`
+	GatewayResponse string       `json:"gateway_response,omitempty" example:"Approved"`
+	PaidAt          *time.Time   `json:"paid_at,omitempty"`
+	AppliedAt       *time.Time   `json:"applied_at,omitempty"` // When it moved the balance
+	ReviewReason    string       `json:"review_reason,omitempty"`
+	PairedLeg       *TransferLeg `json:"paired_leg,omitempty"`
+
+	Attachments []models.TransactionAttachment `json:"attachments,omitempty"` // Private to the owner
+}
+
+// GetTransaction godoc
+// @Summary Get a transaction
+// @Description Retrieve one of the authenticated user's transactions in full, with the counterparty, the other leg of a transfer and the user's attachments. Both legs of a transfer share transfer_group_id; notes, tags and attachments stay on the user's own leg.
+// @Tags Wallet
+// @Produce json
+// @Param reference path string true "Transaction reference"
+// @Success 200 {object} TransactionDetail
+// @Failure 404 {object} map[string]interface{} "Transaction not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/transactions/{reference} [get]
+func GetTransaction(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	categorizeForRead(userID.(string))
+
+	var transaction models.Transaction
+	if err := database.DB.Where("reference = ? AND user_id = ?", c.Param("reference"), userID).
+		First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	detail, err := toTransactionDetail(transaction)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction"})
+		return
+	}
+	if detail.Attachments, err = loadAttachments(transaction.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// AdminGetTransaction godoc
+// @Summary Get any transaction
+// @Description Retrieve any user's transaction in full, so a transfer can be traced from either side. The user's private notes, tags and attachments are left out. Admin only.
+// @Tags Admin
+// @Produce json
+// @Param reference path string true "Transaction reference"
+// @Success 200 {object} TransactionDetail
+// @Failure 404 {object} map[string]interface{} "Transaction not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /admin/transactions/{reference} [get]
+func AdminGetTransaction(c *gin.Context) {
+	var transaction models.Transaction
+	if err := database.DB.Where("reference = ?", c.Param("reference")).First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	detail, err := toTransactionDetail(transaction)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction"})
+		return
+	}
+	detail.UserID = transaction.UserID
+	// Notes and tags are the user's own bookkeeping
+	detail.Note = ""
+	detail.Tags = nil
+
+	c.JSON(http.StatusOK, detail)
+}
+
+func toTransactionDetail(tx models.Transaction) (*TransactionDetail, error) {
+	counterparties, err := loadCounterparties([]models.Transaction{tx})
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &TransactionDetail{
+		TransactionItem: toTransactionItem(tx, counterparties),
+		Channel:         tx.Channel,
+		GatewayResponse: tx.GatewayResponse,
+		PaidAt:          tx.PaidAt,
+		AppliedAt:       tx.AppliedAt,
+		ReviewReason:    tx.ReviewReason,
+	}
+
+	switch tx.Type {
+	case models.TransactionTypeTransfer, models.TransactionTypeCredit:
+		// Wallet transfers never touch a payment provider
+	default:
+		detail.Provider = tx.Provider
+	}
+
+	if tx.TransferGroupID != nil {
+		var leg models.Transaction
+		err := database.DB.Where("transfer_group_id = ? AND id <> ?", *tx.TransferGroupID, tx.ID).First(&leg).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			detail.PairedLeg = &TransferLeg{
+				ID:        leg.ID,
+				Reference: leg.Reference,
+				Type:      string(leg.Type),
+				Status:    string(leg.Status),
+				CreatedAt: leg.CreatedAt,
+			}
+		}
+	}
+
+	return detail, nil
+}
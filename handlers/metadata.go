@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Limits on what callers can attach to a transaction. Metadata is meant for
+// correlating with their own records (order_id, invoice number), not storage.
+const (
+	maxNarrationLength     = 100
+	maxMetadataKeys        = 20
+	maxMetadataKeyLength   = 40
+	maxMetadataValueLength = 500
+)
+
+// validateAnnotations checks a caller's narration and metadata, returning a
+// message fit for the response or "".
+func validateAnnotations(narration string, metadata map[string]string) string {
+	if utf8.RuneCountInString(narration) > maxNarrationLength {
+		return fmt.Sprintf("narration must be at most %d characters", maxNarrationLength)
+	}
+	if len(metadata) > maxMetadataKeys {
+		return fmt.Sprintf("metadata can have at most %d keys", maxMetadataKeys)
+	}
+	for key, value := range metadata {
+		if key == "" || utf8.RuneCountInString(key) > maxMetadataKeyLength {
+			return fmt.Sprintf("metadata keys must be 1 to %d characters", maxMetadataKeyLength)
+		}
+		if utf8.RuneCountInString(value) > maxMetadataValueLength {
+			return fmt.Sprintf("metadata value for %s must be at most %d characters", key, maxMetadataValueLength)
+		}
+	}
+	return ""
+}
+
+// encodeMetadata turns caller metadata into the Transaction.Metadata column.
+func encodeMetadata(metadata map[string]string) (*string, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	encoded := string(data)
+	return &encoded, nil
+}
+
+// providerMetadata is caller metadata in the form providers take it.
+func providerMetadata(metadata map[string]string) map[string]interface{} {
+	if len(metadata) == 0 {
+		return nil
+	}
+	forwarded := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		forwarded[key] = value
+	}
+	return forwarded
+}
@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"wallet-service/config"
+	"wallet-service/database"
+	"wallet-service/mailer"
+	"wallet-service/models"
+	"wallet-service/statements"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// monthlyStatementInterval is how often the scheduler looks for monthly
+	// statements to send; each user gets one per month.
+	monthlyStatementInterval    = time.Hour
+	monthlyStatementMaxAttempts = 5
+	monthlyStatementSendTimeout = 2 * time.Minute
+)
+
+// statementMailer sends monthly statements.
+var statementMailer mailer.Mailer
+
+// InitMailer sets up the mailer picked by MAILER.
+func InitMailer() {
+	var err error
+	switch config.AppConfig.Mailer {
+	case config.MailerSMTP:
+		statementMailer, err = mailer.NewSMTPMailer(config.AppConfig.SMTPHost, config.AppConfig.SMTPPort,
+			config.AppConfig.SMTPUsername, config.AppConfig.SMTPPassword, config.AppConfig.MailFrom)
+	default:
+		statementMailer, err = mailer.NewFileMailer(config.AppConfig.MailDropDir, config.AppConfig.MailFrom)
+	}
+	if err != nil {
+		log.Fatal("Invalid MAIL_FROM: ", err)
+	}
+}
+
+type StatementPreferenceRequest struct {
+	Monthly *bool  `json:"monthly" example:"true"`
+	Format  string `json:"format" example:"pdf"` // Any statement format; unchanged when empty
+}
+
+// GetStatementPreference godoc
+// @Summary Get monthly statement preferences
+// @Description Whether the authenticated user is emailed a statement for each month, and in which format. Users are opted in with PDF statements until they change it.
+// @Tags Statements
+// @Produce json
+// @Success 200 {object} models.StatementPreference
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/statements/preferences [get]
+func GetStatementPreference(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	preference, err := statementPreference(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statement preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// UpdateStatementPreference godoc
+// @Summary Update monthly statement preferences
+// @Description Opt in to or out of monthly statement emails, or change their format. Applies from the next month's statement.
+// @Tags Statements
+// @Accept json
+// @Produce json
+// @Param request body StatementPreferenceRequest true "Preferences to change"
+// @Success 200 {object} models.StatementPreference
+// @Failure 400 {object} map[string]interface{} "Invalid format"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/statements/preferences [put]
+func UpdateStatementPreference(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req StatementPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Format != "" && !statements.IsFormat(req.Format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, pdf, ofx, qfx, qif or camt053"})
+		return
+	}
+
+	preference, err := statementPreference(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update statement preferences"})
+		return
+	}
+	if req.Monthly != nil {
+		preference.Monthly = *req.Monthly
+	}
+	if req.Format != "" {
+		preference.Format = req.Format
+	}
+
+	if err := database.DB.Save(&preference).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update statement preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// ListStatementDeliveries godoc
+// @Summary List monthly statement emails
+// @Description Retrieve the monthly statements emailed, or due to be emailed, to the authenticated user, newest first
+// @Tags Statements
+// @Produce json
+// @Success 200 {array} models.StatementDelivery
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/statements/deliveries [get]
+func ListStatementDeliveries(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var deliveries []models.StatementDelivery
+	if err := database.DB.Where("user_id = ?", userID).Order("period DESC").Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statement deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// AdminListStatementDeliveries godoc
+// @Summary List monthly statement emails (admin)
+// @Description Retrieve monthly statement deliveries across users, newest first, e.g. to find the ones that failed
+// @Tags Admin
+// @Produce json
+// @Param period query string false "Only this month, YYYY-MM"
+// @Param status query string false "Only deliveries with this status (pending, sending, sent, failed, skipped)"
+// @Success 200 {array} models.StatementDelivery
+// @Failure 400 {object} map[string]interface{} "Invalid period"
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /admin/statements/deliveries [get]
+func AdminListStatementDeliveries(c *gin.Context) {
+	query := database.DB.Order("period DESC, created_at DESC")
+	if value := c.Query("period"); value != "" {
+		period, err := time.Parse("2006-01", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "period must be a month (YYYY-MM)"})
+			return
+		}
+		query = query.Where("period = ?", period)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []models.StatementDelivery
+	if err := query.Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statement deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// statementPreference is a user's saved preference, or the default for
+// users who never set one.
+func statementPreference(userID string) (models.StatementPreference, error) {
+	preference := models.StatementPreference{UserID: userID, Monthly: true, Format: statements.FormatPDF}
+	err := database.DB.Where("user_id = ?", userID).First(&preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return preference, nil
+	}
+	return preference, err
+}
+
+// StartMonthlyStatements emails users their statement for the previous month
+// (UTC), from the 1st. Every run records a delivery per user and month before
+// sending, so nothing is sent twice; failed deliveries are retried on later
+// runs.
+func StartMonthlyStatements() {
+	if !config.AppConfig.MonthlyStatements {
+		return
+	}
+
+	// There is no telling whether a delivery cut off by a restart was sent,
+	// and a repeated statement is better than a missing one
+	if err := database.DB.Model(&models.StatementDelivery{}).
+		Where("status = ?", models.StatementDeliverySending).
+		Updates(map[string]interface{}{
+			"status": models.StatementDeliveryFailed,
+			"error":  "Interrupted while sending",
+		}).Error; err != nil {
+		log.Println("Failed to recover interrupted statement deliveries:", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(monthlyStatementInterval)
+		defer ticker.Stop()
+
+		for {
+			runMonthlyStatements(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func runMonthlyStatements(now time.Time) {
+	period := startOfMonth(now).AddDate(0, -1, 0)
+
+	if err := queueMonthlyStatements(period); err != nil {
+		log.Println("Failed to queue monthly statements:", err)
+		return
+	}
+
+	var ids []string
+	if err := database.DB.Model(&models.StatementDelivery{}).
+		Where("period = ? AND (status = ? OR (status = ? AND attempts < ?))", period,
+			models.StatementDeliveryPending, models.StatementDeliveryFailed, monthlyStatementMaxAttempts).
+		Pluck("id", &ids).Error; err != nil {
+		log.Println("Failed to load monthly statements to send:", err)
+		return
+	}
+
+	for _, id := range ids {
+		deliverMonthlyStatement(id)
+	}
+}
+
+// queueMonthlyStatements records a delivery of period's statement for every
+// user whose wallet was open by the end of it: pending, or skipped for users
+// who opted out.
+func queueMonthlyStatements(period time.Time) error {
+	opened := database.DB.Model(&models.Wallet{}).Select("user_id").Where("created_at < ?", period.AddDate(0, 1, 0))
+	queued := database.DB.Model(&models.StatementDelivery{}).Select("user_id").Where("period = ?", period)
+
+	var users []models.User
+	return database.DB.Where("id IN (?) AND id NOT IN (?)", opened, queued).
+		FindInBatches(&users, 500, func(tx *gorm.DB, batch int) error {
+			ids := make([]string, 0, len(users))
+			for _, user := range users {
+				ids = append(ids, user.ID)
+			}
+
+			var saved []models.StatementPreference
+			if err := database.DB.Where("user_id IN ?", ids).Find(&saved).Error; err != nil {
+				return err
+			}
+			preferences := make(map[string]models.StatementPreference, len(saved))
+			for _, preference := range saved {
+				preferences[preference.UserID] = preference
+			}
+
+			deliveries := make([]models.StatementDelivery, 0, len(users))
+			for _, user := range users {
+				delivery := models.StatementDelivery{
+					UserID: user.ID,
+					Period: period,
+					Format: statements.FormatPDF,
+					Status: models.StatementDeliveryPending,
+				}
+				if preference, ok := preferences[user.ID]; ok {
+					delivery.Format = preference.Format
+					if !preference.Monthly {
+						delivery.Status = models.StatementDeliverySkipped
+					}
+				}
+				deliveries = append(deliveries, delivery)
+			}
+
+			// Another run may have queued some of them in the meantime
+			return database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+		}).Error
+}
+
+// deliverMonthlyStatement generates and emails one monthly statement, and
+// records how it went.
+func deliverMonthlyStatement(id string) {
+	// Claim the delivery, so it is only ever sent by one run
+	claim := database.DB.Model(&models.StatementDelivery{}).
+		Where("id = ? AND status IN ?", id, []models.StatementDeliveryStatus{models.StatementDeliveryPending, models.StatementDeliveryFailed}).
+		Updates(map[string]interface{}{
+			"status":   models.StatementDeliverySending,
+			"attempts": gorm.Expr("attempts + 1"),
+		})
+	if claim.Error != nil {
+		log.Printf("Failed to claim statement delivery %s: %v", id, claim.Error)
+		return
+	}
+	if claim.RowsAffected == 0 {
+		return
+	}
+
+	var delivery models.StatementDelivery
+	if err := database.DB.First(&delivery, "id = ?", id).Error; err != nil {
+		log.Printf("Failed to load statement delivery %s: %v", id, err)
+		return
+	}
+
+	email, lineCount, err := sendMonthlyStatement(delivery)
+	if err != nil {
+		log.Printf("Failed to send monthly statement %s (attempt %d): %v", id, delivery.Attempts, err)
+		message := "Failed to send statement"
+		if errors.Is(err, errBalanceHistoryUnavailable) {
+			message = "No balance history for this period"
+		}
+		if err := database.DB.Model(&delivery).Updates(map[string]interface{}{
+			"status": models.StatementDeliveryFailed,
+			"email":  email,
+			"error":  message,
+		}).Error; err != nil {
+			log.Printf("Failed to mark statement delivery %s failed: %v", id, err)
+		}
+		return
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&delivery).Updates(map[string]interface{}{
+		"status":     models.StatementDeliverySent,
+		"email":      email,
+		"line_count": lineCount,
+		"error":      "",
+		"sent_at":    &now,
+	}).Error; err != nil {
+		log.Printf("Failed to mark statement delivery %s sent: %v", id, err)
+	}
+}
+
+// sendMonthlyStatement emails a delivery's statement to the user's current
+// address, returning the address and the number of lines sent.
+func sendMonthlyStatement(delivery models.StatementDelivery) (string, int, error) {
+	var user models.User
+	if err := database.DB.Preload("Wallet").Where("id = ?", delivery.UserID).First(&user).Error; err != nil {
+		return "", 0, err
+	}
+	if user.Wallet == nil {
+		return user.Email, 0, errors.New("wallet not found")
+	}
+
+	from := delivery.Period
+	to := from.AddDate(0, 1, -1)
+	statement, err := buildStatement(user, *user.Wallet, from, to)
+	if err != nil {
+		return user.Email, 0, err
+	}
+
+	var buf bytes.Buffer
+	if err := statements.Write(&buf, delivery.Format, statement); err != nil {
+		return user.Email, 0, err
+	}
+
+	month := from.Format("January 2006")
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Your wallet statement for " + month,
+		Body:    monthlyStatementBody(user, statement, month),
+		Attachments: []mailer.Attachment{{
+			Filename:    statementFilename(user.Wallet.WalletNumber, from, to, delivery.Format),
+			ContentType: statements.ContentType(delivery.Format),
+			Data:        buf.Bytes(),
+		}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), monthlyStatementSendTimeout)
+	defer cancel()
+	if err := statementMailer.Send(ctx, msg); err != nil {
+		return user.Email, 0, err
+	}
+	return user.Email, len(statement.Lines), nil
+}
+
+func monthlyStatementBody(user models.User, statement *statements.Statement, month string) string {
+	name := user.Name
+	if name == "" {
+		name = "there"
+	}
+	return fmt.Sprintf(`Hello %s,
+
+Your wallet statement for %s is attached.
+
+Wallet number:    %s
+Opening balance:  %s %s
+Money in:         %s %s
+Money out:        %s %s
+Closing balance:  %s %s
+Transactions:     %d
+
+To stop receiving monthly statements by email, turn them off in your
+statement preferences.
+`,
+		name, month, statement.WalletNumber,
+		statement.Currency, statements.FormatAmount(statement.OpeningBalance),
+		statement.Currency, statements.FormatAmount(statement.TotalCredits),
+		statement.Currency, statements.FormatAmount(statement.TotalDebits),
+		statement.Currency, statements.FormatAmount(statement.ClosingBalance),
+		len(statement.Lines))
+}
+
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
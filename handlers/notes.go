@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"wallet-service/database"
+	"wallet-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits on the bookkeeping notes and tags users keep on their transactions.
+// Both live on the user's own leg only, so the counterparty never sees them.
+const (
+	maxNoteLength = 1000
+	maxTags       = 10
+	maxTagLength  = 30
+)
+
+type AnnotateTransactionRequest struct {
+	Note *string   `json:"note" example:"Printer toner for the shop"` // Empty removes the note
+	Tags *[]string `json:"tags" example:"office,supplies"`            // Replaces every tag; empty removes them
+}
+
+type TagCount struct {
+	Tag   string `json:"tag" example:"supplies"`
+	Count int64  `json:"count" example:"12"`
+}
+
+// AnnotateTransaction godoc
+// @Summary Add a note or tags to a transaction
+// @Description Keep a private note and tags on one of the authenticated user's transactions for bookkeeping. Fields left out are unchanged. Tags are lowercased and deduplicated. Neither is ever shown to the other side of a transfer.
+// @Tags Wallet
+// @Accept json
+// @Produce json
+// @Param reference path string true "Transaction reference"
+// @Param request body AnnotateTransactionRequest true "Note and tags"
+// @Success 200 {object} TransactionItem
+// @Failure 400 {object} map[string]interface{} "Invalid note or tags"
+// @Failure 404 {object} map[string]interface{} "Transaction not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/transactions/{reference} [patch]
+func AnnotateTransaction(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req AnnotateTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Note != nil {
+		note := strings.TrimSpace(*req.Note)
+		if utf8.RuneCountInString(note) > maxNoteLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("note must be at most %d characters", maxNoteLength)})
+			return
+		}
+		updates["note"] = note
+	}
+	if req.Tags != nil {
+		tags, msg := normalizeTags(*req.Tags)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		updates["tags"] = nil
+		if len(tags) > 0 {
+			encoded, err := json.Marshal(tags)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+				return
+			}
+			updates["tags"] = string(encoded)
+		}
+	}
+
+	var transaction models.Transaction
+	if err := database.DB.Where("reference = ? AND user_id = ?", c.Param("reference"), userID).
+		First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	if len(updates) > 0 {
+		// Notes and tags leave updated_at alone, like categories
+		if err := database.DB.Model(&transaction).UpdateColumns(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+			return
+		}
+		if err := database.DB.First(&transaction, "id = ?", transaction.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+			return
+		}
+	}
+
+	counterparties, err := loadCounterparties([]models.Transaction{transaction})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toTransactionItem(transaction, counterparties))
+}
+
+// ListTags godoc
+// @Summary List tags
+// @Description Every tag on the authenticated user's transactions, with how many transactions carry it, most used first
+// @Tags Wallet
+// @Produce json
+// @Success 200 {array} TagCount
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/tags [get]
+func ListTags(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	tags := []TagCount{}
+	if err := database.DB.Model(&models.Transaction{}).
+		Select("tag, COUNT(*) AS count").
+		Joins("CROSS JOIN LATERAL jsonb_array_elements_text(transactions.tags) AS tag").
+		Where("transactions.user_id = ? AND transactions.tags IS NOT NULL", userID).
+		Group("tag").
+		Order("count DESC, tag").
+		Scan(&tags).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// normalizeTags lowercases, trims, deduplicates and sorts tags, returning a
+// message fit for the response or "".
+func normalizeTags(tags []string) ([]string, string) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if msg := validateTag(tag); msg != "" {
+			return nil, msg
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > maxTags {
+		return nil, fmt.Sprintf("a transaction can have at most %d tags", maxTags)
+	}
+	sort.Strings(normalized)
+	return normalized, ""
+}
+
+// validateTag allows letters, digits, spaces, '-', '_' and '.', so tags can
+// be listed comma-separated in the history filter.
+func validateTag(tag string) string {
+	if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+		return fmt.Sprintf("tags must be 1 to %d characters", maxTagLength)
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" -_.", r) {
+			return fmt.Sprintf("tag %q can only have letters, digits, spaces, '-', '_' and '.'", tag)
+		}
+	}
+	return ""
+}
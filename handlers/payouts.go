@@ -0,0 +1,767 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"wallet-service/config"
+	"wallet-service/database"
+	"wallet-service/models"
+	"wallet-service/payouts"
+	"wallet-service/services"
+	"wallet-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxPayoutFileSize bounds uploaded payout files.
+const maxPayoutFileSize = 5 << 20
+
+// payoutResolveWorkers is how many account lookups run at once.
+const payoutResolveWorkers = 5
+
+// Lines processing for longer than payoutStaleAfter are checked with Paystack
+// every payoutRecoveryInterval, at most payoutRecoveryBatchSize at a time.
+const (
+	payoutStaleAfter        = 30 * time.Minute
+	payoutRecoveryInterval  = 15 * time.Minute
+	payoutRecoveryBatchSize = 100
+)
+
+var (
+	errPayoutLineNotRetryable = errors.New("payout line is not retryable")
+	errInvalidPayoutTotal     = errors.New("invalid payout total")
+)
+
+// CreatePayoutBatch godoc
+// @Summary Create a bulk payout
+// @Description Pay many bank accounts from the wallet with one CSV or JSON file of account_number, bank_code, amount (kobo) and narration. Only users on a tier listed in PAYOUT_TIERS (business accounts) may make payouts. Every account is resolved first; if any line is invalid nothing is paid. The wallet is debited once for the whole batch, the lines are sent as Paystack bulk transfers, and lines that fail are credited back automatically.
+// @Tags Payouts
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV file with a header row, or a JSON array of lines"
+// @Param currency formData string false "Currency of the payouts; only NGN, the currency wallets are held in, is accepted"
+// @Param X-Idempotency-Key header string false "Idempotency key to prevent duplicate batches (optional but recommended)"
+// @Success 202 {object} models.PayoutBatch
+// @Failure 400 {object} map[string]interface{} "Invalid file, invalid lines or insufficient balance"
+// @Failure 403 {object} map[string]interface{} "Bulk payouts are only available to business accounts"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 503 {object} map[string]interface{} "Payment provider unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/payouts [post]
+func CreatePayoutBatch(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	if !requirePayoutTier(c, userID) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPayoutFileSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A CSV or JSON payout file is required"})
+		return
+	}
+
+	// Wallets hold a single balance, so payouts are only made in its currency
+	currency := strings.ToUpper(c.DefaultPostForm("currency", defaultCurrency))
+	if currency != defaultCurrency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payouts can only be made in " + defaultCurrency})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	lines, lineErrors, err := payouts.Parse(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payout file", "details": err.Error()})
+		return
+	}
+
+	count := len(lines) + len(lineErrors)
+	if count == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payout file has no lines"})
+		return
+	}
+	if count > config.AppConfig.PayoutMaxLines {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Payout file has %d lines, the limit is %d", count, config.AppConfig.PayoutMaxLines)})
+		return
+	}
+
+	accountNames, resolveErrors, err := resolvePayoutAccounts(c.Request.Context(), lines)
+	if err != nil {
+		log.Println("Failed to resolve payout accounts:", err)
+		respondPaymentError(c, err)
+		return
+	}
+
+	lineErrors = append(lineErrors, resolveErrors...)
+	if len(lineErrors) > 0 {
+		sort.Slice(lineErrors, func(i, j int) bool { return lineErrors[i].Line < lineErrors[j].Line })
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Some payout lines are invalid, nothing was paid", "lines": lineErrors})
+		return
+	}
+
+	batch, err := createPayoutBatch(userID.(string), currency, lines, accountNames)
+	if err != nil {
+		if errors.Is(err, errInsufficientBalance) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
+			return
+		}
+		if errors.Is(err, errInvalidPayoutTotal) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Payout total is too large"})
+			return
+		}
+		log.Println("Failed to create payout batch:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payout batch"})
+		return
+	}
+
+	go dispatchPayoutBatch(batch.ID)
+
+	c.JSON(http.StatusAccepted, batch)
+}
+
+// resolvePayoutAccounts looks up the account name of every line, in line
+// order. Accounts Paystack cannot resolve are returned as line errors; any
+// other failure aborts the lookup.
+// requirePayoutTier answers 403 unless the user is on a tier allowed to make
+// bulk payouts. The transfer permission alone is not enough: payouts move
+// money to many accounts at once and are meant for business accounts.
+func requirePayoutTier(c *gin.Context, userID interface{}) bool {
+	var user models.User
+	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return false
+	}
+	if !payoutsAllowed(user.Tier) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Bulk payouts are only available to business accounts"})
+		return false
+	}
+	return true
+}
+
+// payoutsAllowed reports whether users on tier may make bulk payouts.
+func payoutsAllowed(tier string) bool {
+	tier = strings.ToLower(tier)
+	if tier == "" {
+		tier = config.DefaultUserTier
+	}
+	return containsString(config.AppConfig.PayoutTiers, tier)
+}
+
+func resolvePayoutAccounts(ctx context.Context, lines []payouts.Line) ([]string, []payouts.LineError, error) {
+	names := make([]string, len(lines))
+	problems := make([]string, len(lines))
+
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+
+	for w := 0; w < payoutResolveWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				name, err := paystack.ResolveAccount(ctx, lines[i].AccountNumber, lines[i].BankCode)
+				var validationErr *services.ValidationError
+				switch {
+				case err == nil:
+					names[i] = name
+				case errors.As(err, &validationErr):
+					problems[i] = "Account could not be resolved: " + validationErr.Message
+				default:
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range lines {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+
+	var lineErrors []payouts.LineError
+	for i, problem := range problems {
+		if problem != "" {
+			lineErrors = append(lineErrors, payouts.LineError{Line: lines[i].Line, Error: problem})
+		}
+	}
+	return names, lineErrors, nil
+}
+
+// createPayoutBatch debits the wallet once for every line and records the
+// batch with its lines pending.
+func createPayoutBatch(userID, currency string, lines []payouts.Line, accountNames []string) (*models.PayoutBatch, error) {
+	total, err := payouts.Total(lines)
+	if err != nil {
+		return nil, errInvalidPayoutTotal
+	}
+
+	batch := models.PayoutBatch{
+		UserID:      userID,
+		Reference:   utils.GenerateReference(),
+		Provider:    paystack.Name(),
+		Currency:    currency,
+		TotalAmount: total,
+		LineCount:   len(lines),
+		Status:      models.PayoutBatchStatusProcessing,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var wallet models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+			return err
+		}
+		if wallet.Available() < total {
+			return errInsufficientBalance
+		}
+
+		wallet.Balance -= total
+		if err := tx.Save(&wallet).Error; err != nil {
+			return err
+		}
+
+		debit := models.Transaction{
+			UserID:    userID,
+			Type:      models.TransactionTypePayout,
+			Amount:    total,
+			Currency:  currency,
+			Status:    models.TransactionStatusSuccess,
+			Reference: batch.Reference,
+			Provider:  batch.Provider,
+			Narration: fmt.Sprintf("Bulk payout of %d lines", len(lines)),
+		}
+		applyBalance(&debit, wallet, -total)
+		if err := tx.Create(&debit).Error; err != nil {
+			return err
+		}
+
+		batch.TransactionID = debit.ID
+		if err := tx.Create(&batch).Error; err != nil {
+			return err
+		}
+
+		batch.Lines = make([]models.PayoutLine, 0, len(lines))
+		for i, line := range lines {
+			batch.Lines = append(batch.Lines, models.PayoutLine{
+				BatchID:       batch.ID,
+				LineNumber:    line.Line,
+				AccountNumber: line.AccountNumber,
+				BankCode:      line.BankCode,
+				AccountName:   accountNames[i],
+				Amount:        line.Amount,
+				Narration:     line.Narration,
+				Reference:     payoutLineReference(batch.Reference, line.Line, 1),
+				Status:        models.PayoutLineStatusPending,
+				Attempts:      1,
+			})
+		}
+		return tx.CreateInBatches(&batch.Lines, 100).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Payout batch created: %s, Lines: %d, Amount: %d", batch.Reference, batch.LineCount, batch.TotalAmount)
+	return &batch, nil
+}
+
+// payoutLineReference is the transfer reference of one attempt at a line.
+// Paystack only accepts lowercase letters, digits, - and _ in references.
+func payoutLineReference(batchReference string, lineNumber, attempt int) string {
+	return fmt.Sprintf("%s-%d-%d", strings.ToLower(batchReference), lineNumber, attempt)
+}
+
+// ResumePayoutBatches dispatches lines left pending, e.g. by a restart
+// between creating a batch and sending it, and periodically recovers lines
+// left processing.
+func ResumePayoutBatches() {
+	var batchIDs []string
+	if err := database.DB.Model(&models.PayoutLine{}).Where("status = ?", models.PayoutLineStatusPending).
+		Distinct().Pluck("batch_id", &batchIDs).Error; err != nil {
+		log.Println("Failed to load pending payout lines:", err)
+	}
+
+	for _, batchID := range batchIDs {
+		go dispatchPayoutBatch(batchID)
+	}
+
+	go func() {
+		ticker := time.NewTicker(payoutRecoveryInterval)
+		defer ticker.Stop()
+
+		for {
+			recoverStalePayoutLines(context.Background())
+			<-ticker.C
+		}
+	}()
+}
+
+// recoverStalePayoutLines checks lines that have been processing for longer
+// than payoutStaleAfter with Paystack, e.g. after a bulk transfer whose
+// outcome was unknown or a transfer webhook that never arrived. Settled
+// transfers are applied; lines Paystack never received go back to pending and
+// are sent again.
+func recoverStalePayoutLines(ctx context.Context) {
+	cutoff := time.Now().Add(-payoutStaleAfter)
+
+	// Lines still in flight are saved when checked, so they go to the back of
+	// the queue
+	var lines []models.PayoutLine
+	if err := database.DB.Where("status = ? AND updated_at < ?", models.PayoutLineStatusProcessing, cutoff).
+		Order("updated_at").Limit(payoutRecoveryBatchSize).Find(&lines).Error; err != nil {
+		log.Println("Failed to load stale payout lines:", err)
+		return
+	}
+
+	resend := make(map[string]bool)
+	for _, line := range lines {
+		payout, err := paystack.VerifyTransfer(ctx, line.Reference)
+
+		var validationErr *services.ValidationError
+		switch {
+		case err == nil:
+			payout.Reference = line.Reference
+			if err := settlePayoutLine(*payout); err != nil {
+				log.Printf("Failed to settle payout line %s: %v", line.Reference, err)
+			}
+		case errors.As(err, &validationErr) && (validationErr.StatusCode == http.StatusBadRequest || validationErr.StatusCode == http.StatusNotFound):
+			// Paystack never received it, so it can safely be sent again
+			result := database.DB.Model(&models.PayoutLine{}).
+				Where("id = ? AND status = ?", line.ID, models.PayoutLineStatusProcessing).
+				Update("status", models.PayoutLineStatusPending)
+			if result.Error != nil {
+				log.Printf("Failed to return payout line %s to pending: %v", line.Reference, result.Error)
+				continue
+			}
+			if result.RowsAffected > 0 {
+				log.Printf("Payout line %s was never received by Paystack, sending it again", line.Reference)
+				resend[line.BatchID] = true
+			}
+		default:
+			// Checked again on a later sweep
+			log.Printf("Failed to verify payout line %s: %v", line.Reference, err)
+		}
+	}
+
+	for batchID := range resend {
+		dispatchPayoutBatch(batchID)
+	}
+}
+
+// dispatchPayoutBatch sends a batch's pending lines to Paystack as bulk
+// transfers.
+func dispatchPayoutBatch(batchID string) {
+	ctx := context.Background()
+
+	var batch models.PayoutBatch
+	if err := database.DB.First(&batch, "id = ?", batchID).Error; err != nil {
+		log.Printf("Failed to load payout batch %s: %v", batchID, err)
+		return
+	}
+
+	var lines []models.PayoutLine
+	if err := database.DB.Where("batch_id = ? AND status = ?", batchID, models.PayoutLineStatusPending).
+		Order("line_number").Find(&lines).Error; err != nil {
+		log.Printf("Failed to load payout lines of %s: %v", batch.Reference, err)
+		return
+	}
+
+	for start := 0; start < len(lines); start += services.MaxBulkTransfers {
+		end := start + services.MaxBulkTransfers
+		if end > len(lines) {
+			end = len(lines)
+		}
+		sendPayoutLines(ctx, batch, lines[start:end])
+	}
+
+	if err := updatePayoutBatchStatus(batchID); err != nil {
+		log.Printf("Failed to update payout batch %s: %v", batch.Reference, err)
+	}
+}
+
+// sendPayoutLines sends up to MaxBulkTransfers lines in one bulk transfer.
+// Each line is claimed (moved to processing) before it is sent, so lines are
+// never sent twice by concurrent dispatches.
+func sendPayoutLines(ctx context.Context, batch models.PayoutBatch, lines []models.PayoutLine) {
+	var transfers []services.BulkTransfer
+	var sent []models.PayoutLine
+
+	for _, line := range lines {
+		claim := database.DB.Model(&models.PayoutLine{}).
+			Where("id = ? AND status = ?", line.ID, models.PayoutLineStatusPending).
+			Update("status", models.PayoutLineStatusProcessing)
+		if claim.Error != nil {
+			log.Printf("Failed to claim payout line %s: %v", line.Reference, claim.Error)
+			continue
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		if line.RecipientCode == "" {
+			code, err := paystack.CreateTransferRecipient(ctx, services.PayoutRequest{
+				AccountNumber: line.AccountNumber,
+				BankCode:      line.BankCode,
+				AccountName:   line.AccountName,
+				Currency:      batch.Currency,
+			})
+			if err != nil {
+				// Nothing was sent, so the line can safely be credited back
+				failure := services.Payout{Reference: line.Reference, Status: services.PayoutStatusFailed, Message: "Failed to create transfer recipient: " + err.Error()}
+				if err := settlePayoutLine(failure); err != nil {
+					log.Printf("Failed to fail payout line %s: %v", line.Reference, err)
+				}
+				continue
+			}
+			line.RecipientCode = code
+			if err := database.DB.Model(&line).Update("recipient_code", code).Error; err != nil {
+				log.Printf("Failed to record recipient of payout line %s: %v", line.Reference, err)
+			}
+		}
+
+		transfers = append(transfers, services.BulkTransfer{
+			Recipient: line.RecipientCode,
+			Amount:    line.Amount,
+			Reference: line.Reference,
+			Reason:    line.Narration,
+		})
+		sent = append(sent, line)
+	}
+
+	if len(transfers) == 0 {
+		return
+	}
+
+	results, err := paystack.BulkTransfer(ctx, batch.Currency, transfers)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if !errors.As(err, &validationErr) {
+			// Paystack may still have queued them; their transfer webhooks settle them
+			log.Printf("ALERT: bulk transfer for payout batch %s has an unknown outcome, %d lines left processing: %v", batch.Reference, len(sent), err)
+			return
+		}
+
+		for _, line := range sent {
+			failure := services.Payout{Reference: line.Reference, Status: services.PayoutStatusFailed, Message: validationErr.Message}
+			if err := settlePayoutLine(failure); err != nil {
+				log.Printf("Failed to fail payout line %s: %v", line.Reference, err)
+			}
+		}
+		return
+	}
+
+	for _, result := range results {
+		if err := settlePayoutLine(result); err != nil {
+			log.Printf("Failed to record transfer for payout line %s: %v", result.Reference, err)
+		}
+	}
+}
+
+// processPayoutEvent applies a transfer webhook to the payout line it was
+// sent for.
+func processPayoutEvent(payout services.Payout) error {
+	err := settlePayoutLine(payout)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Payout event for %s matches no payout line", payout.Reference)
+		return nil
+	}
+	return err
+}
+
+// settlePayoutLine records a transfer's outcome on the payout line with its
+// reference. A failed line is credited back to the wallet. Pending outcomes
+// only record the transfer code. It is safe to call more than once.
+func settlePayoutLine(payout services.Payout) error {
+	var batchID string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var line models.PayoutLine
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference = ?", payout.Reference).First(&line).Error; err != nil {
+			return err
+		}
+		batchID = line.BatchID
+
+		if line.Status == models.PayoutLineStatusSuccess || line.Status == models.PayoutLineStatusFailed {
+			log.Printf("Payout line already settled: %s (%s)", line.Reference, line.Status)
+			return nil
+		}
+
+		if payout.ID != "" {
+			line.TransferCode = payout.ID
+		}
+
+		now := time.Now()
+		switch payout.Status {
+		case services.PayoutStatusSuccess:
+			line.Status = models.PayoutLineStatusSuccess
+			line.CompletedAt = &now
+			log.Printf("Payout line paid: %s, Amount: %d", line.Reference, line.Amount)
+			return tx.Save(&line).Error
+		case services.PayoutStatusFailed:
+		default:
+			return tx.Save(&line).Error
+		}
+
+		line.Status = models.PayoutLineStatusFailed
+		line.FailureReason = payout.Message
+		line.CompletedAt = &now
+		if err := tx.Save(&line).Error; err != nil {
+			return err
+		}
+
+		var batch models.PayoutBatch
+		if err := tx.First(&batch, "id = ?", line.BatchID).Error; err != nil {
+			return err
+		}
+
+		var wallet models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", batch.UserID).First(&wallet).Error; err != nil {
+			return err
+		}
+
+		wallet.Balance += line.Amount
+		if err := tx.Save(&wallet).Error; err != nil {
+			return err
+		}
+
+		reversal := models.Transaction{
+			UserID:          batch.UserID,
+			Type:            models.TransactionTypePayoutReversal,
+			Amount:          line.Amount,
+			Currency:        batch.Currency,
+			Status:          models.TransactionStatusSuccess,
+			Reference:       line.Reference + "_rev",
+			Provider:        batch.Provider,
+			GatewayResponse: payout.Message,
+			Narration:       "Payout to " + line.AccountName + " failed",
+		}
+		applyBalance(&reversal, wallet, line.Amount)
+		if err := tx.Create(&reversal).Error; err != nil {
+			return err
+		}
+
+		log.Printf("Payout line failed: %s, Amount: %d credited back, New Balance: %d", line.Reference, line.Amount, wallet.Balance)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return updatePayoutBatchStatus(batchID)
+}
+
+// updatePayoutBatchStatus derives a batch's status from its lines.
+func updatePayoutBatchStatus(batchID string) error {
+	var counts []struct {
+		Status models.PayoutLineStatus
+		Count  int
+	}
+	if err := database.DB.Model(&models.PayoutLine{}).Select("status, COUNT(*) AS count").
+		Where("batch_id = ?", batchID).Group("status").Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	tally := make(map[models.PayoutLineStatus]int)
+	for _, count := range counts {
+		tally[count.Status] = count.Count
+	}
+
+	updates := map[string]interface{}{"completed_at": nil}
+	switch {
+	case tally[models.PayoutLineStatusPending]+tally[models.PayoutLineStatusProcessing] > 0:
+		updates["status"] = models.PayoutBatchStatusProcessing
+	case tally[models.PayoutLineStatusFailed] == 0:
+		updates["status"] = models.PayoutBatchStatusCompleted
+	case tally[models.PayoutLineStatusSuccess] == 0:
+		updates["status"] = models.PayoutBatchStatusFailed
+	default:
+		updates["status"] = models.PayoutBatchStatusPartiallyFailed
+	}
+	if updates["status"] != models.PayoutBatchStatusProcessing {
+		updates["completed_at"] = time.Now()
+	}
+
+	return database.DB.Model(&models.PayoutBatch{}).Where("id = ?", batchID).Updates(updates).Error
+}
+
+// ListPayoutBatches godoc
+// @Summary List bulk payouts
+// @Description Retrieve the authenticated user's payout batches, newest first, without their lines
+// @Tags Payouts
+// @Produce json
+// @Success 200 {array} models.PayoutBatch
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/payouts [get]
+func ListPayoutBatches(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var batches []models.PayoutBatch
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&batches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payout batches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, batches)
+}
+
+// GetPayoutBatch godoc
+// @Summary Get a bulk payout
+// @Description Retrieve a payout batch with the status of every line
+// @Tags Payouts
+// @Produce json
+// @Param reference path string true "Batch reference"
+// @Success 200 {object} models.PayoutBatch
+// @Failure 404 {object} map[string]interface{} "Payout batch not found"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/payouts/{reference} [get]
+func GetPayoutBatch(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var batch models.PayoutBatch
+	if err := database.DB.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("line_number") }).
+		Where("reference = ? AND user_id = ?", c.Param("reference"), userID).First(&batch).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payout batch not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// RetryPayoutLine godoc
+// @Summary Retry a failed payout line
+// @Description Send a failed line of a payout batch again under a new reference. The wallet, which was credited back when the line failed, is debited again.
+// @Tags Payouts
+// @Produce json
+// @Param reference path string true "Batch reference"
+// @Param id path string true "Payout line ID"
+// @Param X-Idempotency-Key header string false "Idempotency key to prevent duplicate retries (optional but recommended)"
+// @Success 202 {object} models.PayoutLine
+// @Failure 400 {object} map[string]interface{} "Insufficient balance"
+// @Failure 403 {object} map[string]interface{} "Bulk payouts are only available to business accounts"
+// @Failure 404 {object} map[string]interface{} "Payout line not found"
+// @Failure 409 {object} map[string]interface{} "Payout line has not failed"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/payouts/{reference}/lines/{id}/retry [post]
+func RetryPayoutLine(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	if !requirePayoutTier(c, userID) {
+		return
+	}
+
+	var batch models.PayoutBatch
+	var line models.PayoutLine
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("reference = ? AND user_id = ?", c.Param("reference"), userID).First(&batch).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND batch_id = ?", c.Param("id"), batch.ID).First(&line).Error; err != nil {
+			return err
+		}
+		if line.Status != models.PayoutLineStatusFailed {
+			return errPayoutLineNotRetryable
+		}
+
+		var wallet models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", batch.UserID).First(&wallet).Error; err != nil {
+			return err
+		}
+		if wallet.Available() < line.Amount {
+			return errInsufficientBalance
+		}
+
+		wallet.Balance -= line.Amount
+		if err := tx.Save(&wallet).Error; err != nil {
+			return err
+		}
+
+		line.Attempts++
+		line.Reference = payoutLineReference(batch.Reference, line.LineNumber, line.Attempts)
+		line.Status = models.PayoutLineStatusPending
+		line.TransferCode = ""
+		line.FailureReason = ""
+		line.CompletedAt = nil
+		if err := tx.Save(&line).Error; err != nil {
+			return err
+		}
+
+		debit := models.Transaction{
+			UserID:    batch.UserID,
+			Type:      models.TransactionTypePayout,
+			Amount:    line.Amount,
+			Currency:  batch.Currency,
+			Status:    models.TransactionStatusSuccess,
+			Reference: line.Reference,
+			Provider:  batch.Provider,
+			Narration: "Payout to " + line.AccountName,
+		}
+		applyBalance(&debit, wallet, -line.Amount)
+		if err := tx.Create(&debit).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&batch).Updates(map[string]interface{}{
+			"status":       models.PayoutBatchStatusProcessing,
+			"completed_at": nil,
+		}).Error
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payout line not found"})
+		case errors.Is(err, errPayoutLineNotRetryable):
+			c.JSON(http.StatusConflict, gin.H{"error": "Only failed payout lines can be retried"})
+		case errors.Is(err, errInsufficientBalance):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
+		default:
+			log.Println("Failed to retry payout line:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry payout line"})
+		}
+		return
+	}
+
+	go dispatchPayoutBatch(batch.ID)
+
+	c.JSON(http.StatusAccepted, line)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"testing"
+	"wallet-service/config"
+)
+
+func TestPayoutsAllowed(t *testing.T) {
+	tests := []struct {
+		name        string
+		payoutTiers []string
+		tier        string
+		want        bool
+	}{
+		{name: "business tier", payoutTiers: []string{"business"}, tier: "business", want: true},
+		{name: "tier in another case", payoutTiers: []string{"business"}, tier: "Business", want: true},
+		{name: "one of several tiers", payoutTiers: []string{"business", "enterprise"}, tier: "enterprise", want: true},
+		{name: "default tier", payoutTiers: []string{"business"}, tier: config.DefaultUserTier, want: false},
+		{name: "empty tier is the default tier", payoutTiers: []string{"business"}, tier: "", want: false},
+		{name: "empty tier when the default tier may pay out", payoutTiers: []string{config.DefaultUserTier}, tier: "", want: true},
+		{name: "deposit tier only", payoutTiers: []string{"business"}, tier: "verified", want: false},
+		{name: "no payout tiers", tier: "business", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.AppConfig = &config.Config{PayoutTiers: tt.payoutTiers}
+			if got := payoutsAllowed(tt.tier); got != tt.want {
+				t.Errorf("payoutsAllowed(%q) = %v, want %v", tt.tier, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"wallet-service/database"
+	"wallet-service/reconcile"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSettlementFileSize bounds uploaded export files (roughly 100k lines).
+const maxSettlementFileSize = 20 << 20
+
+// ReconcilePaystackSettlement godoc
+// @Summary Reconcile a Paystack export (admin)
+// @Description Match a Paystack settlement or transaction export (CSV) against our deposits by reference. Reports settled payments with no deposit or an uncredited one, amount and fee mismatches, total fees, and deposits we credited that the file does not settle within the period.
+// @Tags Admin
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Paystack CSV export"
+// @Param from formData string false "First day of the period (YYYY-MM-DD), defaults to the file's first day"
+// @Param to formData string false "Last day of the period (YYYY-MM-DD), defaults to the file's last day"
+// @Param amounts_in_kobo formData bool false "The file holds amounts in kobo instead of naira"
+// @Success 200 {object} reconcile.Report
+// @Failure 400 {object} map[string]interface{} "Missing or unreadable file, or invalid period"
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /admin/reconciliation/paystack [post]
+func ReconcilePaystackSettlement(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSettlementFileSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A CSV file is required"})
+		return
+	}
+
+	opts, err := reconcile.ParsePeriod(c.PostForm("from"), c.PostForm("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period", "details": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	lines, err := reconcile.ParseSettlementCSV(file, c.PostForm("amounts_in_kobo") == "true")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid settlement file", "details": err.Error()})
+		return
+	}
+
+	report, err := reconcile.Reconcile(database.DB, lines, opts)
+	if err != nil {
+		log.Println("Reconciliation failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Reconciliation failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
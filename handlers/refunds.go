@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"wallet-service/database"
+	"wallet-service/models"
+	"wallet-service/services"
+	"wallet-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errDepositNotRefundable = errors.New("deposit is not refundable")
+	errRefundTooLarge       = errors.New("refund exceeds the refundable amount")
+	errInsufficientBalance  = errors.New("insufficient balance")
+	errDepositDisputed      = errors.New("deposit is disputed")
+)
+
+type RefundRequest struct {
+	Amount int64  `json:"amount" binding:"omitempty,gt=0" example:"5000"` // Defaults to everything not refunded yet
+	Reason string `json:"reason" example:"Customer request"`
+}
+
+type RefundResponse struct {
+	Reference        string     `json:"reference" example:"TXN_1234567890"`
+	DepositReference string     `json:"deposit_reference" example:"TXN_1234567880"`
+	Amount           int64      `json:"amount" example:"5000"`
+	Currency         string     `json:"currency" example:"NGN"`
+	Status           string     `json:"status" example:"pending"`
+	Provider         string     `json:"provider" example:"paystack"`
+	Reason           string     `json:"reason,omitempty" example:"Customer request"`
+	FailureReason    string     `json:"failure_reason,omitempty"`
+	ProcessedAt      *time.Time `json:"processed_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func toRefundResponse(refund models.Refund) RefundResponse {
+	return RefundResponse{
+		Reference:        refund.Reference,
+		DepositReference: refund.DepositReference,
+		Amount:           refund.Amount,
+		Currency:         refund.Currency,
+		Status:           string(refund.Status),
+		Provider:         refund.Provider,
+		Reason:           refund.Reason,
+		FailureReason:    refund.FailureReason,
+		ProcessedAt:      refund.ProcessedAt,
+		CreatedAt:        refund.CreatedAt,
+	}
+}
+
+// RefundDeposit godoc
+// @Summary Refund a deposit
+// @Description Pay a successful deposit (or part of it) back to the card or account it came from. The wallet is debited straight away and credited back automatically if the refund fails.
+// @Tags Refunds
+// @Accept json
+// @Produce json
+// @Param reference path string true "Deposit reference"
+// @Param X-Idempotency-Key header string false "Idempotency key to prevent duplicate refunds (optional but recommended)"
+// @Param request body RefundRequest false "Amount in kobo (defaults to the full refundable amount) and reason"
+// @Success 200 {object} RefundResponse
+// @Failure 400 {object} map[string]interface{} "Deposit not refundable or disputed, amount too large or insufficient balance"
+// @Failure 404 {object} map[string]interface{} "Deposit not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 502 {object} map[string]interface{} "Payment provider error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/deposit/{reference}/refund [post]
+func RefundDeposit(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var deposit models.Transaction
+	if err := database.DB.Where("reference = ? AND user_id = ? AND type = ?", c.Param("reference"), userID, models.TransactionTypeDeposit).
+		First(&deposit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deposit not found"})
+		return
+	}
+
+	requestRefund(c, deposit, userID.(string))
+}
+
+// AdminRefundDeposit godoc
+// @Summary Refund any deposit (admin)
+// @Description Refund a successful deposit on behalf of its owner. The owner's wallet is debited straight away and credited back automatically if the refund fails.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param reference path string true "Deposit reference"
+// @Param request body RefundRequest false "Amount in kobo (defaults to the full refundable amount) and reason"
+// @Success 200 {object} RefundResponse
+// @Failure 400 {object} map[string]interface{} "Deposit not refundable or disputed, amount too large or insufficient balance"
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Failure 404 {object} map[string]interface{} "Deposit not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 502 {object} map[string]interface{} "Payment provider error"
+// @Security BearerAuth
+// @Router /admin/deposits/{reference}/refund [post]
+func AdminRefundDeposit(c *gin.Context) {
+	adminID, _ := c.Get("user_id")
+
+	var deposit models.Transaction
+	if err := database.DB.Where("reference = ? AND type = ?", c.Param("reference"), models.TransactionTypeDeposit).
+		First(&deposit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deposit not found"})
+		return
+	}
+
+	requestRefund(c, deposit, adminID.(string))
+}
+
+// refundedAmount is how much of a deposit has been refunded, or is being.
+func refundedAmount(tx *gorm.DB, depositID string) (int64, error) {
+	var refunded int64
+	err := tx.Model(&models.Refund{}).
+		Where("deposit_id = ? AND status <> ?", depositID, models.RefundStatusFailed).
+		Select("COALESCE(SUM(amount), 0)").Scan(&refunded).Error
+	return refunded, err
+}
+
+// requestRefund debits the deposit owner's wallet, records the refund and
+// hands it to the deposit's payment provider. The outcome usually arrives
+// later through a refund webhook.
+func requestRefund(c *gin.Context, deposit models.Transaction, requestedBy string) {
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than 0"})
+		return
+	}
+
+	provider, ok := paymentProviders.Get(deposit.Provider)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payment provider for this deposit is not enabled"})
+		return
+	}
+
+	var refund models.Refund
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Locking the deposit serializes refunds against it
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&deposit, "id = ?", deposit.ID).Error; err != nil {
+			return err
+		}
+		if deposit.Status != models.TransactionStatusSuccess {
+			return errDepositNotRefundable
+		}
+
+		// The customer is already getting the money back through the
+		// dispute, or may yet
+		var disputes int64
+		if err := tx.Model(&models.Dispute{}).
+			Where("deposit_id = ? AND status <> ?", deposit.ID, models.DisputeStatusWon).
+			Count(&disputes).Error; err != nil {
+			return err
+		}
+		if disputes > 0 {
+			return errDepositDisputed
+		}
+
+		refunded, err := refundedAmount(tx, deposit.ID)
+		if err != nil {
+			return err
+		}
+
+		amount := req.Amount
+		if amount == 0 {
+			amount = deposit.Amount - refunded
+		}
+		if amount <= 0 || refunded+amount > deposit.Amount {
+			return errRefundTooLarge
+		}
+
+		var wallet models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", deposit.UserID).First(&wallet).Error; err != nil {
+			return err
+		}
+		if wallet.Available() < amount {
+			return errInsufficientBalance
+		}
+
+		wallet.Balance -= amount
+		if err := tx.Save(&wallet).Error; err != nil {
+			return err
+		}
+
+		debit := models.Transaction{
+			UserID:    deposit.UserID,
+			Type:      models.TransactionTypeRefund,
+			Amount:    amount,
+			Currency:  deposit.Currency,
+			Status:    models.TransactionStatusPending,
+			Reference: utils.GenerateReference(),
+			Provider:  deposit.Provider,
+			Narration: "Refund of deposit " + deposit.Reference,
+		}
+		applyBalance(&debit, wallet, -amount)
+		if err := tx.Create(&debit).Error; err != nil {
+			return err
+		}
+
+		refund = models.Refund{
+			UserID:           deposit.UserID,
+			DepositID:        deposit.ID,
+			DepositReference: deposit.Reference,
+			TransactionID:    debit.ID,
+			Reference:        debit.Reference,
+			Provider:         deposit.Provider,
+			Amount:           amount,
+			Currency:         deposit.Currency,
+			Status:           models.RefundStatusPending,
+			Reason:           req.Reason,
+			RequestedBy:      requestedBy,
+		}
+		return tx.Create(&refund).Error
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, errDepositNotRefundable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only successful deposits can be refunded"})
+		case errors.Is(err, errDepositDisputed):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Deposits with an open or lost dispute cannot be refunded"})
+		case errors.Is(err, errRefundTooLarge):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Refund amount exceeds what is left to refund on this deposit"})
+		case errors.Is(err, errInsufficientBalance):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
+		default:
+			log.Println("Failed to create refund:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create refund"})
+		}
+		return
+	}
+
+	result, err := provider.Refund(c.Request.Context(), deposit.Reference, refund.Amount)
+	if err != nil {
+		log.Printf("%s refund error for %s: %v", provider.Name(), refund.Reference, err)
+
+		var networkErr *services.NetworkError
+		if errors.As(err, &networkErr) {
+			// The provider may have accepted the refund; its webhook settles it
+			log.Printf("ALERT: refund %s left pending, provider outcome unknown", refund.Reference)
+			c.JSON(http.StatusOK, toRefundResponse(refund))
+			return
+		}
+
+		if failErr := settleRefund(refund.ID, models.RefundStatusFailed, err.Error()); failErr != nil {
+			log.Println("Failed to reverse refund:", failErr)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse refund"})
+			return
+		}
+		respondPaymentError(c, err)
+		return
+	}
+
+	updates := map[string]interface{}{"provider_refund_id": result.ID}
+	if err := database.DB.Model(&refund).Updates(updates).Error; err != nil {
+		log.Println("Failed to record provider refund id:", err)
+	}
+
+	switch result.Status {
+	case services.RefundStatusProcessed:
+		err = settleRefund(refund.ID, models.RefundStatusProcessed, "")
+	case services.RefundStatusFailed:
+		err = settleRefund(refund.ID, models.RefundStatusFailed, result.Message)
+	}
+	if err != nil {
+		log.Println("Failed to settle refund:", err)
+	}
+
+	if err := database.DB.First(&refund, "id = ?", refund.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refund"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toRefundResponse(refund))
+}
+
+// ListRefunds godoc
+// @Summary List refunds
+// @Description Retrieve refunds of the authenticated user's deposits, newest first
+// @Tags Refunds
+// @Produce json
+// @Success 200 {array} RefundResponse
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/refunds [get]
+func ListRefunds(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var refunds []models.Refund
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&refunds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refunds"})
+		return
+	}
+
+	response := make([]RefundResponse, 0, len(refunds))
+	for _, refund := range refunds {
+		response = append(response, toRefundResponse(refund))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// processRefundEvent applies a refund webhook. Refunds are matched by the
+// provider's refund id, falling back to the oldest pending refund of the
+// same amount against the deposit.
+func processRefundEvent(providerName string, event services.Refund) error {
+	var refund models.Refund
+
+	err := gorm.ErrRecordNotFound
+	if event.ID != "" {
+		err = database.DB.Where("provider = ? AND status = ?", providerName, models.RefundStatusPending).
+			Where("provider_refund_id = ?", event.ID).First(&refund).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = database.DB.Where("provider = ? AND status = ?", providerName, models.RefundStatusPending).
+			Where("deposit_reference = ? AND amount = ?", event.Reference, event.Amount).
+			Order("created_at").First(&refund).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Already settled, or made outside the service (e.g. from the dashboard)
+		log.Printf("Refund event for %s (%s) matches no pending refund", event.Reference, event.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if event.Status == services.RefundStatusFailed {
+		return settleRefund(refund.ID, models.RefundStatusFailed, event.Message)
+	}
+	return settleRefund(refund.ID, models.RefundStatusProcessed, "")
+}
+
+// settleRefund moves a pending refund to its final status. A failed refund
+// credits the wallet back. It is safe to call more than once.
+func settleRefund(refundID string, status models.RefundStatus, failureReason string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var refund models.Refund
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&refund, "id = ?", refundID).Error; err != nil {
+			return err
+		}
+
+		if refund.Status != models.RefundStatusPending {
+			log.Printf("Refund already settled: %s (%s)", refund.Reference, refund.Status)
+			return nil
+		}
+
+		now := time.Now()
+		refund.Status = status
+		refund.FailureReason = failureReason
+		refund.ProcessedAt = &now
+		if err := tx.Save(&refund).Error; err != nil {
+			return err
+		}
+
+		transactionStatus := models.TransactionStatusSuccess
+		if status == models.RefundStatusFailed {
+			transactionStatus = models.TransactionStatusFailed
+		}
+		if err := tx.Model(&models.Transaction{}).Where("id = ?", refund.TransactionID).
+			Updates(map[string]interface{}{
+				"status":           transactionStatus,
+				"gateway_response": failureReason,
+			}).Error; err != nil {
+			return err
+		}
+
+		if status != models.RefundStatusFailed {
+			log.Printf("Refund processed: %s, Amount: %d", refund.Reference, refund.Amount)
+			return nil
+		}
+
+		var wallet models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", refund.UserID).First(&wallet).Error; err != nil {
+			return err
+		}
+
+		wallet.Balance += refund.Amount
+		if err := tx.Save(&wallet).Error; err != nil {
+			return err
+		}
+
+		reversal := models.Transaction{
+			UserID:          refund.UserID,
+			Type:            models.TransactionTypeRefundReversal,
+			Amount:          refund.Amount,
+			Currency:        refund.Currency,
+			Status:          models.TransactionStatusSuccess,
+			Reference:       refund.Reference + "_rev",
+			Provider:        refund.Provider,
+			GatewayResponse: failureReason,
+			Narration:       "Refund of deposit " + refund.DepositReference + " failed",
+		}
+		applyBalance(&reversal, wallet, refund.Amount)
+		if err := tx.Create(&reversal).Error; err != nil {
+			return err
+		}
+
+		log.Printf("Refund failed: %s, Amount: %d re-credited, New Balance: %d", refund.Reference, refund.Amount, wallet.Balance)
+		return nil
+	})
+}
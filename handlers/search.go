@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"wallet-service/database"
+	"wallet-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSearchQueryLength bounds the text a search can be given.
+const maxSearchQueryLength = 200
+
+// searchQuery parses what users type: words, "quoted phrases", OR and -word.
+const searchQuery = "websearch_to_tsquery('simple', ?)"
+
+// counterpartyVector is the text a counterparty is found by.
+const counterpartyVector = "to_tsvector('simple', wallets.wallet_number || ' ' || COALESCE(users.name, ''))"
+
+type SearchResult struct {
+	TransactionItem
+	Rank float32 `json:"rank" example:"0.6079"` // Higher is a better match
+}
+
+type SearchPage struct {
+	Data       []SearchResult `json:"data"`
+	Pagination Pagination     `json:"pagination"`
+}
+
+// rankedTransaction is a transaction read back with its search rank.
+type rankedTransaction struct {
+	models.Transaction
+	Rank float32
+}
+
+// SearchTransactions godoc
+// @Summary Search transactions
+// @Description Full-text search over the authenticated user's transactions, best matches first. A transaction matches when its reference, narration, note or metadata values match q, or when the name or wallet number of its counterparty does. Words match whole and ignore case; q can use "quoted phrases", OR, and -word to exclude. The history filters narrow the results further. Pass the returned next_cursor as cursor to get the next page.
+// @Tags Wallet
+// @Produce json
+// @Param q query string true "Search text"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param type query string false "Comma-separated transaction types, e.g. deposit,transfer"
+// @Param status query string false "Comma-separated statuses, e.g. success,pending"
+// @Param from query string false "Earliest creation time, YYYY-MM-DD or RFC 3339"
+// @Param to query string false "Latest creation time, YYYY-MM-DD (inclusive) or RFC 3339"
+// @Param category query string false "Category ID, or none for uncategorized transactions"
+// @Param tags query string false "Comma-separated tags the transaction must all carry"
+// @Param include_abandoned query bool false "Include abandoned and cancelled deposits"
+// @Success 200 {object} SearchPage
+// @Failure 400 {object} map[string]interface{} "Missing query, invalid filter or cursor"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/transactions/search [get]
+func SearchTransactions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	if utf8.RuneCountInString(q) > maxSearchQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("q must be at most %d characters", maxSearchQueryLength)})
+		return
+	}
+
+	limit := defaultHistoryLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = min(n, maxHistoryLimit)
+	}
+
+	categorizeForRead(userID.(string))
+
+	// Counterparties live in other tables, so the ones that match are found
+	// first, among the wallets this user has dealt with
+	counterpartyIDs, err := searchCounterparties(userID.(string), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search transactions"})
+		return
+	}
+
+	matches := database.DB.Where("search_vector @@ "+searchQuery, q)
+	if len(counterpartyIDs) > 0 {
+		matches = matches.Or("COALESCE(recipient_wallet_id, sender_wallet_id) IN ?", counterpartyIDs)
+	}
+	query, empty, err := filterTransactions(c, database.DB.Where("user_id = ?", userID).Where(matches))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page := SearchPage{Data: []SearchResult{}, Pagination: Pagination{Limit: limit}}
+	if empty {
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
+	// A counterparty match ranks like a match in the narration
+	ranked := query.Model(&models.Transaction{}).Select(
+		"transactions.*, (ts_rank(search_vector, "+searchQuery+") + COALESCE(("+
+			"SELECT ts_rank(setweight("+counterpartyVector+", 'B'), "+searchQuery+") FROM wallets "+
+			"JOIN users ON users.id = wallets.user_id "+
+			"WHERE wallets.id = CAST(COALESCE(transactions.recipient_wallet_id, transactions.sender_wallet_id) AS uuid)"+
+			"), 0))::real AS rank", q, q)
+	results := database.DB.Table("(?) AS results", ranked)
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		rank, createdAt, id, err := decodeSearchCursor(cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		results = results.Where("(rank, created_at, id) < (?, ?, ?)", rank, createdAt, id)
+	}
+
+	// One extra row tells us whether there is another page
+	var transactions []rankedTransaction
+	if err := results.Order("rank DESC, created_at DESC, id DESC").Limit(limit + 1).Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search transactions"})
+		return
+	}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		page.Pagination.HasMore = true
+		page.Pagination.NextCursor = encodeSearchCursor(last.Rank, last.CreatedAt, last.ID)
+	}
+
+	legs := make([]models.Transaction, len(transactions))
+	for i, tx := range transactions {
+		legs[i] = tx.Transaction
+	}
+	counterparties, err := loadCounterparties(legs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search transactions"})
+		return
+	}
+
+	for _, tx := range transactions {
+		page.Data = append(page.Data, SearchResult{
+			TransactionItem: toTransactionItem(tx.Transaction, counterparties),
+			Rank:            tx.Rank,
+		})
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// searchCounterparties returns the wallets a user has transacted with whose
+// wallet number or owner's name matches a search.
+func searchCounterparties(userID, q string) ([]string, error) {
+	dealtWith := database.DB.Model(&models.Transaction{}).
+		Select("DISTINCT CAST(COALESCE(recipient_wallet_id, sender_wallet_id) AS uuid)").
+		Where("user_id = ? AND COALESCE(recipient_wallet_id, sender_wallet_id) IS NOT NULL", userID)
+
+	var walletIDs []string
+	err := database.DB.Table("wallets").
+		Joins("JOIN users ON users.id = wallets.user_id").
+		Where("wallets.id IN (?)", dealtWith).
+		Where(counterpartyVector+" @@ "+searchQuery, q).
+		Pluck("wallets.id", &walletIDs).Error
+	return walletIDs, err
+}
+
+// Search cursors point at the last result of a page: its rank, creation time
+// and ID.
+func encodeSearchCursor(rank float32, createdAt time.Time, id string) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + "|" + createdAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSearchCursor(cursor string) (float32, time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, time.Time{}, "", errInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return 0, time.Time{}, "", errInvalidCursor
+	}
+	rank, err := strconv.ParseFloat(parts[0], 32)
+	if err != nil {
+		return 0, time.Time{}, "", errInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return 0, time.Time{}, "", errInvalidCursor
+	}
+	return float32(rank), createdAt, parts[2], nil
+}
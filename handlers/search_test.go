@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestDecodeSearchCursor(t *testing.T) {
+	createdAt := time.Date(2026, 3, 14, 9, 26, 53, 589793000, time.UTC)
+	id := "0f8e3c1a-6b2d-4e8f-9a1b-2c3d4e5f6a7b"
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name     string
+		cursor   string
+		wantRank float32
+		wantErr  bool
+	}{
+		{name: "round trip", cursor: encodeSearchCursor(0.6079271, createdAt, id), wantRank: 0.6079271},
+		{name: "zero rank", cursor: encodeSearchCursor(0, createdAt, id), wantRank: 0},
+		{name: "tiny rank", cursor: encodeSearchCursor(1e-20, createdAt, id), wantRank: 1e-20},
+		{name: "not base64", cursor: "not a cursor!", wantErr: true},
+		{name: "padded base64", cursor: base64.URLEncoding.EncodeToString([]byte("0.5|2026-03-14T09:26:53Z|" + id)), wantErr: true},
+		{name: "too few parts", cursor: encode("0.5|2026-03-14T09:26:53Z"), wantErr: true},
+		{name: "empty id", cursor: encode("0.5|2026-03-14T09:26:53Z|"), wantErr: true},
+		{name: "bad rank", cursor: encode("high|2026-03-14T09:26:53Z|" + id), wantErr: true},
+		{name: "bad time", cursor: encode("0.5|2026-03-14|" + id), wantErr: true},
+		{name: "history cursor", cursor: encode("2026-03-14T09:26:53Z|" + id), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rank, gotCreatedAt, gotID, err := decodeSearchCursor(tt.cursor)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeSearchCursor(%q) succeeded, want an error", tt.cursor)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeSearchCursor(%q) error = %v", tt.cursor, err)
+			}
+			if rank != tt.wantRank || !gotCreatedAt.Equal(createdAt) || gotID != id {
+				t.Errorf("decodeSearchCursor(%q) = (%v, %v, %q), want (%v, %v, %q)",
+					tt.cursor, rank, gotCreatedAt, gotID, tt.wantRank, createdAt, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+	"wallet-service/config"
+	"wallet-service/database"
+	"wallet-service/models"
+	"wallet-service/statements"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// statementBatchSize is how many transactions are loaded at a time while
+// building a statement.
+const statementBatchSize = 1000
+
+// StatementResponse is a statement being generated in the background.
+type StatementResponse struct {
+	models.Statement
+	DownloadURL string `json:"download_url,omitempty" example:"/wallet/statements/3f2c.../download"` // Set once ready
+}
+
+// GetStatement godoc
+// @Summary Download an account statement
+// @Description Generate a statement of every balance change between two dates (inclusive, UTC), with opening and closing balances, a running balance per line and totals. Formats: csv, pdf, ofx and qfx (personal finance apps), qif, and camt053 (ISO 20022 XML for ERPs). Statements with more lines than STATEMENT_SYNC_MAX_LINES are generated in the background instead: the response is 202 with a statement to poll, which carries a download_url once ready.
+// @Tags Statements
+// @Produce text/csv
+// @Produce application/pdf
+// @Produce application/x-ofx
+// @Produce application/xml
+// @Produce json
+// @Param from query string true "First day, YYYY-MM-DD"
+// @Param to query string true "Last day, YYYY-MM-DD"
+// @Param format query string false "csv, pdf, ofx, qfx, qif or camt053 (default pdf)"
+// @Success 200 {file} file "The statement"
+// @Success 202 {object} StatementResponse "Generating in the background"
+// @Failure 400 {object} map[string]interface{} "Invalid dates or format"
+// @Failure 404 {object} map[string]interface{} "Wallet not found"
+// @Failure 422 {object} map[string]interface{} "No balance history for the period"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/statement [get]
+func GetStatement(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	from, errFrom := time.Parse(time.DateOnly, c.Query("from"))
+	to, errTo := time.Parse(time.DateOnly, c.Query("to"))
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be dates (YYYY-MM-DD)"})
+		return
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+	if from.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from cannot be in the future"})
+		return
+	}
+
+	format := c.DefaultQuery("format", statements.FormatPDF)
+	if !statements.IsFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, pdf, ofx, qfx, qif or camt053"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Preload("Wallet").Where("id = ?", userID).First(&user).Error; err != nil || user.Wallet == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+		return
+	}
+
+	var count int64
+	if err := statementTransactions(user.ID, from, to).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate statement"})
+		return
+	}
+
+	if count > int64(config.AppConfig.StatementSyncMaxLines) {
+		job := models.Statement{
+			UserID: user.ID,
+			Format: format,
+			From:   from,
+			To:     to,
+			Status: models.StatementStatusPending,
+		}
+		if err := database.DB.Create(&job).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate statement"})
+			return
+		}
+		go generateStatement(job.ID)
+
+		c.JSON(http.StatusAccepted, toStatementResponse(job))
+		return
+	}
+
+	statement, err := buildStatement(user, *user.Wallet, from, to)
+	if errors.Is(err, errBalanceHistoryUnavailable) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No balance history for this period"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to build statement for %s: %v", user.Wallet.WalletNumber, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate statement"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := statements.Write(&buf, format, statement); err != nil {
+		log.Printf("Failed to render statement for %s: %v", user.Wallet.WalletNumber, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate statement"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, statementFilename(user.Wallet.WalletNumber, from, to, format)))
+	c.Data(http.StatusOK, statements.ContentType(format), buf.Bytes())
+}
+
+// ListStatements godoc
+// @Summary List background statements
+// @Description Retrieve the statements generated in the background for the authenticated user, newest first
+// @Tags Statements
+// @Produce json
+// @Success 200 {array} StatementResponse
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/statements [get]
+func ListStatements(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var jobs []models.Statement
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statements"})
+		return
+	}
+
+	response := make([]StatementResponse, 0, len(jobs))
+	for _, job := range jobs {
+		response = append(response, toStatementResponse(job))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetStatementStatus godoc
+// @Summary Get a background statement
+// @Description Retrieve a statement being generated in the background; download_url is set once it is ready
+// @Tags Statements
+// @Produce json
+// @Param id path string true "Statement ID"
+// @Success 200 {object} StatementResponse
+// @Failure 404 {object} map[string]interface{} "Statement not found"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/statements/{id} [get]
+func GetStatementStatus(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var job models.Statement
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Statement not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toStatementResponse(job))
+}
+
+// DownloadStatement godoc
+// @Summary Download a background statement
+// @Description Download a statement generated in the background
+// @Tags Statements
+// @Produce text/csv
+// @Produce application/pdf
+// @Produce application/x-ofx
+// @Produce application/xml
+// @Param id path string true "Statement ID"
+// @Success 200 {file} file "The statement"
+// @Failure 404 {object} map[string]interface{} "Statement not found"
+// @Failure 409 {object} map[string]interface{} "Statement not ready"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/statements/{id}/download [get]
+func DownloadStatement(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var job models.Statement
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Statement not found"})
+		return
+	}
+	if job.Status != models.StatementStatusReady {
+		c.JSON(http.StatusConflict, gin.H{"error": "Statement is " + string(job.Status)})
+		return
+	}
+
+	var wallet models.Wallet
+	if err := database.DB.Where("user_id = ?", job.UserID).First(&wallet).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+		return
+	}
+
+	c.Header("Content-Type", statements.ContentType(job.Format))
+	c.FileAttachment(job.FilePath, statementFilename(wallet.WalletNumber, job.From, job.To, job.Format))
+}
+
+// ResumeStatements generates statements left pending, e.g. by a restart.
+func ResumeStatements() {
+	var ids []string
+	if err := database.DB.Model(&models.Statement{}).Where("status = ?", models.StatementStatusPending).
+		Pluck("id", &ids).Error; err != nil {
+		log.Println("Failed to load pending statements:", err)
+		return
+	}
+
+	for _, id := range ids {
+		go generateStatement(id)
+	}
+}
+
+// generateStatement builds a background statement and saves it under
+// STATEMENT_DIR.
+func generateStatement(id string) {
+	var job models.Statement
+	if err := database.DB.First(&job, "id = ?", id).Error; err != nil {
+		log.Printf("Failed to load statement %s: %v", id, err)
+		return
+	}
+
+	path, lineCount, err := writeStatementFile(job)
+	if err != nil {
+		log.Printf("Failed to generate statement %s: %v", id, err)
+		message := "Failed to generate statement"
+		if errors.Is(err, errBalanceHistoryUnavailable) {
+			message = "No balance history for this period"
+		}
+		if err := database.DB.Model(&job).Updates(map[string]interface{}{
+			"status": models.StatementStatusFailed,
+			"error":  message,
+		}).Error; err != nil {
+			log.Printf("Failed to mark statement %s failed: %v", id, err)
+		}
+		return
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&job).Updates(map[string]interface{}{
+		"status":       models.StatementStatusReady,
+		"file_path":    path,
+		"line_count":   lineCount,
+		"completed_at": &now,
+	}).Error; err != nil {
+		log.Printf("Failed to mark statement %s ready: %v", id, err)
+		return
+	}
+
+	log.Printf("Statement %s ready: %d lines", id, lineCount)
+}
+
+func writeStatementFile(job models.Statement) (string, int, error) {
+	var user models.User
+	if err := database.DB.Preload("Wallet").Where("id = ?", job.UserID).First(&user).Error; err != nil {
+		return "", 0, err
+	}
+	if user.Wallet == nil {
+		return "", 0, errors.New("wallet not found")
+	}
+
+	statement, err := buildStatement(user, *user.Wallet, job.From, job.To)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := os.MkdirAll(config.AppConfig.StatementDir, 0o700); err != nil {
+		return "", 0, err
+	}
+	path := filepath.Join(config.AppConfig.StatementDir, job.ID+"."+statements.Extension(job.Format))
+
+	// Written aside and renamed, so a half-written file is never served
+	file, err := os.CreateTemp(config.AppConfig.StatementDir, job.ID+"-*.tmp")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(file.Name())
+
+	if err := statements.Write(file, job.Format, statement); err != nil {
+		file.Close()
+		return "", 0, err
+	}
+	if err := file.Close(); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", 0, err
+	}
+	return path, len(statement.Lines), nil
+}
+
+// statementTransactions are the transactions that moved a user's balance
+// between two dates, both inclusive.
+func statementTransactions(userID string, from, to time.Time) *gorm.DB {
+	return database.DB.Model(&models.Transaction{}).
+		Where("user_id = ? AND applied_at >= ? AND applied_at < ?", userID, from, to.AddDate(0, 0, 1))
+}
+
+// buildStatement loads the balance changes between two dates (inclusive) in
+// the order they were applied.
+func buildStatement(user models.User, wallet models.Wallet, from, to time.Time) (*statements.Statement, error) {
+	opening, err := balanceAt(wallet, from)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := statements.New(opening)
+	statement.AccountName = user.Name
+	statement.Email = user.Email
+	statement.WalletNumber = wallet.WalletNumber
+	statement.Currency = defaultCurrency
+	statement.From = from
+	statement.To = to
+	statement.GeneratedAt = time.Now()
+
+	var after *models.Transaction
+	for {
+		query := statementTransactions(user.ID, from, to)
+		if after != nil {
+			query = query.Where("(applied_at, id) > (?, ?)", after.AppliedAt, after.ID)
+		}
+
+		var transactions []models.Transaction
+		if err := query.Order("applied_at, id").Limit(statementBatchSize).Find(&transactions).Error; err != nil {
+			return nil, err
+		}
+
+		counterparties, err := loadCounterparties(transactions)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range transactions {
+			statement.AddLine(*tx.AppliedAt, tx.Reference, string(tx.Type),
+				describeTransaction(tx, counterparties), *tx.BalanceAfter-*tx.BalanceBefore)
+		}
+
+		if len(transactions) < statementBatchSize {
+			return statement, nil
+		}
+		after = &transactions[len(transactions)-1]
+	}
+}
+
+// describeTransaction is a statement line's description: the narration,
+// else what the transaction was and who with.
+func describeTransaction(tx models.Transaction, counterparties map[string]Counterparty) string {
+	if tx.Narration != "" {
+		return tx.Narration
+	}
+
+	counterparty, ok := counterparties[counterpartyWalletID(tx)]
+	who := counterparty.Name
+	if who == "" {
+		who = counterparty.WalletNumber
+	}
+
+	switch {
+	case tx.Type == models.TransactionTypeTransfer && ok:
+		return "Transfer to " + who
+	case tx.Type == models.TransactionTypeCredit && ok:
+		return "Transfer from " + who
+	case tx.Type == models.TransactionTypeDeposit && tx.Channel != "":
+		return "Deposit by " + tx.Channel
+	case tx.Type == models.TransactionTypeDeposit:
+		return "Deposit"
+	}
+	return string(tx.Type)
+}
+
+func statementFilename(walletNumber string, from, to time.Time, format string) string {
+	return fmt.Sprintf("statement-%s-%s-%s.%s", walletNumber, from.Format(time.DateOnly), to.Format(time.DateOnly), statements.Extension(format))
+}
+
+func toStatementResponse(job models.Statement) StatementResponse {
+	response := StatementResponse{Statement: job}
+	if job.Status == models.StatementStatusReady {
+		response.DownloadURL = "/wallet/statements/" + job.ID + "/download"
+	}
+	return response
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"wallet-service/config"
+	"wallet-service/database"
+	"wallet-service/models"
+	"wallet-service/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VirtualAccountResponse struct {
+	AccountNumber string `json:"account_number" example:"9930000000"`
+	AccountName   string `json:"account_name" example:"WALLET/JOHN DOE"`
+	BankName      string `json:"bank_name" example:"Wema Bank"`
+	Currency      string `json:"currency" example:"NGN"`
+	Active        bool   `json:"active" example:"true"`
+}
+
+type CreateVirtualAccountRequest struct {
+	Phone string `json:"phone" example:"+2348000000000"`
+}
+
+func toVirtualAccountResponse(account models.VirtualAccount) VirtualAccountResponse {
+	return VirtualAccountResponse{
+		AccountNumber: account.AccountNumber,
+		AccountName:   account.AccountName,
+		BankName:      account.BankName,
+		Currency:      account.Currency,
+		Active:        account.Active,
+	}
+}
+
+// CreateVirtualAccount godoc
+// @Summary Get a dedicated virtual account
+// @Description Assign a dedicated bank account number to the wallet. Bank transfers into it are credited automatically. Calling this again returns the existing account.
+// @Tags Virtual Accounts
+// @Accept json
+// @Produce json
+// @Param request body CreateVirtualAccountRequest false "Phone number, required by some banks"
+// @Success 200 {object} VirtualAccountResponse "Existing account"
+// @Success 201 {object} VirtualAccountResponse "New account"
+// @Failure 404 {object} map[string]interface{} "Wallet not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 503 {object} map[string]interface{} "Payment provider unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/virtual-account [post]
+func CreateVirtualAccount(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req CreateVirtualAccountRequest
+	// The body is optional
+	_ = c.ShouldBindJSON(&req)
+
+	var virtualAccount models.VirtualAccount
+	var existing bool
+	var providerErr error
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Requests for the same wallet wait here, so only one of them asks
+		// the provider for an account and the rest find it saved
+		var wallet models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("user_id = ?", userID).First(&virtualAccount).Error
+		if err == nil {
+			existing = true
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var user models.User
+		if err := tx.Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+
+		firstName, lastName, _ := strings.Cut(strings.TrimSpace(user.Name), " ")
+
+		account, err := paystack.CreateDedicatedAccount(c.Request.Context(), services.DedicatedAccountRequest{
+			Email:         user.Email,
+			FirstName:     firstName,
+			LastName:      strings.TrimSpace(lastName),
+			Phone:         req.Phone,
+			PreferredBank: config.AppConfig.PaystackDVABank,
+		})
+		if err != nil {
+			providerErr = err
+			return err
+		}
+
+		virtualAccount = models.VirtualAccount{
+			UserID:            user.ID,
+			WalletID:          wallet.ID,
+			Provider:          paystack.Name(),
+			ProviderAccountID: account.ID,
+			CustomerCode:      account.CustomerCode,
+			AccountNumber:     account.AccountNumber,
+			AccountName:       account.AccountName,
+			BankName:          account.BankName,
+			BankSlug:          account.BankSlug,
+			Currency:          account.Currency,
+			Active:            account.Active,
+		}
+		return tx.Create(&virtualAccount).Error
+	})
+	switch {
+	case providerErr != nil:
+		log.Println("Dedicated account creation error:", providerErr)
+		respondPaymentError(c, providerErr)
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+		return
+	case err != nil:
+		log.Println("Failed to save virtual account:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save virtual account"})
+		return
+	case existing:
+		c.JSON(http.StatusOK, toVirtualAccountResponse(virtualAccount))
+		return
+	}
+
+	c.JSON(http.StatusCreated, toVirtualAccountResponse(virtualAccount))
+}
+
+// GetVirtualAccount godoc
+// @Summary Get the wallet's dedicated virtual account
+// @Description Retrieve the bank account number that funds this wallet by transfer
+// @Tags Virtual Accounts
+// @Produce json
+// @Success 200 {object} VirtualAccountResponse
+// @Failure 404 {object} map[string]interface{} "No virtual account yet"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /wallet/virtual-account [get]
+func GetVirtualAccount(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var account models.VirtualAccount
+	if err := database.DB.Where("user_id = ?", userID).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No virtual account yet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toVirtualAccountResponse(account))
+}
+
+// processVirtualAccountDeposit credits a bank transfer into a dedicated
+// virtual account. There is no pending deposit to match against; the wallet
+// is found by the receiving account number, and the provider's reference
+// keeps repeated webhooks from crediting twice.
+func processVirtualAccountDeposit(charge services.Charge) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var account models.VirtualAccount
+		err := gorm.ErrRecordNotFound
+		if charge.VirtualAccountNumber != "" {
+			err = tx.Where("account_number = ? AND provider = ?", charge.VirtualAccountNumber, charge.Provider).First(&account).Error
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) && charge.Customer != "" {
+			err = tx.Where("customer_code = ? AND provider = ?", charge.Customer, charge.Provider).First(&account).Error
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Retrying will not make the account appear, so do not fail the webhook
+			log.Printf("ALERT: transfer %s into unknown virtual account %q (customer %q)",
+				charge.Reference, charge.VirtualAccountNumber, charge.Customer)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var wallet models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", account.WalletID).First(&wallet).Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.Transaction{}).Where("reference = ?", charge.Reference).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			log.Println("Transaction already processed:", charge.Reference)
+			return nil
+		}
+
+		transaction := models.Transaction{
+			UserID:          account.UserID,
+			Type:            models.TransactionTypeDeposit,
+			Amount:          charge.Amount,
+			Currency:        account.Currency,
+			Status:          models.TransactionStatusSuccess,
+			Reference:       charge.Reference,
+			Provider:        charge.Provider,
+			Channel:         charge.Channel,
+			GatewayResponse: charge.GatewayResponse,
+			Fees:            charge.Fees,
+			PaidAt:          charge.PaidAt,
+		}
+		if charge.SenderName != "" {
+			transaction.Narration = "Bank transfer from " + charge.SenderName
+		}
+
+		if charge.Currency != "" && !strings.EqualFold(charge.Currency, account.Currency) {
+			transaction.Status = models.TransactionStatusReview
+			transaction.ReviewReason = "currency mismatch: expected " + account.Currency + ", received " + charge.Currency
+		}
+
+		if transaction.Status == models.TransactionStatusSuccess {
+			wallet.Balance += charge.Amount
+			applyBalance(&transaction, wallet, charge.Amount)
+		}
+
+		if err := tx.Create(&transaction).Error; err != nil {
+			return err
+		}
+
+		if transaction.Status != models.TransactionStatusSuccess {
+			log.Printf("ALERT: deposit %s moved to %s: %s", charge.Reference, transaction.Status, transaction.ReviewReason)
+			return nil
+		}
+
+		if err := tx.Save(&wallet).Error; err != nil {
+			return err
+		}
+
+		log.Printf("Bank transfer deposit processed: %s from %s, Amount: %d, New Balance: %d",
+			charge.Reference, charge.SenderName, charge.Amount, wallet.Balance)
+		return nil
+	})
+}
@@ -1,14 +1,15 @@
 package handlers
 
 import (
-	"crypto/hmac"
-	"crypto/sha512"
-	"encoding/hex"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 	"wallet-service/config"
 	"wallet-service/database"
 	"wallet-service/models"
@@ -17,94 +18,278 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-var paystackService = services.NewPaystackService()
+var paymentProviders *services.ProviderRegistry
+
+// paystack is also used directly for Paystack-only features such as saved cards.
+var paystack *services.PaystackService
+
+// defaultCurrency is the currency wallets are held in.
+const defaultCurrency = "NGN"
+
+// InitPaymentProviders registers the payment providers enabled in the
+// configuration. Paystack is always enabled; others need their credentials.
+func InitPaymentProviders() {
+	paystack = services.NewPaystackService()
+
+	providers := []services.PaymentProvider{paystack}
+	if config.AppConfig.FlutterwaveSecretKey != "" {
+		providers = append(providers, services.NewFlutterwaveService())
+	}
+
+	paymentProviders = services.NewProviderRegistry(
+		config.AppConfig.PaymentRoutes,
+		config.AppConfig.DefaultPaymentProvider,
+		providers...,
+	)
+}
 
 type DepositRequest struct {
-	Amount int64 `json:"amount" binding:"required,gt=0" example:"5000"`
+	Amount    int64             `json:"amount" binding:"required,gt=0" example:"5000"`
+	Currency  string            `json:"currency" example:"NGN"`
+	Provider  string            `json:"provider" example:"paystack"`
+	Channels  []string          `json:"channels" example:"card,bank_transfer"`
+	Narration string            `json:"narration" example:"Top up for order 1042"`
+	Metadata  map[string]string `json:"metadata" swaggertype:"object,string" example:"order_id:1042"` // Up to 20 keys, passed to the provider
 }
 
 type DepositResponse struct {
 	Reference        string `json:"reference" example:"TXN_1234567890"`
 	AuthorizationURL string `json:"authorization_url" example:"https://checkout.paystack.com/..."`
+	Provider         string `json:"provider" example:"paystack"`
 }
 
 // InitiateDeposit godoc
 // @Summary Initiate wallet deposit
-// @Description Initialize a Paystack transaction for depositing money into wallet
+// @Description Initialize a checkout for depositing money into wallet. The amount and channels must satisfy the configured deposit rules (minimum, maximum, allowed channels and the user's tier caps). The payment provider is picked from configuration (currency and amount routes) unless one is requested; if the chosen provider fails, the next available one is tried. After checkout the customer comes back through /wallet/deposit/callback and is redirected to the frontend with the outcome.
 // @Tags Wallet
 // @Accept json
 // @Produce json
-// @Param request body DepositRequest true "Deposit amount in kobo (100 kobo = ₦1)"
+// @Param request body DepositRequest true "Deposit amount in kobo (100 kobo = ₦1), optional currency (default NGN), provider, payment channels to offer (card, bank, ussd, qr, mobile_money, bank_transfer, eft, apple_pay), narration (max 100 characters) and metadata: up to 20 string key/value pairs (keys max 40, values max 500 characters), stored with the deposit and passed to the provider"
 // @Success 200 {object} DepositResponse
-// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 400 {object} DepositRuleError "Deposit rule broken (other bad requests return only error)"
 // @Failure 404 {object} map[string]interface{} "Wallet not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 502 {object} map[string]interface{} "Payment provider error"
+// @Failure 503 {object} map[string]interface{} "Payment provider unavailable"
 // @Security BearerAuth
 // @Security ApiKeyAuth
 // @Router /wallet/deposit [post]
 func InitiateDeposit(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	email, _ := c.Get("email")
 
 	var req DepositRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount is required and must be greater than 0"})
 		return
 	}
+	if problem := validateAnnotations(req.Narration, req.Metadata); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	metadata, err := encodeMetadata(req.Metadata)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid metadata"})
+		return
+	}
 
-	var wallet models.Wallet
-	if err := database.DB.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	// Wallets hold a single balance, so deposits are only taken in its currency
+	if currency != defaultCurrency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Deposits can only be made in " + defaultCurrency})
+		return
+	}
+
+	candidates, err := paymentProviders.Candidates(currency, req.Amount, req.Provider)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No payment provider available for this deposit"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Preload("Wallet").Where("id = ?", userID).First(&user).Error; err != nil || user.Wallet == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
 		return
 	}
 
+	channels := req.Channels
+	if len(channels) == 0 && len(config.AppConfig.DepositRules.Channels) > 0 {
+		// Keep the checkout to the channels deposits are allowed through
+		channels = allowedDepositChannels()
+	}
+
 	reference := utils.GenerateReference()
 
 	transaction := models.Transaction{
 		UserID:    userID.(string),
 		Type:      models.TransactionTypeDeposit,
 		Amount:    req.Amount,
+		Currency:  currency,
 		Status:    models.TransactionStatusPending,
 		Reference: reference,
+		Provider:  candidates[0].Name(),
+		Narration: req.Narration,
+		Metadata:  metadata,
 	}
 
-	if err := database.DB.Create(&transaction).Error; err != nil {
-		log.Println("Failed to create transaction:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create transaction",
-			"details": err.Error(),
-		})
+	if !createDeposit(c, user, &transaction, req.Channels) {
 		return
 	}
 
-	emailStr := email.(string)
-	result, err := paystackService.InitializeTransaction(emailStr, req.Amount, reference)
-	if err != nil {
-		log.Println("Paystack initialization error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize payment"})
+	checkoutRequest := services.CheckoutRequest{
+		Email:       user.Email,
+		Amount:      req.Amount,
+		Currency:    currency,
+		Reference:   reference,
+		CallbackURL: config.AppConfig.DepositCallbackURL,
+		Channels:    channels,
+		Metadata:    providerMetadata(req.Metadata),
+	}
+
+	var checkout *services.Checkout
+	var provider, tried services.PaymentProvider
+	for _, candidate := range candidates {
+		tried = candidate
+		checkout, err = candidate.InitializeCheckout(c.Request.Context(), checkoutRequest)
+		if err == nil {
+			provider = candidate
+			break
+		}
+		log.Printf("%s initialization error for %s: %v", candidate.Name(), reference, err)
+		// Only fail over when the provider is down; a rejected request would
+		// most likely be rejected elsewhere too
+		if !services.IsUnavailable(err) {
+			break
+		}
+	}
+
+	if provider == nil {
+		if outcomeUnknown(err) {
+			// The provider may have created the checkout before the call
+			// failed, so the deposit stays pending with the provider that
+			// was last asked, for verify or expiry to settle
+			if tried.Name() != transaction.Provider {
+				if err := database.DB.Model(&transaction).Update("provider", tried.Name()).Error; err != nil {
+					log.Println("Failed to record failover provider:", err)
+				}
+			}
+		} else if failErr := markDepositFailed(reference, err.Error()); failErr != nil {
+			log.Println("Failed to mark deposit failed:", failErr)
+		}
+		respondPaymentError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"reference":         reference,
-		"authorization_url": result.Data.AuthorizationURL,
+	if provider.Name() != transaction.Provider {
+		if err := database.DB.Model(&transaction).Update("provider", provider.Name()).Error; err != nil {
+			log.Println("Failed to record failover provider:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize payment"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, DepositResponse{
+		Reference:        reference,
+		AuthorizationURL: checkout.AuthorizationURL,
+		Provider:         provider.Name(),
 	})
 }
 
-type PaystackWebhookEvent struct {
-	Event string `json:"event"`
-	Data  struct {
-		Reference string `json:"reference"`
-		Amount    int64  `json:"amount"`
-		Status    string `json:"status"`
-	} `json:"data"`
+// respondPaymentError turns a payment provider failure into a response the
+// caller can act on.
+func respondPaymentError(c *gin.Context, err error) {
+	var validationErr *services.ValidationError
+	var networkErr *services.NetworkError
+	var providerErr *services.ProviderError
+
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Payment provider rejected the request",
+			"details": validationErr.Message,
+		})
+	case errors.As(err, &networkErr):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Payment provider is unavailable, please try again later"})
+	case errors.As(err, &providerErr):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Payment provider failed to process the request"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize payment"})
+	}
+}
+
+// outcomeUnknown reports whether a provider call failed without saying
+// whether the provider acted on it: it was sent but never answered.
+func outcomeUnknown(err error) bool {
+	var networkErr *services.NetworkError
+	return errors.As(err, &networkErr) && !errors.Is(err, services.ErrCircuitOpen)
+}
+
+// DepositCallback godoc
+// @Summary Checkout callback
+// @Description Payment providers send the customer here after checkout. The deposit is verified with its provider and applied, then the customer is redirected to DEPOSIT_REDIRECT_URL with reference and status (success, failed, pending, review, abandoned, cancelled or not_found) query parameters.
+// @Tags Wallet
+// @Param reference query string false "Deposit reference (Paystack also sends trxref, Flutterwave tx_ref)"
+// @Success 302 "Redirect to the frontend"
+// @Router /wallet/deposit/callback [get]
+func DepositCallback(c *gin.Context) {
+	reference := c.Query("reference")
+	if reference == "" {
+		reference = c.Query("trxref")
+	}
+	if reference == "" {
+		reference = c.Query("tx_ref")
+	}
+
+	if reference == "" {
+		redirectToFrontend(c, reference, "not_found")
+		return
+	}
+
+	var transaction models.Transaction
+	if err := database.DB.Where("reference = ? AND type = ?", reference, models.TransactionTypeDeposit).
+		First(&transaction).Error; err != nil {
+		redirectToFrontend(c, reference, "not_found")
+		return
+	}
+
+	switch transaction.Status {
+	case models.TransactionStatusPending, models.TransactionStatusAbandoned, models.TransactionStatusCancelled:
+		// A customer coming back from checkout may have paid a deposit we
+		// already gave up on
+		if err := verifyPendingDeposit(c.Request.Context(), &transaction); err != nil {
+			// The webhook will still settle it; the frontend shows it as pending
+			log.Println("Failed to verify deposit on callback:", err)
+		} else if err := database.DB.First(&transaction, "id = ?", transaction.ID).Error; err != nil {
+			log.Println("Failed to reload deposit on callback:", err)
+		}
+	}
+
+	redirectToFrontend(c, reference, string(transaction.Status))
+}
+
+func redirectToFrontend(c *gin.Context, reference, status string) {
+	target, err := url.Parse(config.AppConfig.DepositRedirectURL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid deposit redirect URL"})
+		return
+	}
+
+	query := target.Query()
+	query.Set("reference", reference)
+	query.Set("status", status)
+	target.RawQuery = query.Encode()
+
+	c.Redirect(http.StatusFound, target.String())
 }
 
 // PaystackWebhook godoc
 // @Summary Paystack webhook handler
-// @Description Receives and processes payment notifications from Paystack (signature verified)
+// @Description Receives and processes payment notifications from Paystack (signature verified, optionally restricted to Paystack's IPs)
 // @Tags Wallet
 // @Accept json
 // @Produce json
@@ -112,120 +297,340 @@ type PaystackWebhookEvent struct {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 401 {object} map[string]interface{} "Invalid signature"
+// @Failure 403 {object} map[string]interface{} "Source IP not allowed"
+// @Failure 413 {object} map[string]interface{} "Request body too large"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /wallet/paystack/webhook [post]
 func PaystackWebhook(c *gin.Context) {
-	signature := c.GetHeader("x-paystack-signature")
-	if signature == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing signature"})
+	handleProviderWebhook(c, "paystack")
+}
+
+// FlutterwaveWebhook godoc
+// @Summary Flutterwave webhook handler
+// @Description Receives and processes payment notifications from Flutterwave (verif-hash verified)
+// @Tags Wallet
+// @Accept json
+// @Produce json
+// @Param verif-hash header string true "Flutterwave secret hash"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Invalid signature"
+// @Failure 404 {object} map[string]interface{} "Provider not enabled"
+// @Failure 413 {object} map[string]interface{} "Request body too large"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /wallet/flutterwave/webhook [post]
+func FlutterwaveWebhook(c *gin.Context) {
+	handleProviderWebhook(c, "flutterwave")
+}
+
+func handleProviderWebhook(c *gin.Context, providerName string) {
+	provider, ok := paymentProviders.Get(providerName)
+	if !ok {
+		rejectWebhook(c, providerName, "provider_disabled", http.StatusNotFound, "Provider not enabled")
+		return
+	}
+
+	if !webhookSourceAllowed(providerName, c.ClientIP()) {
+		rejectWebhook(c, providerName, "ip_not_allowed", http.StatusForbidden, "Webhook source not allowed")
 		return
 	}
 
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.AppConfig.WebhookMaxBodyBytes)
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			rejectWebhook(c, providerName, "body_too_large", http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		rejectWebhook(c, providerName, "unreadable_body", http.StatusBadRequest, "Failed to read request body")
 		return
 	}
 
-	if !verifyPaystackSignature(body, signature) {
-		log.Println("Invalid Paystack signature")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+	if !provider.VerifyWebhook(c.Request.Header, body) {
+		rejectWebhook(c, providerName, "invalid_signature", http.StatusUnauthorized, "Invalid signature")
 		return
 	}
 
-	var event PaystackWebhookEvent
-	if err := json.Unmarshal(body, &event); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	event, err := provider.ParseWebhook(body)
+	if err != nil {
+		rejectWebhook(c, providerName, "invalid_payload", http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if event.Event != "charge.success" {
+	// Retrying an event that came without its data would not help
+	if missingEventData(event) {
+		log.Printf("ALERT: %s %s event arrived without its data, ignoring it", providerName, event.Type)
+		webhookMetrics.Add(providerName+".accepted", 1)
 		c.JSON(http.StatusOK, gin.H{"status": true})
 		return
 	}
 
-	if err := processSuccessfulDeposit(event.Data.Reference, event.Data.Amount); err != nil {
-		log.Println("Failed to process deposit:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process deposit"})
+	switch event.Type {
+	case services.EventChargeSuccess:
+		if event.Charge.VirtualAccount {
+			err = processVirtualAccountDeposit(*event.Charge)
+		} else {
+			err = processSuccessfulDeposit(*event.Charge)
+		}
+	case services.EventChargeFailed:
+		err = markDepositFailed(event.Charge.Reference, event.Charge.GatewayResponse)
+	case services.EventRefundProcessed, services.EventRefundFailed:
+		err = processRefundEvent(providerName, *event.Refund)
+	case services.EventDisputeCreated, services.EventDisputeUpdated, services.EventDisputeResolved:
+		err = processDisputeEvent(providerName, event.Type, *event.Dispute)
+	case services.EventPayoutSuccess, services.EventPayoutFailed:
+		err = processPayoutEvent(*event.Payout)
+	}
+
+	if err != nil {
+		webhookMetrics.Add(providerName+".failed", 1)
+		log.Printf("Failed to process %s event: %v", event.Type, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process event"})
 		return
 	}
 
+	webhookMetrics.Add(providerName+".accepted", 1)
 	c.JSON(http.StatusOK, gin.H{"status": true})
 }
 
-func verifyPaystackSignature(body []byte, signature string) bool {
-	mac := hmac.New(sha512.New, []byte(config.AppConfig.PaystackSecretKey))
-	mac.Write(body)
-	expectedSignature := hex.EncodeToString(mac.Sum(nil))
-	return hmac.Equal([]byte(signature), []byte(expectedSignature))
+// missingEventData reports whether event lacks the data its type is handled
+// with.
+func missingEventData(event *services.WebhookEvent) bool {
+	switch event.Type {
+	case services.EventChargeSuccess, services.EventChargeFailed:
+		return event.Charge == nil
+	case services.EventRefundProcessed, services.EventRefundFailed:
+		return event.Refund == nil
+	case services.EventDisputeCreated, services.EventDisputeUpdated, services.EventDisputeResolved:
+		return event.Dispute == nil
+	case services.EventPayoutSuccess, services.EventPayoutFailed:
+		return event.Payout == nil
+	}
+	return false
 }
 
-func processSuccessfulDeposit(reference string, amount int64) error {
+// processSuccessfulDeposit is the single crediting path for deposits. It is
+// safe to call more than once for the same reference.
+func processSuccessfulDeposit(charge services.Charge) error {
 	return database.DB.Transaction(func(tx *gorm.DB) error {
 		var transaction models.Transaction
-		if err := tx.Where("reference = ?", reference).First(&transaction).Error; err != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference = ?", charge.Reference).First(&transaction).Error; err != nil {
 			return err
 		}
 
-		if transaction.Status == models.TransactionStatusSuccess {
-			log.Println("Transaction already processed:", reference)
+		switch transaction.Status {
+		case models.TransactionStatusPending:
+		case models.TransactionStatusAbandoned, models.TransactionStatusCancelled, models.TransactionStatusFailed:
+			// The customer paid after we gave up on the deposit, or a call
+			// we counted as failed went through after all; the money has
+			// still arrived, so it is credited as usual
+			log.Printf("Late payment for %s deposit %s", transaction.Status, charge.Reference)
+		default:
+			log.Printf("Transaction already processed: %s (%s)", charge.Reference, transaction.Status)
+			return nil
+		}
+
+		if charge.Provider != transaction.Provider {
+			log.Printf("ALERT: %s reported a charge for %s, which was initialized with %s", charge.Provider, charge.Reference, transaction.Provider)
 			return nil
 		}
 
-		transaction.Status = models.TransactionStatusSuccess
+		status, creditAmount, reason := checkDepositCharge(&transaction, charge)
+
+		transaction.Status = status
+		transaction.ReviewReason = reason
+		transaction.Channel = charge.Channel
+		transaction.GatewayResponse = charge.GatewayResponse
+		transaction.Fees = charge.Fees
+		transaction.PaidAt = charge.PaidAt
+		if status == models.TransactionStatusSuccess {
+			transaction.Amount = creditAmount
+		}
 		if err := tx.Save(&transaction).Error; err != nil {
 			return err
 		}
 
+		if status != models.TransactionStatusSuccess {
+			log.Printf("ALERT: deposit %s moved to %s: %s", charge.Reference, status, reason)
+			return nil
+		}
+
 		var wallet models.Wallet
-		if err := tx.Where("user_id = ?", transaction.UserID).First(&wallet).Error; err != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", transaction.UserID).First(&wallet).Error; err != nil {
 			return err
 		}
 
-		wallet.Balance += amount
+		wallet.Balance += creditAmount
 		if err := tx.Save(&wallet).Error; err != nil {
 			return err
 		}
+		applyBalance(&transaction, wallet, creditAmount)
+		if err := tx.Save(&transaction).Error; err != nil {
+			return err
+		}
+
+		if charge.Card != nil {
+			if err := saveCard(tx, transaction.UserID, charge.Provider, charge.Card); err != nil {
+				return err
+			}
+		}
 
-		log.Printf("Deposit processed: %s, Amount: %d, New Balance: %d", reference, amount, wallet.Balance)
+		log.Printf("Deposit processed: %s, Amount: %d, New Balance: %d", charge.Reference, creditAmount, wallet.Balance)
 		return nil
 	})
 }
 
+// applyBalance records on a transaction how it moved the wallet's balance.
+// wallet must already hold the new balance; delta is negative for debits.
+func applyBalance(transaction *models.Transaction, wallet models.Wallet, delta int64) {
+	before := wallet.Balance - delta
+	after := wallet.Balance
+	now := time.Now()
+	transaction.BalanceBefore = &before
+	transaction.BalanceAfter = &after
+	transaction.AppliedAt = &now
+}
+
+// checkDepositCharge compares what was actually paid against the pending
+// deposit and decides the resulting status and how much to credit.
+// Currency mismatches always go to review; amount mismatches follow
+// config.AppConfig.DepositMismatchPolicy.
+func checkDepositCharge(transaction *models.Transaction, charge services.Charge) (models.TransactionStatus, int64, string) {
+	if charge.Currency != "" && !strings.EqualFold(charge.Currency, transaction.Currency) {
+		return models.TransactionStatusReview, 0,
+			fmt.Sprintf("currency mismatch: expected %s, received %s", transaction.Currency, charge.Currency)
+	}
+	if !strings.EqualFold(transaction.Currency, defaultCurrency) {
+		return models.TransactionStatusReview, 0,
+			fmt.Sprintf("currency mismatch: wallets are held in %s, deposit is in %s", defaultCurrency, transaction.Currency)
+	}
+
+	if charge.Amount == transaction.Amount {
+		return models.TransactionStatusSuccess, charge.Amount, ""
+	}
+
+	reason := fmt.Sprintf("amount mismatch: expected %d, received %d", transaction.Amount, charge.Amount)
+
+	switch config.AppConfig.DepositMismatchPolicy {
+	case config.DepositMismatchCreditActual:
+		log.Printf("Deposit %s: %s, crediting actual amount", charge.Reference, reason)
+		return models.TransactionStatusSuccess, charge.Amount, ""
+	case config.DepositMismatchReject:
+		return models.TransactionStatusFailed, 0, reason
+	default:
+		return models.TransactionStatusReview, 0, reason
+	}
+}
+
+// markDepositFailed moves a pending deposit to failed once the provider
+// reports that the charge did not go through.
+func markDepositFailed(reference, gatewayResponse string) error {
+	return database.DB.Model(&models.Transaction{}).
+		Where("reference = ? AND status = ?", reference, models.TransactionStatusPending).
+		Updates(map[string]interface{}{
+			"status":           models.TransactionStatusFailed,
+			"gateway_response": gatewayResponse,
+		}).Error
+}
+
 // GetDepositStatus godoc
 // @Summary Get deposit transaction status
-// @Description Manually check the status of a deposit transaction by reference
+// @Description Check the status of one of your deposits by reference. Pending deposits are verified live with their payment provider and credited if the charge succeeded.
 // @Tags Wallet
 // @Produce json
 // @Param reference path string true "Transaction reference"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} DepositStatusResponse
 // @Failure 404 {object} map[string]interface{} "Transaction not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Security ApiKeyAuth
 // @Router /wallet/deposit/{reference}/status [get]
 func GetDepositStatus(c *gin.Context) {
+	userID, _ := c.Get("user_id")
 	reference := c.Param("reference")
 
 	var transaction models.Transaction
-	if err := database.DB.Where("reference = ?", reference).First(&transaction).Error; err != nil {
+	if err := database.DB.Where("reference = ? AND user_id = ? AND type = ?", reference, userID, models.TransactionTypeDeposit).
+		First(&transaction).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"reference": transaction.Reference,
-		"status":    transaction.Status,
-		"amount":    transaction.Amount,
+	if transaction.Status == models.TransactionStatusPending {
+		if err := verifyPendingDeposit(c.Request.Context(), &transaction); err != nil {
+			// The provider being unreachable should not hide what we already know
+			log.Println("Failed to verify deposit with provider:", err)
+		} else if err := database.DB.First(&transaction, "id = ?", transaction.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, DepositStatusResponse{
+		Reference:       transaction.Reference,
+		Status:          string(transaction.Status),
+		Amount:          transaction.Amount,
+		Currency:        transaction.Currency,
+		Provider:        transaction.Provider,
+		Channel:         transaction.Channel,
+		GatewayResponse: transaction.GatewayResponse,
+		Fees:            transaction.Fees,
+		PaidAt:          transaction.PaidAt,
+		ReviewReason:    transaction.ReviewReason,
 	})
 }
 
+type DepositStatusResponse struct {
+	Reference       string     `json:"reference" example:"TXN_1234567890"`
+	Status          string     `json:"status" example:"success"`
+	Amount          int64      `json:"amount" example:"5000"`
+	Currency        string     `json:"currency" example:"NGN"`
+	Provider        string     `json:"provider" example:"paystack"`
+	Channel         string     `json:"channel,omitempty" example:"card"`
+	GatewayResponse string     `json:"gateway_response,omitempty" example:"Approved"`
+	Fees            int64      `json:"fees" example:"75"`
+	PaidAt          *time.Time `json:"paid_at,omitempty"`
+	ReviewReason    string     `json:"review_reason,omitempty" example:"amount mismatch: expected 5000, received 4000"`
+}
+
+// verifyPendingDeposit asks the deposit's payment provider for the outcome of
+// the charge and applies it through the same path the webhook uses.
+func verifyPendingDeposit(ctx context.Context, transaction *models.Transaction) error {
+	provider, ok := paymentProviders.Get(transaction.Provider)
+	if !ok {
+		return fmt.Errorf("payment provider %q is not enabled", transaction.Provider)
+	}
+
+	charge, err := provider.VerifyCharge(ctx, transaction.Reference)
+	if err != nil {
+		return err
+	}
+
+	switch charge.Status {
+	case services.ChargeStatusSuccess:
+		return processSuccessfulDeposit(*charge)
+	case services.ChargeStatusFailed:
+		return markDepositFailed(transaction.Reference, charge.GatewayResponse)
+	}
+
+	// Still awaiting payment
+	return nil
+}
+
 // GetWalletBalance godoc
 // @Summary Get wallet balance
-// @Description Retrieve the current balance of the authenticated user's wallet
+// @Description Retrieve the current balance of the authenticated user's wallet. held_balance is held back by open disputes; available is what can be spent. With as_of, returns only the balance at that time instead: a date means its closing balance (end of day, UTC).
 // @Tags Wallet
 // @Produce json
-// @Success 200 {object} map[string]interface{} "Balance in kobo"
+// @Param as_of query string false "Past date (YYYY-MM-DD) or time (RFC 3339)"
+// @Success 200 {object} map[string]interface{} "Balance, held balance and available balance in kobo; as_of and balance for past balances"
+// @Failure 400 {object} map[string]interface{} "Invalid as_of"
 // @Failure 404 {object} map[string]interface{} "Wallet not found"
+// @Failure 422 {object} map[string]interface{} "No balance history for as_of"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Security ApiKeyAuth
 // @Router /wallet/balance [get]
@@ -238,56 +643,56 @@ func GetWalletBalance(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"balance": wallet.Balance,
-	})
-}
-
-type TransactionResponse struct {
-	Type   string `json:"type" example:"deposit"`
-	Amount int64  `json:"amount" example:"5000"`
-	Status string `json:"status" example:"success"`
-}
-
-// GetTransactionHistory godoc
-// @Summary Get transaction history
-// @Description Retrieve all transactions for the authenticated user
-// @Tags Wallet
-// @Produce json
-// @Success 200 {array} TransactionResponse
-// @Failure 500 {object} map[string]interface{} "Internal server error"
-// @Security BearerAuth
-// @Security ApiKeyAuth
-// @Router /wallet/transactions [get]
-func GetTransactionHistory(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	if value := c.Query("as_of"); value != "" {
+		asOf, dateOnly, err := parseHistoryTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be YYYY-MM-DD or RFC 3339"})
+			return
+		}
+		// Balance changes applied at as_of count; for a date, all of that day
+		until := asOf.Add(time.Microsecond)
+		if dateOnly {
+			until = asOf.AddDate(0, 0, 1)
+		}
+		if asOf.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of cannot be in the future"})
+			return
+		}
 
-	var transactions []models.Transaction
-	if err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&transactions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
-		return
-	}
+		balance, err := balanceAt(wallet, until)
+		if errors.Is(err, errBalanceHistoryUnavailable) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No balance history that far back"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to work out balance"})
+			return
+		}
 
-	var response []TransactionResponse
-	for _, tx := range transactions {
-		response = append(response, TransactionResponse{
-			Type:   string(tx.Type),
-			Amount: tx.Amount,
-			Status: string(tx.Status),
+		c.JSON(http.StatusOK, gin.H{
+			"as_of":   value,
+			"balance": balance,
 		})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, gin.H{
+		"balance":      wallet.Balance,
+		"held_balance": wallet.HeldBalance,
+		"available":    wallet.Available(),
+	})
 }
 
 type TransferRequest struct {
-	WalletNumber string `json:"wallet_number" binding:"required" example:"1234567890123"`
-	Amount       int64  `json:"amount" binding:"required,gt=0" example:"3000"`
+	WalletNumber string            `json:"wallet_number" binding:"required" example:"1234567890123"`
+	Amount       int64             `json:"amount" binding:"required,gt=0" example:"3000"`
+	Narration    string            `json:"narration" example:"Payment for order 1042"`                   // Shown to both sides
+	Metadata     map[string]string `json:"metadata" swaggertype:"object,string" example:"order_id:1042"` // Up to 20 keys, kept on both sides
 }
 
 // TransferFunds godoc
 // @Summary Transfer funds to another wallet
-// @Description Transfer money from authenticated user's wallet to another user's wallet
+// @Description Transfer money from authenticated user's wallet to another user's wallet. An optional narration (max 100 characters) and metadata (up to 20 string key/value pairs, keys max 40 and values max 500 characters) are kept on both sides of the transfer.
 // @Tags Wallet
 // @Accept json
 // @Produce json
@@ -308,19 +713,52 @@ func TransferFunds(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
+	if problem := validateAnnotations(req.Narration, req.Metadata); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	metadata, err := encodeMetadata(req.Metadata)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid metadata"})
+		return
+	}
+
+	groupID, err := utils.GenerateTransferGroupID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Transfer failed"})
+		return
+	}
 
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		var senderWallet models.Wallet
-		if err := tx.Where("user_id = ?", userID).First(&senderWallet).Error; err != nil {
+	var senderTx models.Transaction
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Both wallets are locked in one query, in ID order, so two transfers
+		// between the same wallets in opposite directions cannot deadlock
+		var wallets []models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? OR wallet_number = ?", userID, req.WalletNumber).
+			Order("id").Find(&wallets).Error; err != nil {
 			return err
 		}
 
-		if senderWallet.Balance < req.Amount {
+		var senderWallet, recipientWallet models.Wallet
+		for _, wallet := range wallets {
+			if wallet.UserID == userID.(string) {
+				senderWallet = wallet
+			}
+			if wallet.WalletNumber == req.WalletNumber {
+				recipientWallet = wallet
+			}
+		}
+		if senderWallet.ID == "" {
+			return gorm.ErrRecordNotFound
+		}
+
+		// Money held back by open disputes cannot be sent
+		if senderWallet.Available() < req.Amount {
 			return fmt.Errorf("insufficient balance")
 		}
 
-		var recipientWallet models.Wallet
-		if err := tx.Where("wallet_number = ?", req.WalletNumber).First(&recipientWallet).Error; err != nil {
+		if recipientWallet.ID == "" {
 			return fmt.Errorf("recipient wallet not found")
 		}
 
@@ -338,26 +776,34 @@ func TransferFunds(c *gin.Context) {
 			return err
 		}
 
-		senderTx := models.Transaction{
+		senderTx = models.Transaction{
 			UserID:            userID.(string),
 			Type:              models.TransactionTypeTransfer,
 			Amount:            req.Amount,
 			Status:            models.TransactionStatusSuccess,
 			Reference:         utils.GenerateReference(),
 			RecipientWalletID: &recipientWallet.ID,
+			TransferGroupID:   &groupID,
+			Narration:         req.Narration,
+			Metadata:          metadata,
 		}
+		applyBalance(&senderTx, senderWallet, -req.Amount)
 		if err := tx.Create(&senderTx).Error; err != nil {
 			return err
 		}
 
 		recipientTx := models.Transaction{
-			UserID:         recipientWallet.UserID,
-			Type:           models.TransactionTypeCredit,
-			Amount:         req.Amount,
-			Status:         models.TransactionStatusSuccess,
-			Reference:      utils.GenerateReference(),
-			SenderWalletID: &senderWallet.ID,
+			UserID:          recipientWallet.UserID,
+			Type:            models.TransactionTypeCredit,
+			Amount:          req.Amount,
+			Status:          models.TransactionStatusSuccess,
+			Reference:       utils.GenerateReference(),
+			SenderWalletID:  &senderWallet.ID,
+			TransferGroupID: &groupID,
+			Narration:       req.Narration,
+			Metadata:        metadata,
 		}
+		applyBalance(&recipientTx, recipientWallet, req.Amount)
 		if err := tx.Create(&recipientTx).Error; err != nil {
 			return err
 		}
@@ -383,7 +829,9 @@ func TransferFunds(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Transfer completed",
+		"status":            "success",
+		"message":           "Transfer completed",
+		"reference":         senderTx.Reference,
+		"transfer_group_id": groupID,
 	})
 }
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"testing"
+	"wallet-service/config"
+	"wallet-service/models"
+	"wallet-service/services"
+)
+
+func TestCheckDepositCharge(t *testing.T) {
+	tests := []struct {
+		name       string
+		currency   string
+		policy     string
+		charge     services.Charge
+		wantStatus models.TransactionStatus
+		wantCredit int64
+		wantReason string
+	}{
+		{
+			name:       "exact amount",
+			policy:     config.DepositMismatchHold,
+			charge:     services.Charge{Amount: 500000, Currency: "NGN"},
+			wantStatus: models.TransactionStatusSuccess,
+			wantCredit: 500000,
+		},
+		{
+			name:       "currency left out by the provider",
+			policy:     config.DepositMismatchHold,
+			charge:     services.Charge{Amount: 500000},
+			wantStatus: models.TransactionStatusSuccess,
+			wantCredit: 500000,
+		},
+		{
+			name:       "currency differs only in case",
+			policy:     config.DepositMismatchHold,
+			charge:     services.Charge{Amount: 500000, Currency: "ngn"},
+			wantStatus: models.TransactionStatusSuccess,
+			wantCredit: 500000,
+		},
+		{
+			name:       "hold short payment",
+			policy:     config.DepositMismatchHold,
+			charge:     services.Charge{Amount: 400000, Currency: "NGN"},
+			wantStatus: models.TransactionStatusReview,
+			wantReason: "amount mismatch: expected 500000, received 400000",
+		},
+		{
+			name:       "reject short payment",
+			policy:     config.DepositMismatchReject,
+			charge:     services.Charge{Amount: 400000, Currency: "NGN"},
+			wantStatus: models.TransactionStatusFailed,
+			wantReason: "amount mismatch: expected 500000, received 400000",
+		},
+		{
+			name:       "credit actual overpayment",
+			policy:     config.DepositMismatchCreditActual,
+			charge:     services.Charge{Amount: 600000, Currency: "NGN"},
+			wantStatus: models.TransactionStatusSuccess,
+			wantCredit: 600000,
+		},
+		{
+			name:       "unknown policy holds",
+			policy:     "",
+			charge:     services.Charge{Amount: 400000, Currency: "NGN"},
+			wantStatus: models.TransactionStatusReview,
+			wantReason: "amount mismatch: expected 500000, received 400000",
+		},
+		{
+			name:       "currency mismatch is held whatever the policy",
+			policy:     config.DepositMismatchCreditActual,
+			charge:     services.Charge{Amount: 500000, Currency: "USD"},
+			wantStatus: models.TransactionStatusReview,
+			wantReason: "currency mismatch: expected NGN, received USD",
+		},
+		{
+			name:       "deposit in another currency than the wallet",
+			currency:   "USD",
+			policy:     config.DepositMismatchCreditActual,
+			charge:     services.Charge{Amount: 500000, Currency: "USD"},
+			wantStatus: models.TransactionStatusReview,
+			wantReason: "currency mismatch: wallets are held in NGN, deposit is in USD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.AppConfig = &config.Config{DepositMismatchPolicy: tt.policy}
+			deposit := &models.Transaction{Amount: 500000, Currency: "NGN"}
+			if tt.currency != "" {
+				deposit.Currency = tt.currency
+			}
+
+			status, credit, reason := checkDepositCharge(deposit, tt.charge)
+			if status != tt.wantStatus || credit != tt.wantCredit || reason != tt.wantReason {
+				t.Errorf("checkDepositCharge() = (%s, %d, %q), want (%s, %d, %q)",
+					status, credit, reason, tt.wantStatus, tt.wantCredit, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"expvar"
+	"log"
+	"wallet-service/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// webhookMetrics counts webhook deliveries per provider and outcome, e.g.
+// "paystack.accepted" or "paystack.rejected.invalid_signature".
+var webhookMetrics = expvar.NewMap("webhooks")
+
+// rejectWebhook logs and counts a webhook refused before processing.
+func rejectWebhook(c *gin.Context, providerName, reason string, status int, message string) {
+	webhookMetrics.Add(providerName+".rejected."+reason, 1)
+	log.Printf("Rejected %s webhook from %s: %s", providerName, c.ClientIP(), reason)
+	c.JSON(status, gin.H{"error": message})
+}
+
+// webhookSourceAllowed applies the provider's IP allowlist, if one is
+// configured.
+func webhookSourceAllowed(providerName, ip string) bool {
+	if providerName != "paystack" || config.AppConfig.PaystackWebhookIPs == nil {
+		return true
+	}
+	return config.IPAllowed(config.AppConfig.PaystackWebhookIPs, ip)
+}
+
+// GetMetrics godoc
+// @Summary Service metrics (admin)
+// @Description Runtime and webhook counters in expvar format. Webhook counters are keyed by provider and outcome, e.g. paystack.accepted or paystack.rejected.invalid_signature.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Security BearerAuth
+// @Router /admin/metrics [get]
+func GetMetrics(c *gin.Context) {
+	expvar.Handler().ServeHTTP(c.Writer, c.Request)
+}
@@ -18,7 +18,7 @@ import (
 
 // @title Wallet Service API
 // @version 1.0
-// @description Backend wallet service with Paystack integration, Google OAuth, and API key management
+// @description Backend wallet service with Paystack and Flutterwave integration, Google OAuth, and API key management
 // @termsOfService http://swagger.io/terms/
 
 // @contact.name API Support
@@ -45,13 +45,29 @@ func main() {
 	database.Connect()
 	database.Migrate()
 	handlers.InitGoogleOAuth()
+	handlers.InitPaymentProviders()
+	handlers.InitMailer()
+	handlers.InitAttachmentStorage()
+	handlers.StartDepositExpiry()
+	handlers.ResumePayoutBatches()
+	handlers.StartBalanceSnapshots()
+	handlers.ResumeStatements()
+	handlers.StartMonthlyStatements()
 
 	router := gin.Default()
 
+	// Only trust X-Forwarded-For from known proxies, so the webhook IP
+	// allowlist cannot be bypassed with a forged header
+	if config.AppConfig.TrustedProxies != nil {
+		if err := router.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+			log.Fatal("Invalid TRUSTED_PROXIES:", err)
+		}
+	}
+
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{config.AppConfig.FrontendURL, "http://localhost:3000", "http://localhost:3001"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "x-api-key", "x-paystack-signature"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "x-api-key", "x-paystack-signature", "verif-hash"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -91,13 +107,72 @@ func main() {
 			handlers.InitiateDeposit,
 		)
 
+		wallet.GET("/deposit/callback", handlers.DepositCallback)
+
 		wallet.POST("/paystack/webhook", handlers.PaystackWebhook)
+		wallet.POST("/flutterwave/webhook", handlers.FlutterwaveWebhook)
 
 		wallet.GET("/deposit/:reference/status",
 			middleware.AuthMiddleware(),
 			handlers.GetDepositStatus,
 		)
 
+		wallet.POST("/deposit/:reference/cancel",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("deposit"),
+			handlers.CancelDeposit,
+		)
+
+		wallet.POST("/deposit/:reference/refund",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("transfer"),
+			middleware.IdempotencyMiddleware(),
+			handlers.RefundDeposit,
+		)
+
+		wallet.GET("/refunds",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.ListRefunds,
+		)
+
+		wallet.POST("/deposit/:reference/otp",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("deposit"),
+			handlers.SubmitDepositOTP,
+		)
+
+		wallet.GET("/cards",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.ListSavedCards,
+		)
+
+		wallet.DELETE("/cards/:id",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("deposit"),
+			handlers.RemoveSavedCard,
+		)
+
+		wallet.POST("/cards/:id/charge",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("deposit"),
+			middleware.IdempotencyMiddleware(),
+			handlers.TopUpWithSavedCard,
+		)
+
+		wallet.POST("/virtual-account",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("deposit"),
+			handlers.CreateVirtualAccount,
+		)
+
+		wallet.GET("/virtual-account",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.GetVirtualAccount,
+		)
+
 		wallet.GET("/balance",
 			middleware.AuthMiddleware(),
 			middleware.RequirePermission("read"),
@@ -110,12 +185,191 @@ func main() {
 			handlers.GetTransactionHistory,
 		)
 
+		wallet.GET("/transactions/search",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.SearchTransactions,
+		)
+
+		wallet.GET("/transactions/:reference",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.GetTransaction,
+		)
+
+		wallet.PATCH("/transactions/:reference",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("write"),
+			handlers.AnnotateTransaction,
+		)
+
+		wallet.GET("/transactions/:reference/attachments",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.ListAttachments,
+		)
+
+		wallet.POST("/transactions/:reference/attachments",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("write"),
+			handlers.UploadAttachment,
+		)
+
+		wallet.GET("/transactions/:reference/attachments/:id",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.DownloadAttachment,
+		)
+
+		wallet.DELETE("/transactions/:reference/attachments/:id",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("write"),
+			handlers.DeleteAttachment,
+		)
+
+		wallet.GET("/tags",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.ListTags,
+		)
+
+		wallet.PUT("/transactions/:reference/category",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("write"),
+			handlers.SetTransactionCategory,
+		)
+
+		wallet.GET("/categories",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.ListCategories,
+		)
+
+		wallet.POST("/categories",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("write"),
+			handlers.CreateCategory,
+		)
+
+		wallet.DELETE("/categories/:id",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("write"),
+			handlers.DeleteCategory,
+		)
+
+		wallet.GET("/category-rules",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.ListCategoryRules,
+		)
+
+		wallet.POST("/category-rules",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("write"),
+			handlers.CreateCategoryRule,
+		)
+
+		wallet.DELETE("/category-rules/:id",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("write"),
+			handlers.DeleteCategoryRule,
+		)
+
+		wallet.GET("/analytics",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.GetAnalytics,
+		)
+
+		wallet.GET("/statement",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.GetStatement,
+		)
+
+		wallet.GET("/statements",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.ListStatements,
+		)
+
+		wallet.GET("/statements/preferences",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.GetStatementPreference,
+		)
+
+		wallet.PUT("/statements/preferences",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("write"),
+			handlers.UpdateStatementPreference,
+		)
+
+		wallet.GET("/statements/deliveries",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.ListStatementDeliveries,
+		)
+
+		wallet.GET("/statements/:id",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.GetStatementStatus,
+		)
+
+		wallet.GET("/statements/:id/download",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.DownloadStatement,
+		)
+
 		wallet.POST("/transfer",
 			middleware.AuthMiddleware(),
 			middleware.RequirePermission("transfer"),
 			middleware.IdempotencyMiddleware(),
 			handlers.TransferFunds,
 		)
+
+		wallet.POST("/payouts",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("transfer"),
+			middleware.IdempotencyMiddleware(),
+			handlers.CreatePayoutBatch,
+		)
+
+		wallet.GET("/payouts",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.ListPayoutBatches,
+		)
+
+		wallet.GET("/payouts/:reference",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("read"),
+			handlers.GetPayoutBatch,
+		)
+
+		wallet.POST("/payouts/:reference/lines/:id/retry",
+			middleware.AuthMiddleware(),
+			middleware.RequirePermission("transfer"),
+			middleware.IdempotencyMiddleware(),
+			handlers.RetryPayoutLine,
+		)
+	}
+
+	admin := router.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+	{
+		admin.POST("/deposits/:reference/refund", handlers.AdminRefundDeposit)
+		admin.POST("/reconciliation/paystack", handlers.ReconcilePaystackSettlement)
+		admin.GET("/metrics", handlers.GetMetrics)
+		admin.GET("/transactions/:reference", handlers.AdminGetTransaction)
+		admin.PUT("/users/:id/tier", handlers.SetUserTier)
+		admin.GET("/disputes", handlers.ListDisputes)
+		admin.GET("/disputes/:id", handlers.GetDispute)
+		admin.POST("/disputes/:id/evidence", handlers.SubmitDisputeEvidence)
+		admin.POST("/disputes/:id/resolve", handlers.ResolveDispute)
+		admin.GET("/statements/deliveries", handlers.AdminListStatementDeliveries)
 	}
 
 	port := config.AppConfig.Port
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 	"time"
+	"wallet-service/config"
 	"wallet-service/database"
 	"wallet-service/models"
 	"wallet-service/utils"
@@ -128,6 +129,36 @@ func RequirePermission(permission string) gin.HandlerFunc {
 	}
 }
 
+// RequireAdmin only lets through users signed in with a JWT whose email is
+// listed in ADMIN_EMAILS. API keys never have admin access.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authType, _ := c.Get("auth_type")
+		email, _ := c.Get("email")
+		emailStr, _ := email.(string)
+
+		if authType != "jwt" || !isAdminEmail(emailStr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func isAdminEmail(email string) bool {
+	if email == "" {
+		return false
+	}
+	for _, admin := range config.AppConfig.AdminEmails {
+		if strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
 // RateLimitByAPIKey implements simple rate limiting for API keys
 func RateLimitByAPIKey() gin.HandlerFunc {
 	type rateLimitData struct {
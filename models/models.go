@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strconv"
 	"time"
 
 	"gorm.io/gorm"
@@ -11,6 +12,7 @@ type User struct {
 	Email     string    `gorm:"uniqueIndex;not null" json:"email"`
 	Name      string    `json:"name"`
 	GoogleID  string    `gorm:"uniqueIndex" json:"google_id"`
+	Tier      string    `gorm:"not null;default:'basic'" json:"tier"` // Picks the deposit caps that apply
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -24,43 +26,133 @@ type Wallet struct {
 	UserID       string    `gorm:"uniqueIndex;not null" json:"user_id"`
 	WalletNumber string    `gorm:"uniqueIndex;not null" json:"wallet_number"`
 	Balance      int64     `gorm:"default:0" json:"balance"` // Store in kobo (smallest currency unit)
+	HeldBalance  int64     `gorm:"not null;default:0" json:"held_balance"` // Part of Balance held back by open disputes, in kobo
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 
 	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
+// Available is what the wallet can spend: its balance less any holds. It is
+// negative when a hold covers money that has already been spent.
+func (w *Wallet) Available() int64 {
+	return w.Balance - w.HeldBalance
+}
+
 type TransactionType string
 type TransactionStatus string
 
 const (
-	TransactionTypeDeposit  TransactionType = "deposit"
-	TransactionTypeTransfer TransactionType = "transfer"
-	TransactionTypeCredit   TransactionType = "credit" // When receiving transfer
+	TransactionTypeDeposit            TransactionType = "deposit"
+	TransactionTypeTransfer           TransactionType = "transfer"
+	TransactionTypeCredit             TransactionType = "credit"              // When receiving transfer
+	TransactionTypeRefund             TransactionType = "refund"              // Deposit paid back to its source
+	TransactionTypeChargeback         TransactionType = "chargeback"          // Deposit disputed by the customer
+	TransactionTypePayout             TransactionType = "payout"              // Wallet debited into a bulk payout batch
+	TransactionTypePayoutReversal     TransactionType = "payout_reversal"     // Failed payout line credited back
+	TransactionTypeRefundReversal     TransactionType = "refund_reversal"     // Failed refund credited back
+	TransactionTypeChargebackReversal TransactionType = "chargeback_reversal" // Debited chargeback credited back on a won dispute
 )
 
 const (
 	TransactionStatusPending TransactionStatus = "pending"
 	TransactionStatusSuccess TransactionStatus = "success"
 	TransactionStatusFailed  TransactionStatus = "failed"
+	TransactionStatusReview  TransactionStatus = "review" // Paid, but did not match what we expected
+
+	// Deposits that were never paid. A late payment still credits them.
+	TransactionStatusAbandoned TransactionStatus = "abandoned" // Expired while pending
+	TransactionStatusCancelled TransactionStatus = "cancelled" // Cancelled by the user
 )
 
 type Transaction struct {
-	ID               string            `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
-	UserID           string            `gorm:"not null;index" json:"user_id"`
-	Type             TransactionType   `gorm:"not null" json:"type"`
-	Amount           int64             `gorm:"not null" json:"amount"` // In kobo
-	Status           TransactionStatus `gorm:"not null;default:'pending'" json:"status"`
-	Reference        string            `gorm:"uniqueIndex" json:"reference"`
-	RecipientWalletID *string          `json:"recipient_wallet_id,omitempty"`
-	SenderWalletID    *string          `json:"sender_wallet_id,omitempty"`
-	Metadata         *string           `gorm:"type:jsonb" json:"metadata,omitempty"`
-	CreatedAt        time.Time         `json:"created_at"`
-	UpdatedAt        time.Time         `json:"updated_at"`
+	ID                string            `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID            string            `gorm:"not null;index;index:idx_transactions_user_created,priority:1;index:idx_transactions_user_applied,priority:1" json:"user_id"`
+	Type              TransactionType   `gorm:"not null" json:"type"`
+	Amount            int64             `gorm:"not null" json:"amount"` // In kobo
+	Currency          string            `gorm:"not null;default:'NGN'" json:"currency"`
+	Status            TransactionStatus `gorm:"not null;default:'pending'" json:"status"`
+	Reference         string            `gorm:"uniqueIndex" json:"reference"`
+	Provider          string            `gorm:"not null;default:'paystack'" json:"provider,omitempty"` // Payment provider for deposits
+	RecipientWalletID *string           `json:"recipient_wallet_id,omitempty"`
+	SenderWalletID    *string           `json:"sender_wallet_id,omitempty"`
+	TransferGroupID   *string           `gorm:"index" json:"transfer_group_id,omitempty"`                                      // Shared by both legs of a wallet transfer
+	Metadata          *string           `gorm:"type:jsonb;index:idx_transactions_metadata,type:gin" json:"metadata,omitempty"` // Caller-defined string key/value pairs
+	Channel           string            `json:"channel,omitempty"`
+	GatewayResponse   string            `json:"gateway_response,omitempty"`
+	Fees              int64             `gorm:"default:0" json:"fees"` // In kobo, charged by the payment provider
+	PaidAt            *time.Time        `json:"paid_at,omitempty"`
+	ReviewReason      string            `json:"review_reason,omitempty"`
+	Narration         string            `json:"narration,omitempty"`
+	BalanceBefore     *int64            `json:"balance_before,omitempty"`                                                   // Wallet balance just before this transaction moved it, in kobo
+	BalanceAfter      *int64            `json:"balance_after,omitempty"`                                                    // Wallet balance once this transaction moved it, in kobo
+	AppliedAt         *time.Time        `gorm:"index:idx_transactions_user_applied,priority:2" json:"applied_at,omitempty"` // When it moved the balance; unset if it never has
+	ExpiryCheckedAt   *time.Time        `json:"-"`                                                                          // When deposit expiry last failed to verify it with the provider
+	CategoryID        *string           `gorm:"type:uuid;index" json:"category_id,omitempty"`
+	CategorySource    CategorySource    `gorm:"not null;default:'';index:idx_transactions_uncategorized,where:category_source = ''" json:"-"`
+	Note              string            `json:"note,omitempty"`                                                        // Private to the owner, never copied to the other leg
+	Tags              *string           `gorm:"type:jsonb;index:idx_transactions_tags,type:gin" json:"tags,omitempty"` // Private JSON array of the owner's tags
+	CreatedAt         time.Time         `gorm:"index:idx_transactions_user_created,priority:2" json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+
+	// Kept by Postgres for full-text search
+	SearchVector string `gorm:"type:tsvector GENERATED ALWAYS AS (setweight(to_tsvector('simple', COALESCE(reference, '')), 'A') || setweight(to_tsvector('simple', COALESCE(narration, '') || ' ' || COALESCE(note, '')), 'B') || setweight(jsonb_to_tsvector('simple', COALESCE(metadata, '{}'), '[\"string\"]'), 'C')) STORED;index:idx_transactions_search,type:gin;->:false;<-:false" json:"-"`
 
 	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
+// How a transaction got its category.
+type CategorySource string
+
+const (
+	CategorySourcePending CategorySource = ""     // Not yet run through the user's rules
+	CategorySourceNone    CategorySource = "none" // No rule matched
+	CategorySourceRule    CategorySource = "rule"
+	CategorySourceUser    CategorySource = "user" // Set by hand; rules leave it alone
+)
+
+// Category is a user's own label for transactions, e.g. "Groceries".
+type Category struct {
+	ID        string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID    string    `gorm:"not null;uniqueIndex:idx_categories_user_name" json:"-"`
+	Name      string    `gorm:"not null;uniqueIndex:idx_categories_user_name" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// CategoryRule files a user's transactions under a category automatically.
+// A rule matches when all of its conditions do; when several match, the one
+// with the lowest priority wins.
+type CategoryRule struct {
+	ID                       string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID                   string    `gorm:"not null;index" json:"-"`
+	CategoryID               string    `gorm:"type:uuid;not null;index" json:"category_id"`
+	Priority                 int       `gorm:"not null;default:0" json:"priority"`
+	Type                     string    `json:"type,omitempty"` // Transaction type
+	CounterpartyWalletID     *string   `json:"-"`
+	CounterpartyWalletNumber string    `json:"counterparty,omitempty"`
+	NarrationContains        string    `json:"narration_contains,omitempty"` // Ignoring case
+	CreatedAt                time.Time `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TransactionAttachment is a file, such as a receipt, a user attached to one
+// of their transactions. The file itself is kept in storage.
+type TransactionAttachment struct {
+	ID            string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	TransactionID string    `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	UserID        string    `gorm:"not null;index" json:"-"`
+	Filename      string    `gorm:"not null" json:"filename"`
+	ContentType   string    `gorm:"not null" json:"content_type"`
+	Size          int64     `gorm:"not null" json:"size"` // In bytes
+	StorageKey    string    `gorm:"not null" json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
 type APIKey struct {
 	ID          string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	UserID      string    `gorm:"not null;index" json:"user_id"`
@@ -80,6 +172,276 @@ func (a *APIKey) IsExpired() bool {
 	return time.Now().After(a.ExpiresAt)
 }
 
+// SavedCard is a reusable card authorization captured from a successful card
+// deposit, used for one-click top-ups.
+type SavedCard struct {
+	ID                string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID            string    `gorm:"not null;uniqueIndex:idx_saved_cards_user_signature" json:"user_id"`
+	Provider          string    `gorm:"not null;default:'paystack'" json:"provider"`
+	AuthorizationCode string    `gorm:"not null" json:"-"`                                            // Can charge the card, never exposed
+	Signature         string    `gorm:"not null;uniqueIndex:idx_saved_cards_user_signature" json:"-"` // Same card, same signature
+	Email             string    `gorm:"not null" json:"-"`                                            // Email the authorization is tied to
+	Last4             string    `json:"last4"`
+	Brand             string    `json:"brand"`
+	Bank              string    `json:"bank"`
+	ExpMonth          string    `json:"exp_month"`
+	ExpYear           string    `json:"exp_year"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// IsExpired reports whether the card's expiry month has passed.
+func (s *SavedCard) IsExpired() bool {
+	month, errMonth := strconv.Atoi(s.ExpMonth)
+	year, errYear := strconv.Atoi(s.ExpYear)
+	if errMonth != nil || errYear != nil {
+		return false
+	}
+	// Cards are valid through the end of their expiry month
+	return time.Now().After(time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// VirtualAccount is a dedicated bank account number assigned to a wallet.
+// Bank transfers into it credit the wallet automatically.
+type VirtualAccount struct {
+	ID                string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID            string    `gorm:"uniqueIndex;not null" json:"user_id"`
+	WalletID          string    `gorm:"not null" json:"wallet_id"`
+	Provider          string    `gorm:"not null;default:'paystack'" json:"provider"`
+	ProviderAccountID string    `json:"-"`
+	CustomerCode      string    `gorm:"not null;index" json:"-"`
+	AccountNumber     string    `gorm:"uniqueIndex;not null" json:"account_number"`
+	AccountName       string    `json:"account_name"`
+	BankName          string    `json:"bank_name"`
+	BankSlug          string    `json:"bank_slug"`
+	Currency          string    `gorm:"not null;default:'NGN'" json:"currency"`
+	Active            bool      `gorm:"default:true" json:"active"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"   // Wallet debited, waiting on the provider
+	RefundStatusProcessed RefundStatus = "processed" // Money is on its way back to the customer
+	RefundStatusFailed    RefundStatus = "failed"    // Wallet re-credited
+)
+
+// Refund pays (part of) a successful deposit back to the card or account it
+// came from. The wallet is debited through TransactionID when the refund is
+// requested and credited back if the refund fails.
+type Refund struct {
+	ID               string       `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID           string       `gorm:"not null;index" json:"user_id"`
+	DepositID        string       `gorm:"not null;index" json:"deposit_id"`
+	DepositReference string       `gorm:"not null;index" json:"deposit_reference"`
+	TransactionID    string       `gorm:"not null" json:"transaction_id"`
+	Reference        string       `gorm:"uniqueIndex;not null" json:"reference"` // Same as the debit transaction's
+	Provider         string       `gorm:"not null" json:"provider"`
+	ProviderRefundID string       `gorm:"index" json:"-"`
+	Amount           int64        `gorm:"not null" json:"amount"` // In kobo
+	Currency         string       `gorm:"not null;default:'NGN'" json:"currency"`
+	Status           RefundStatus `gorm:"not null;default:'pending';index" json:"status"`
+	Reason           string       `json:"reason,omitempty"`
+	RequestedBy      string       `gorm:"not null" json:"requested_by"` // User ID of the owner or the admin
+	FailureReason    string       `json:"failure_reason,omitempty"`
+	ProcessedAt      *time.Time   `json:"processed_at,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen        DisputeStatus = "open"         // Waiting on us to accept or contest it
+	DisputeStatusUnderReview DisputeStatus = "under_review" // Responded to, waiting on the customer's bank
+	DisputeStatusWon         DisputeStatus = "won"          // Hold released or debit reversed
+	DisputeStatusLost        DisputeStatus = "lost"         // The customer got the money back
+)
+
+// DisputePolicyNone is recorded instead of the configured DISPUTE_POLICY when
+// the disputed deposit was never credited, so the wallet is left alone.
+const DisputePolicyNone = "none"
+
+// Dispute is a customer disputing a deposit with their bank (a chargeback).
+// Unless Policy is none, the wallet is held or debited through the pending
+// chargeback transaction TransactionID until the dispute is resolved.
+type Dispute struct {
+	ID                  string        `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID              string        `gorm:"not null;index" json:"user_id"`
+	DepositID           string        `gorm:"not null;index" json:"deposit_id"`
+	DepositReference    string        `gorm:"not null;index" json:"deposit_reference"`
+	TransactionID       *string       `json:"transaction_id,omitempty"`
+	Provider            string        `gorm:"not null;uniqueIndex:idx_disputes_provider_dispute" json:"provider"`
+	ProviderDisputeID   string        `gorm:"not null;uniqueIndex:idx_disputes_provider_dispute" json:"provider_dispute_id"`
+	Amount              int64         `gorm:"not null" json:"amount"` // In kobo
+	Currency            string        `gorm:"not null;default:'NGN'" json:"currency"`
+	Category            string        `json:"category,omitempty"`
+	Policy              string        `gorm:"not null" json:"policy"` // hold, debit or none, as applied when opened
+	Status              DisputeStatus `gorm:"not null;default:'open';index" json:"status"`
+	ProviderStatus      string        `json:"provider_status,omitempty"`
+	DueAt               *time.Time    `json:"due_at,omitempty"`
+	Evidence            *string       `gorm:"type:jsonb" json:"-"` // services.DisputeEvidence as submitted
+	ProviderEvidenceID  int64         `json:"-"`
+	EvidenceSubmittedAt *time.Time    `json:"evidence_submitted_at,omitempty"`
+	ResolutionMessage   string        `json:"resolution_message,omitempty"`
+	RespondedBy         string        `json:"responded_by,omitempty"` // Admin who submitted evidence or accepted/declined it
+	ResolvedAt          *time.Time    `json:"resolved_at,omitempty"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+type PayoutBatchStatus string
+
+const (
+	PayoutBatchStatusProcessing      PayoutBatchStatus = "processing"       // Some lines are not final yet
+	PayoutBatchStatusCompleted       PayoutBatchStatus = "completed"        // Every line was paid
+	PayoutBatchStatusPartiallyFailed PayoutBatchStatus = "partially_failed" // Some lines failed and were credited back
+	PayoutBatchStatusFailed          PayoutBatchStatus = "failed"           // Every line failed and was credited back
+)
+
+// PayoutBatch is a bulk payout from a wallet to many bank accounts. The
+// wallet is debited once for the whole batch through TransactionID; lines
+// that fail are credited back one by one.
+type PayoutBatch struct {
+	ID            string            `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID        string            `gorm:"not null;index" json:"user_id"`
+	TransactionID string            `gorm:"not null" json:"transaction_id"`
+	Reference     string            `gorm:"uniqueIndex;not null" json:"reference"` // Same as the debit transaction's
+	Provider      string            `gorm:"not null;default:'paystack'" json:"provider"`
+	Currency      string            `gorm:"not null;default:'NGN'" json:"currency"`
+	TotalAmount   int64             `gorm:"not null" json:"total_amount"` // In kobo
+	LineCount     int               `gorm:"not null" json:"line_count"`
+	Status        PayoutBatchStatus `gorm:"not null;default:'processing';index" json:"status"`
+	CompletedAt   *time.Time        `json:"completed_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+
+	Lines []PayoutLine `gorm:"foreignKey:BatchID" json:"lines,omitempty"`
+	User  User         `gorm:"foreignKey:UserID" json:"-"`
+}
+
+type PayoutLineStatus string
+
+const (
+	PayoutLineStatusPending    PayoutLineStatus = "pending"    // Not sent to the provider yet
+	PayoutLineStatusProcessing PayoutLineStatus = "processing" // Sent, waiting on the transfer outcome
+	PayoutLineStatusSuccess    PayoutLineStatus = "success"
+	PayoutLineStatusFailed     PayoutLineStatus = "failed" // Credited back to the wallet, can be retried
+)
+
+// PayoutLine is one transfer in a PayoutBatch. Each attempt is sent under a
+// new Reference; retrying a failed line debits the wallet again.
+type PayoutLine struct {
+	ID            string           `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	BatchID       string           `gorm:"not null;index" json:"batch_id"`
+	LineNumber    int              `gorm:"not null" json:"line_number"` // Line in the uploaded file
+	AccountNumber string           `gorm:"not null" json:"account_number"`
+	BankCode      string           `gorm:"not null" json:"bank_code"`
+	AccountName   string           `json:"account_name"`           // As resolved by the provider
+	Amount        int64            `gorm:"not null" json:"amount"` // In kobo
+	Narration     string           `json:"narration,omitempty"`
+	Reference     string           `gorm:"uniqueIndex;not null" json:"reference"` // Transfer reference of the latest attempt
+	RecipientCode string           `json:"-"`
+	TransferCode  string           `json:"transfer_code,omitempty"`
+	Status        PayoutLineStatus `gorm:"not null;default:'pending';index" json:"status"`
+	Attempts      int              `gorm:"not null;default:1" json:"attempts"`
+	FailureReason string           `json:"failure_reason,omitempty"`
+	CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+// BalanceSnapshot is a wallet's closing balance for a day (UTC). Balances at
+// other times are worked out from the nearest snapshot and the balance
+// changes recorded on transactions since.
+type BalanceSnapshot struct {
+	ID        string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	WalletID  string    `gorm:"type:uuid;not null;uniqueIndex:idx_balance_snapshots_wallet_date" json:"wallet_id"`
+	UserID    string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex:idx_balance_snapshots_wallet_date" json:"date"`
+	Balance   int64     `gorm:"not null" json:"balance"` // At the end of Date, in kobo
+	CreatedAt time.Time `json:"created_at"`
+
+	Wallet Wallet `gorm:"foreignKey:WalletID" json:"-"`
+	User   User   `gorm:"foreignKey:UserID" json:"-"`
+}
+
+type StatementStatus string
+
+const (
+	StatementStatusPending StatementStatus = "pending" // Waiting to be generated in the background
+	StatementStatusReady   StatementStatus = "ready"
+	StatementStatusFailed  StatementStatus = "failed"
+)
+
+// Statement is an account statement too large to generate during the
+// request, generated in the background and kept for download.
+type Statement struct {
+	ID          string          `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID      string          `gorm:"not null;index" json:"user_id"`
+	Format      string          `gorm:"not null" json:"format"` // csv, pdf, ofx, qfx, qif or camt053
+	From        time.Time       `gorm:"type:date;not null" json:"from"`
+	To          time.Time       `gorm:"type:date;not null" json:"to"` // Inclusive
+	Status      StatementStatus `gorm:"not null;default:'pending';index" json:"status"`
+	LineCount   int             `json:"line_count"`
+	FilePath    string          `json:"-"`
+	Error       string          `json:"error,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// StatementPreference is how a user wants their monthly statement. Users
+// without one get it emailed as a PDF.
+type StatementPreference struct {
+	UserID    string    `gorm:"primaryKey;type:uuid" json:"-"`
+	Monthly   bool      `gorm:"not null" json:"monthly"` // Email a statement for each month
+	Format    string    `gorm:"not null" json:"format"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+type StatementDeliveryStatus string
+
+const (
+	StatementDeliveryPending StatementDeliveryStatus = "pending" // Waiting to be sent
+	StatementDeliverySending StatementDeliveryStatus = "sending"
+	StatementDeliverySent    StatementDeliveryStatus = "sent"
+	StatementDeliveryFailed  StatementDeliveryStatus = "failed"  // Retried on later runs until out of attempts
+	StatementDeliverySkipped StatementDeliveryStatus = "skipped" // The user had opted out
+)
+
+// StatementDelivery is one user's monthly statement for one month. There is
+// at most one per user and month, so a run can be repeated without sending
+// anything twice.
+type StatementDelivery struct {
+	ID        string                  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID    string                  `gorm:"not null;uniqueIndex:idx_statement_deliveries_user_period" json:"user_id"`
+	Period    time.Time               `gorm:"type:date;not null;uniqueIndex:idx_statement_deliveries_user_period;index" json:"period"` // First day of the month covered
+	Format    string                  `gorm:"not null" json:"format"`
+	Email     string                  `json:"email,omitempty"` // Where it was last sent
+	Status    StatementDeliveryStatus `gorm:"not null;default:'pending';index" json:"status"`
+	Attempts  int                     `gorm:"not null;default:0" json:"attempts"`
+	LineCount int                     `json:"line_count"`
+	Error     string                  `json:"error,omitempty"`
+	SentAt    *time.Time              `json:"sent_at,omitempty"`
+	CreatedAt time.Time               `json:"created_at"`
+	UpdatedAt time.Time               `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
 type IdempotencyKey struct {
 	ID           string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	Key          string    `gorm:"uniqueIndex;not null" json:"key"`
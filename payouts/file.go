@@ -0,0 +1,216 @@
+// Package payouts reads bulk payout files: lists of bank accounts to pay,
+// uploaded as CSV or JSON.
+package payouts
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxNarrationLength is the longest narration Paystack shows on a transfer.
+const MaxNarrationLength = 100
+
+// MaxAmount is the most one line can pay, in kobo (₦100,000,000).
+const MaxAmount int64 = 10_000_000_000
+
+// Line is one payment in a payout file.
+type Line struct {
+	Line          int    `json:"line"` // Line number in a CSV file, position in a JSON one
+	AccountNumber string `json:"account_number"`
+	BankCode      string `json:"bank_code"`
+	Amount        int64  `json:"amount"` // In kobo
+	Narration     string `json:"narration,omitempty"`
+}
+
+// LineError explains why a line cannot be paid.
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Column names accepted in CSV files, after lowercasing and replacing spaces
+// with underscores.
+var columnAliases = map[string][]string{
+	"account_number": {"account_number", "account_no", "account", "nuban"},
+	"bank_code":      {"bank_code", "bank"},
+	"amount":         {"amount"},
+	"narration":      {"narration", "reason", "description"},
+}
+
+// Parse reads a CSV or JSON payout file; JSON files hold an array of lines.
+// Amounts are in kobo. Lines that are malformed or fail Validate are
+// reported as LineErrors; err is only set when the file cannot be read.
+func Parse(data []byte) ([]Line, []LineError, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	var lines []Line
+	var lineErrors []LineError
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		lines, err = parseJSON(trimmed)
+	} else {
+		lines, lineErrors, err = parseCSV(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	valid := lines[:0]
+	for _, line := range lines {
+		if problem := Validate(line); problem != "" {
+			lineErrors = append(lineErrors, LineError{Line: line.Line, Error: problem})
+			continue
+		}
+		valid = append(valid, line)
+	}
+	sort.Slice(lineErrors, func(i, j int) bool { return lineErrors[i].Line < lineErrors[j].Line })
+	return valid, lineErrors, nil
+}
+
+// Validate checks a line's fields, returning what is wrong with it or "".
+func Validate(line Line) string {
+	switch {
+	case len(line.AccountNumber) != 10 || !isDigits(line.AccountNumber):
+		return "account_number must be 10 digits"
+	case line.BankCode == "" || !isDigits(line.BankCode):
+		return "bank_code must be a numeric bank code"
+	case line.Amount <= 0:
+		return "amount must be greater than 0"
+	case line.Amount > MaxAmount:
+		return fmt.Sprintf("amount must be at most %d", MaxAmount)
+	case len(line.Narration) > MaxNarrationLength:
+		return fmt.Sprintf("narration must be at most %d characters", MaxNarrationLength)
+	}
+	return ""
+}
+
+// Total adds up the amounts of lines, failing when the sum is not positive or
+// does not fit in an int64.
+func Total(lines []Line) (int64, error) {
+	var total int64
+	for _, line := range lines {
+		if line.Amount <= 0 || total > math.MaxInt64-line.Amount {
+			return 0, errors.New("payout total is out of range")
+		}
+		total += line.Amount
+	}
+	if total <= 0 {
+		return 0, errors.New("payout total is out of range")
+	}
+	return total, nil
+}
+
+func parseJSON(data []byte) ([]Line, error) {
+	var lines []Line
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	for i := range lines {
+		lines[i].Line = i + 1
+		lines[i].AccountNumber = strings.TrimSpace(lines[i].AccountNumber)
+		lines[i].BankCode = strings.TrimSpace(lines[i].BankCode)
+		lines[i].Narration = strings.TrimSpace(lines[i].Narration)
+	}
+	return lines, nil
+}
+
+func parseCSV(r io.Reader) ([]Line, []LineError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := mapColumns(header)
+	for _, required := range []string{"account_number", "bank_code", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	var lines []Line
+	var lineErrors []LineError
+	for lineNumber := 2; ; lineNumber++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		field := func(name string) string {
+			index, ok := columns[name]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		if field("account_number") == "" && field("amount") == "" {
+			// Blank rows
+			continue
+		}
+
+		amount, err := strconv.ParseInt(strings.ReplaceAll(field("amount"), ",", ""), 10, 64)
+		if err != nil {
+			lineErrors = append(lineErrors, LineError{Line: lineNumber, Error: "amount must be a whole number of kobo"})
+			continue
+		}
+
+		lines = append(lines, Line{
+			Line:          lineNumber,
+			AccountNumber: field("account_number"),
+			BankCode:      field("bank_code"),
+			Amount:        amount,
+			Narration:     field("narration"),
+		})
+	}
+
+	return lines, lineErrors, nil
+}
+
+func mapColumns(header []string) map[string]int {
+	normalized := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.ReplaceAll(name, " ", "_")
+		if _, exists := normalized[name]; !exists {
+			normalized[name] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for column, aliases := range columnAliases {
+		for _, alias := range aliases {
+			if index, ok := normalized[alias]; ok {
+				columns[column] = index
+				break
+			}
+		}
+	}
+	return columns
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
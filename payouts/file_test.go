@@ -0,0 +1,165 @@
+package payouts
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantLines  []Line
+		wantErrors []LineError
+		wantErr    string
+	}{
+		{
+			name: "csv",
+			data: "account_number,bank_code,amount,narration\n" +
+				"0123456789,058,500000,Salary\n" +
+				"9876543210,044,250000,\n",
+			wantLines: []Line{
+				{Line: 2, AccountNumber: "0123456789", BankCode: "058", Amount: 500000, Narration: "Salary"},
+				{Line: 3, AccountNumber: "9876543210", BankCode: "044", Amount: 250000},
+			},
+		},
+		{
+			name: "csv with column aliases, byte order mark and blank rows",
+			data: "\ufeffBank, Account No ,Amount,Reason\n" +
+				"058, 0123456789,\"1,000\",Rent\n" +
+				",,,\n" +
+				"044,9876543210,200,\n",
+			wantLines: []Line{
+				{Line: 2, AccountNumber: "0123456789", BankCode: "058", Amount: 1000, Narration: "Rent"},
+				{Line: 4, AccountNumber: "9876543210", BankCode: "044", Amount: 200},
+			},
+		},
+		{
+			name: "csv line errors in line order",
+			data: "account_number,bank_code,amount\n" +
+				"012345678,058,500000\n" +
+				"0123456789,058,5.50\n" +
+				"0123456789,GTB,500000\n" +
+				"0123456789,058,0\n" +
+				"0123456789,058,100\n",
+			wantLines: []Line{
+				{Line: 6, AccountNumber: "0123456789", BankCode: "058", Amount: 100},
+			},
+			wantErrors: []LineError{
+				{Line: 2, Error: "account_number must be 10 digits"},
+				{Line: 3, Error: "amount must be a whole number of kobo"},
+				{Line: 4, Error: "bank_code must be a numeric bank code"},
+				{Line: 5, Error: "amount must be greater than 0"},
+			},
+		},
+		{
+			name: "csv narration too long",
+			data: "account_number,bank_code,amount,narration\n" +
+				"0123456789,058,100," + strings.Repeat("x", MaxNarrationLength+1) + "\n",
+			wantErrors: []LineError{
+				{Line: 2, Error: "narration must be at most 100 characters"},
+			},
+		},
+		{
+			name: "amounts above the per-line maximum",
+			data: "account_number,bank_code,amount\n" +
+				"0123456789,058,9223372036854775000\n" +
+				"0123456789,058,9223372036854775000\n",
+			wantErrors: []LineError{
+				{Line: 2, Error: "amount must be at most 10000000000"},
+				{Line: 3, Error: "amount must be at most 10000000000"},
+			},
+		},
+		{
+			name:    "csv missing a required column",
+			data:    "account_number,amount\n0123456789,100\n",
+			wantErr: "missing bank_code column",
+		},
+		{
+			name:    "empty file",
+			data:    "",
+			wantErr: "file is empty",
+		},
+		{
+			name: "json",
+			data: ` [{"account_number": " 0123456789 ", "bank_code": "058", "amount": 500000, "narration": "Salary"},
+				{"account_number": "9876543210", "bank_code": "044", "amount": -1}]`,
+			wantLines: []Line{
+				{Line: 1, AccountNumber: "0123456789", BankCode: "058", Amount: 500000, Narration: "Salary"},
+			},
+			wantErrors: []LineError{
+				{Line: 2, Error: "amount must be greater than 0"},
+			},
+		},
+		{
+			name:    "invalid json",
+			data:    `[{"account_number": "0123456789", "amount": "5000"}]`,
+			wantErr: "invalid JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, lineErrors, err := Parse([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(lines) != 0 || len(tt.wantLines) != 0 {
+				if !reflect.DeepEqual(lines, tt.wantLines) {
+					t.Errorf("Parse() lines = %+v, want %+v", lines, tt.wantLines)
+				}
+			}
+			if len(lineErrors) != 0 || len(tt.wantErrors) != 0 {
+				if !reflect.DeepEqual(lineErrors, tt.wantErrors) {
+					t.Errorf("Parse() line errors = %+v, want %+v", lineErrors, tt.wantErrors)
+				}
+			}
+		})
+	}
+}
+
+func TestTotal(t *testing.T) {
+	tests := []struct {
+		name    string
+		amounts []int64
+		want    int64
+		wantErr bool
+	}{
+		{name: "one line", amounts: []int64{500000}, want: 500000},
+		{name: "several lines", amounts: []int64{500000, 250000, 1}, want: 750001},
+		{name: "up to the int64 limit", amounts: []int64{math.MaxInt64 - 1, 1}, want: math.MaxInt64},
+		{name: "two lines near the int64 limit", amounts: []int64{math.MaxInt64 - 100, math.MaxInt64 - 100}, wantErr: true},
+		{name: "overflow on a later line", amounts: []int64{1, math.MaxInt64 - 1, 1}, wantErr: true},
+		{name: "negative line", amounts: []int64{500000, -500000}, wantErr: true},
+		{name: "zero line", amounts: []int64{0}, wantErr: true},
+		{name: "no lines", amounts: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := make([]Line, len(tt.amounts))
+			for i, amount := range tt.amounts {
+				lines[i] = Line{Line: i + 2, Amount: amount}
+			}
+
+			got, err := Total(lines)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Total() = %d, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Total() = %d, %v; want %d", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1063 @@
+// Package paystacksim is an in-process fake of the parts of the Paystack API
+// the wallet service uses. It keeps everything in memory, serves a checkout
+// page that can be "paid" or declined, and delivers HMAC-signed webhooks just
+// like Paystack does, so deposits can be tested end to end without real keys
+// or internet access.
+package paystacksim
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Server is a fake Paystack. Create one with New and mount Handler on an
+// http.Server or httptest.Server.
+type Server struct {
+	secretKey  string
+	webhookURL string
+	publicURL  string
+	client     *http.Client
+
+	mu           sync.Mutex
+	transactions map[string]*transaction  // By reference
+	accessCodes  map[string]string        // Access code to reference
+	cards        map[string]authorization // By authorization code
+	cardEmails   map[string]string        // Authorization code to the email it is tied to
+	recipients   map[string]recipient     // By recipient code
+	customers    map[string]string        // Email to customer code
+	accounts     map[string]string        // Dedicated account number to customer code
+	transfers    map[string]*transfer     // By reference
+	refunds      []*refund
+	disputes     map[string]*dispute // By id
+}
+
+type transaction struct {
+	ID          int64      `json:"id"`
+	Reference   string     `json:"reference"`
+	Email       string     `json:"-"`
+	Amount      int64      `json:"amount"`
+	Currency    string     `json:"currency"`
+	Status      string     `json:"status"`
+	Channel     string     `json:"channel"`
+	Gateway     string     `json:"gateway_response"`
+	Fees        int64      `json:"fees"`
+	PaidAt      *time.Time `json:"paid_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DisplayText string     `json:"display_text,omitempty"`
+	AccessCode  string     `json:"-"`
+	CallbackURL string     `json:"-"`
+	Channels    []string   `json:"-"`
+
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+
+	Authorization *authorization `json:"authorization,omitempty"`
+	Customer      customer       `json:"customer"`
+}
+
+type authorization struct {
+	AuthorizationCode string `json:"authorization_code"`
+	Last4             string `json:"last4"`
+	ExpMonth          string `json:"exp_month"`
+	ExpYear           string `json:"exp_year"`
+	Channel           string `json:"channel"`
+	CardType          string `json:"card_type"`
+	Bank              string `json:"bank"`
+	Brand             string `json:"brand"`
+	Reusable          bool   `json:"reusable"`
+	Signature         string `json:"signature"`
+
+	ReceiverBankAccountNumber string `json:"receiver_bank_account_number,omitempty"`
+	SenderName                string `json:"sender_name,omitempty"`
+	SenderBank                string `json:"sender_bank,omitempty"`
+}
+
+type customer struct {
+	Email        string `json:"email"`
+	CustomerCode string `json:"customer_code,omitempty"`
+}
+
+type recipient struct {
+	Code          string `json:"recipient_code"`
+	Name          string `json:"name"`
+	AccountNumber string `json:"account_number"`
+	BankCode      string `json:"bank_code"`
+	Currency      string `json:"currency"`
+}
+
+type transfer struct {
+	ID        int64  `json:"id"`
+	Code      string `json:"transfer_code"`
+	Reference string `json:"reference"`
+	Amount    int64  `json:"amount"`
+	Recipient string `json:"recipient"`
+	Reason    string `json:"reason"`
+	Status    string `json:"status"`
+}
+
+type refund struct {
+	ID                   int64  `json:"id"`
+	TransactionReference string `json:"transaction_reference"`
+	Amount               int64  `json:"amount"`
+	Currency             string `json:"currency"`
+	Status               string `json:"status"`
+}
+
+type dispute struct {
+	ID           int64               `json:"id"`
+	RefundAmount int64               `json:"refund_amount"`
+	Currency     string              `json:"currency"`
+	Status       string              `json:"status"`
+	Resolution   *string             `json:"resolution"`
+	Category     string              `json:"category"`
+	DueAt        time.Time           `json:"dueAt"`
+	EvidenceID   int64               `json:"-"`
+	Transaction  disputedTransaction `json:"transaction"`
+}
+
+type disputedTransaction struct {
+	ID        int64  `json:"id"`
+	Reference string `json:"reference"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+}
+
+// New returns a simulator that authenticates API calls with secretKey, signs
+// webhooks with it and posts them to webhookURL. publicURL is the address the
+// simulator is reachable on, used to build checkout links.
+func New(secretKey, webhookURL, publicURL string) *Server {
+	return &Server{
+		secretKey:    secretKey,
+		webhookURL:   webhookURL,
+		publicURL:    strings.TrimSuffix(publicURL, "/"),
+		client:       &http.Client{Timeout: 10 * time.Second},
+		transactions: make(map[string]*transaction),
+		accessCodes:  make(map[string]string),
+		cards:        make(map[string]authorization),
+		cardEmails:   make(map[string]string),
+		recipients:   make(map[string]recipient),
+		customers:    make(map[string]string),
+		accounts:     make(map[string]string),
+		transfers:    make(map[string]*transfer),
+		disputes:     make(map[string]*dispute),
+	}
+}
+
+// Handler returns the simulator's HTTP routes.
+func (s *Server) Handler() http.Handler {
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	router.GET("/checkout/:access_code", s.checkoutPage)
+	router.POST("/checkout/:access_code/pay", s.checkoutPay)
+	router.POST("/checkout/:access_code/decline", s.checkoutDecline)
+	router.POST("/simulate/bank-transfer", s.simulateBankTransfer)
+	router.POST("/simulate/dispute", s.simulateDispute)
+
+	api := router.Group("/")
+	api.Use(s.requireSecretKey())
+	{
+		api.POST("/transaction/initialize", s.initializeTransaction)
+		api.GET("/transaction/verify/:reference", s.verifyTransaction)
+		api.POST("/transaction/charge_authorization", s.chargeAuthorization)
+		api.POST("/charge/submit_otp", s.submitOTP)
+		api.POST("/customer", s.createCustomer)
+		api.POST("/dedicated_account", s.createDedicatedAccount)
+		api.POST("/transferrecipient", s.createRecipient)
+		api.POST("/transfer", s.createTransfer)
+		api.POST("/transfer/bulk", s.createBulkTransfer)
+		api.GET("/transfer/verify/:reference", s.verifyTransfer)
+		api.GET("/bank/resolve", s.resolveAccount)
+		api.POST("/refund", s.createRefund)
+		api.POST("/dispute/:id/evidence", s.addDisputeEvidence)
+		api.PUT("/dispute/:id/resolve", s.resolveDispute)
+	}
+
+	return router
+}
+
+func (s *Server) requireSecretKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "Bearer "+s.secretKey {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": "Invalid key"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func (s *Server) initializeTransaction(c *gin.Context) {
+	var req struct {
+		Email       string          `json:"email"`
+		Amount      int64           `json:"amount"`
+		Currency    string          `json:"currency"`
+		Reference   string          `json:"reference"`
+		CallbackURL string          `json:"callback_url"`
+		Channels    []string        `json:"channels"`
+		Metadata    json.RawMessage `json:"metadata"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Email is required"})
+		return
+	}
+	if req.Amount < 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Invalid Amount Sent"})
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = "NGN"
+	}
+	if req.Reference == "" {
+		req.Reference = "SIM_" + randomCode(8)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.transactions[req.Reference]; exists {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Duplicate Transaction Reference"})
+		return
+	}
+
+	accessCode := randomCode(10)
+	s.transactions[req.Reference] = &transaction{
+		ID:          time.Now().UnixNano(),
+		Reference:   req.Reference,
+		Email:       req.Email,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Status:      "ongoing",
+		CreatedAt:   time.Now(),
+		AccessCode:  accessCode,
+		CallbackURL: req.CallbackURL,
+		Channels:    req.Channels,
+		Metadata:    req.Metadata,
+		Customer:    customer{Email: req.Email},
+	}
+	s.accessCodes[accessCode] = req.Reference
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Authorization URL created",
+		"data": gin.H{
+			"authorization_url": s.publicURL + "/checkout/" + accessCode,
+			"access_code":       accessCode,
+			"reference":         req.Reference,
+		},
+	})
+}
+
+func (s *Server) verifyTransaction(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	txn, ok := s.transactions[c.Param("reference")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Transaction reference not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Verification successful", "data": txn})
+}
+
+// otpThreshold is the amount from which charging a saved card asks for an OTP
+// (₦10,000), so both challenge and frictionless paths can be exercised.
+const otpThreshold = 1000000
+
+// simulatorOTP is the only OTP the simulator accepts.
+const simulatorOTP = "123456"
+
+func (s *Server) chargeAuthorization(c *gin.Context) {
+	var req struct {
+		AuthorizationCode string `json:"authorization_code"`
+		Email             string `json:"email"`
+		Amount            int64  `json:"amount"`
+		Currency          string `json:"currency"`
+		Reference         string `json:"reference"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Amount < 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Invalid Amount Sent"})
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = "NGN"
+	}
+
+	s.mu.Lock()
+	card, ok := s.cards[req.AuthorizationCode]
+	if !ok || !strings.EqualFold(s.cardEmails[req.AuthorizationCode], req.Email) {
+		s.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Invalid authorization code"})
+		return
+	}
+	if _, exists := s.transactions[req.Reference]; exists {
+		s.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Duplicate Transaction Reference"})
+		return
+	}
+
+	txn := &transaction{
+		ID:            time.Now().UnixNano(),
+		Reference:     req.Reference,
+		Email:         req.Email,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Channel:       "card",
+		CreatedAt:     time.Now(),
+		Authorization: &card,
+		Customer:      customer{Email: req.Email},
+	}
+	s.transactions[req.Reference] = txn
+
+	if req.Amount >= otpThreshold {
+		txn.Status = "send_otp"
+		txn.DisplayText = "Please enter the OTP sent to your phone (" + simulatorOTP + ")"
+		data := *txn
+		s.mu.Unlock()
+		c.JSON(http.StatusOK, gin.H{"status": true, "message": "Charge attempted", "data": data})
+		return
+	}
+
+	data := s.approve(txn)
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Charge attempted", "data": data})
+	go s.sendWebhook("charge.success", data)
+}
+
+func (s *Server) submitOTP(c *gin.Context) {
+	var req struct {
+		OTP       string `json:"otp"`
+		Reference string `json:"reference"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "OTP and reference are required"})
+		return
+	}
+
+	s.mu.Lock()
+	txn, ok := s.transactions[req.Reference]
+	if !ok || txn.Status != "send_otp" {
+		s.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Transaction is not awaiting an OTP"})
+		return
+	}
+	if req.OTP != simulatorOTP {
+		s.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Invalid OTP"})
+		return
+	}
+
+	txn.DisplayText = ""
+	data := s.approve(txn)
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Charge attempted", "data": data})
+	go s.sendWebhook("charge.success", data)
+}
+
+// approve marks a transaction paid. Must be called with s.mu held.
+func (s *Server) approve(txn *transaction) transaction {
+	now := time.Now().UTC()
+	txn.Status = "success"
+	txn.Gateway = "Approved"
+	txn.Fees = paystackFees(txn.Amount)
+	txn.PaidAt = &now
+	return *txn
+}
+
+// issueCard returns a reusable test card authorization. The signature is
+// stable per email so repeat payments look like the same card. Must be
+// called with s.mu held.
+func (s *Server) issueCard(email string) *authorization {
+	signature := hmac.New(sha512.New, []byte(s.secretKey))
+	signature.Write([]byte(strings.ToLower(email)))
+
+	card := authorization{
+		AuthorizationCode: "AUTH_" + randomCode(10),
+		Last4:             "4081",
+		ExpMonth:          "12",
+		ExpYear:           "2030",
+		Channel:           "card",
+		CardType:          "visa ",
+		Bank:              "TEST BANK",
+		Brand:             "visa",
+		Reusable:          true,
+		Signature:         "SIG_" + hex.EncodeToString(signature.Sum(nil))[:20],
+	}
+	s.cards[card.AuthorizationCode] = card
+	s.cardEmails[card.AuthorizationCode] = email
+	return &card
+}
+
+func (s *Server) createCustomer(c *gin.Context) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Email is required"})
+		return
+	}
+
+	s.mu.Lock()
+	code, ok := s.customers[strings.ToLower(req.Email)]
+	if !ok {
+		code = "CUS_" + randomCode(12)
+		s.customers[strings.ToLower(req.Email)] = code
+	}
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Customer created",
+		"data":    gin.H{"email": req.Email, "customer_code": code},
+	})
+}
+
+func (s *Server) createDedicatedAccount(c *gin.Context) {
+	var req struct {
+		Customer string `json:"customer"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Customer == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Customer is required"})
+		return
+	}
+
+	s.mu.Lock()
+	accountNumber := ""
+	for number, code := range s.accounts {
+		if code == req.Customer {
+			accountNumber = number
+		}
+	}
+	if accountNumber == "" {
+		accountNumber = fmt.Sprintf("99%08d", len(s.accounts)+1)
+		s.accounts[accountNumber] = req.Customer
+	}
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "NUBAN successfully created",
+		"data": gin.H{
+			"id":             time.Now().UnixNano(),
+			"account_name":   "PAYSTACKSIM/" + req.Customer,
+			"account_number": accountNumber,
+			"currency":       "NGN",
+			"active":         true,
+			"bank":           gin.H{"name": "Simulator Bank", "slug": "test-bank"},
+		},
+	})
+}
+
+// simulateBankTransfer pretends someone paid into a dedicated account and
+// sends the resulting dedicated_nuban charge.success webhook.
+func (s *Server) simulateBankTransfer(c *gin.Context) {
+	var req struct {
+		AccountNumber string `json:"account_number"`
+		Amount        int64  `json:"amount"`
+		SenderName    string `json:"sender_name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "account_number and amount are required"})
+		return
+	}
+	if req.SenderName == "" {
+		req.SenderName = "JANE DOE"
+	}
+
+	s.mu.Lock()
+	customerCode, ok := s.accounts[req.AccountNumber]
+	if !ok {
+		s.mu.Unlock()
+		c.JSON(http.StatusNotFound, gin.H{"status": false, "message": "Unknown dedicated account"})
+		return
+	}
+
+	reference := "SIMNUBAN_" + randomCode(12)
+	txn := &transaction{
+		ID:        time.Now().UnixNano(),
+		Reference: reference,
+		Amount:    req.Amount,
+		Currency:  "NGN",
+		Channel:   "dedicated_nuban",
+		CreatedAt: time.Now(),
+		Authorization: &authorization{
+			Channel:                   "dedicated_nuban",
+			ReceiverBankAccountNumber: req.AccountNumber,
+			SenderName:                req.SenderName,
+			SenderBank:                "Simulator Bank",
+		},
+		Customer: customer{CustomerCode: customerCode},
+	}
+	s.transactions[reference] = txn
+	data := s.approve(txn)
+	s.mu.Unlock()
+
+	if err := s.sendWebhook("charge.success", data); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"status": false, "message": "Webhook delivery failed: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Transfer received", "data": data})
+}
+
+func (s *Server) createRecipient(c *gin.Context) {
+	var req struct {
+		Name          string `json:"name"`
+		AccountNumber string `json:"account_number"`
+		BankCode      string `json:"bank_code"`
+		Currency      string `json:"currency"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.AccountNumber == "" || req.BankCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Account number and bank code are required"})
+		return
+	}
+
+	r := recipient{
+		Code:          "RCP_" + randomCode(10),
+		Name:          req.Name,
+		AccountNumber: req.AccountNumber,
+		BankCode:      req.BankCode,
+		Currency:      req.Currency,
+	}
+
+	s.mu.Lock()
+	s.recipients[r.Code] = r
+	s.mu.Unlock()
+
+	c.JSON(http.StatusCreated, gin.H{"status": true, "message": "Transfer recipient created successfully", "data": r})
+}
+
+func (s *Server) createTransfer(c *gin.Context) {
+	var req struct {
+		Amount    int64  `json:"amount"`
+		Recipient string `json:"recipient"`
+		Reason    string `json:"reason"`
+		Reference string `json:"reference"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Invalid amount"})
+		return
+	}
+
+	s.mu.Lock()
+	if _, ok := s.recipients[req.Recipient]; !ok {
+		s.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Recipient specified is invalid"})
+		return
+	}
+	if req.Reference == "" {
+		req.Reference = "TRF_REF_" + randomCode(8)
+	}
+	t := &transfer{
+		ID:        time.Now().UnixNano(),
+		Code:      "TRF_" + randomCode(10),
+		Reference: req.Reference,
+		Amount:    req.Amount,
+		Recipient: req.Recipient,
+		Reason:    req.Reason,
+		Status:    "pending",
+	}
+	s.transfers[t.Reference] = t
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Transfer has been queued", "data": t})
+
+	go s.settleTransfer(t)
+}
+
+// createBulkTransfer queues several transfers to existing recipients. Like
+// Paystack, it rejects the whole request if any transfer is invalid.
+func (s *Server) createBulkTransfer(c *gin.Context) {
+	var req struct {
+		Currency  string `json:"currency"`
+		Transfers []struct {
+			Amount    int64  `json:"amount"`
+			Recipient string `json:"recipient"`
+			Reference string `json:"reference"`
+			Reason    string `json:"reason"`
+		} `json:"transfers"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Transfers) == 0 || len(req.Transfers) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Between 1 and 100 transfers are required"})
+		return
+	}
+
+	s.mu.Lock()
+	for _, item := range req.Transfers {
+		_, known := s.recipients[item.Recipient]
+		_, duplicate := s.transfers[item.Reference]
+		if !known || item.Amount <= 0 || item.Reference == "" || duplicate {
+			s.mu.Unlock()
+			c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Invalid transfer to " + item.Recipient + " (" + item.Reference + ")"})
+			return
+		}
+	}
+
+	var queued []*transfer
+	var data []gin.H
+	for _, item := range req.Transfers {
+		t := &transfer{
+			ID:        time.Now().UnixNano(),
+			Code:      "TRF_" + randomCode(10),
+			Reference: item.Reference,
+			Amount:    item.Amount,
+			Recipient: item.Recipient,
+			Reason:    item.Reason,
+			Status:    "pending",
+		}
+		s.transfers[t.Reference] = t
+		queued = append(queued, t)
+		data = append(data, gin.H{
+			"reference":     t.Reference,
+			"recipient":     t.Recipient,
+			"amount":        t.Amount,
+			"transfer_code": t.Code,
+			"currency":      req.Currency,
+			"status":        "received",
+		})
+	}
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": fmt.Sprintf("%d transfers queued.", len(queued)), "data": data})
+
+	go func() {
+		for _, t := range queued {
+			s.settleTransfer(t)
+		}
+	}()
+}
+
+func (s *Server) verifyTransfer(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.transfers[c.Param("reference")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Transfer not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Transfer retrieved", "data": t})
+}
+
+// settleTransfer completes a queued transfer and reports it by webhook, the
+// way Paystack settles transfers asynchronously. Transfers to account
+// numbers ending in 99 fail.
+func (s *Server) settleTransfer(t *transfer) {
+	s.mu.Lock()
+	event := "transfer.success"
+	t.Status = "success"
+	if strings.HasSuffix(s.recipients[t.Recipient].AccountNumber, "99") {
+		event = "transfer.failed"
+		t.Status = "failed"
+	}
+	data := *t
+	s.mu.Unlock()
+	s.sendWebhook(event, data)
+}
+
+// resolveAccount names any 10 digit account number, except those starting
+// with 000, which do not exist.
+func (s *Server) resolveAccount(c *gin.Context) {
+	accountNumber := c.Query("account_number")
+	if len(accountNumber) != 10 || strings.HasPrefix(accountNumber, "000") || c.Query("bank_code") == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"status": false, "message": "Could not resolve account name. Check parameters or try again."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Account number resolved",
+		"data": gin.H{
+			"account_number": accountNumber,
+			"account_name":   "PAYSTACKSIM ACCOUNT " + accountNumber[6:],
+			"bank_id":        1,
+		},
+	})
+}
+
+func (s *Server) createRefund(c *gin.Context) {
+	var req struct {
+		Transaction string `json:"transaction"`
+		Amount      int64  `json:"amount"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Transaction is required"})
+		return
+	}
+
+	s.mu.Lock()
+	txn, ok := s.transactions[req.Transaction]
+	if !ok || txn.Status != "success" {
+		s.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Transaction has not been paid"})
+		return
+	}
+
+	var refunded int64
+	for _, r := range s.refunds {
+		if r.TransactionReference == txn.Reference && r.Status != "failed" {
+			refunded += r.Amount
+		}
+	}
+	if req.Amount == 0 {
+		req.Amount = txn.Amount - refunded
+	}
+	if req.Amount <= 0 || refunded+req.Amount > txn.Amount {
+		s.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Refund amount cannot be more than transaction amount"})
+		return
+	}
+
+	r := &refund{
+		ID:                   time.Now().UnixNano(),
+		TransactionReference: txn.Reference,
+		Amount:               req.Amount,
+		Currency:             txn.Currency,
+		Status:               "pending",
+	}
+	s.refunds = append(s.refunds, r)
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Refund has been queued for processing",
+		"data": gin.H{
+			"id":          r.ID,
+			"amount":      r.Amount,
+			"currency":    r.Currency,
+			"status":      r.Status,
+			"transaction": gin.H{"id": txn.ID, "reference": txn.Reference},
+		},
+	})
+
+	go func() {
+		s.mu.Lock()
+		r.Status = "processed"
+		data := *r
+		s.mu.Unlock()
+		s.sendWebhook("refund.processed", data)
+	}()
+}
+
+// simulateDispute pretends the customer disputed a paid transaction with
+// their bank and sends the charge.dispute.create webhook.
+func (s *Server) simulateDispute(c *gin.Context) {
+	var req struct {
+		Reference string `json:"reference"`
+		Amount    int64  `json:"amount"` // Defaults to the transaction amount
+		Category  string `json:"category"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Reference == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "reference is required"})
+		return
+	}
+	if req.Category == "" {
+		req.Category = "chargeback"
+	}
+
+	s.mu.Lock()
+	txn, ok := s.transactions[req.Reference]
+	if !ok || txn.Status != "success" {
+		s.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Transaction has not been paid"})
+		return
+	}
+	if req.Amount <= 0 || req.Amount > txn.Amount {
+		req.Amount = txn.Amount
+	}
+
+	d := &dispute{
+		ID:           time.Now().UnixNano(),
+		RefundAmount: req.Amount,
+		Currency:     txn.Currency,
+		Status:       "awaiting-merchant-feedback",
+		Category:     req.Category,
+		DueAt:        time.Now().Add(48 * time.Hour).UTC(),
+		Transaction: disputedTransaction{
+			ID:        txn.ID,
+			Reference: txn.Reference,
+			Amount:    txn.Amount,
+			Currency:  txn.Currency,
+		},
+	}
+	s.disputes[fmt.Sprint(d.ID)] = d
+	data := *d
+	s.mu.Unlock()
+
+	if err := s.sendWebhook("charge.dispute.create", data); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"status": false, "message": "Webhook delivery failed: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Dispute created", "data": data})
+}
+
+func (s *Server) addDisputeEvidence(c *gin.Context) {
+	var req struct {
+		CustomerEmail  string `json:"customer_email"`
+		CustomerName   string `json:"customer_name"`
+		CustomerPhone  string `json:"customer_phone"`
+		ServiceDetails string `json:"service_details"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.CustomerEmail == "" || req.CustomerName == "" || req.CustomerPhone == "" || req.ServiceDetails == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "customer_email, customer_name, customer_phone and service_details are required"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.disputes[c.Param("id")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"status": false, "message": "Dispute not found"})
+		return
+	}
+	d.EvidenceID = time.Now().UnixNano()
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Evidence created", "data": gin.H{"id": d.EvidenceID, "dispute": d.ID}})
+}
+
+// resolveDispute accepts or declines a dispute. The customer's bank always
+// sides with a declining merchant, shortly after, by webhook.
+func (s *Server) resolveDispute(c *gin.Context) {
+	var req struct {
+		Resolution string `json:"resolution"`
+		Message    string `json:"message"`
+		Evidence   int64  `json:"evidence"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Resolution != "merchant-accepted" && req.Resolution != "declined") {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Resolution must be merchant-accepted or declined"})
+		return
+	}
+
+	s.mu.Lock()
+	d, ok := s.disputes[c.Param("id")]
+	if !ok {
+		s.mu.Unlock()
+		c.JSON(http.StatusNotFound, gin.H{"status": false, "message": "Dispute not found"})
+		return
+	}
+	if d.Status == "resolved" {
+		s.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Dispute has already been resolved"})
+		return
+	}
+	if req.Resolution == "declined" && (d.EvidenceID == 0 || req.Evidence != d.EvidenceID) {
+		s.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Evidence is required to decline a dispute"})
+		return
+	}
+
+	resolution := req.Resolution
+	if resolution == "merchant-accepted" {
+		d.Status = "resolved"
+		d.Resolution = &resolution
+	} else {
+		d.Status = "awaiting-bank-feedback"
+	}
+	data := *d
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Dispute updated", "data": data})
+
+	go func() {
+		s.mu.Lock()
+		d.Status = "resolved"
+		d.Resolution = &resolution
+		data := *d
+		s.mu.Unlock()
+		s.sendWebhook("charge.dispute.resolve", data)
+	}()
+}
+
+var checkoutTemplate = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Paystack Simulator Checkout</title></head>
+<body style="font-family: sans-serif; max-width: 420px; margin: 60px auto;">
+  <h2>Paystack Simulator</h2>
+  <p>{{.Email}} is paying <strong>{{.Currency}} {{.Major}}</strong></p>
+  <p>Reference: <code>{{.Reference}}</code></p>
+  <p>Status: <strong>{{.Status}}</strong></p>
+  {{if .Open}}
+  <form method="post" action="/checkout/{{.AccessCode}}/pay" style="display:inline">
+    <button type="submit">Pay</button>
+  </form>
+  <form method="post" action="/checkout/{{.AccessCode}}/decline" style="display:inline">
+    <button type="submit">Decline</button>
+  </form>
+  {{end}}
+  {{if .Message}}<p>{{.Message}}</p>{{end}}
+</body>
+</html>`))
+
+func (s *Server) checkoutPage(c *gin.Context) {
+	s.renderCheckout(c, c.Param("access_code"), "")
+}
+
+func (s *Server) checkoutPay(c *gin.Context) {
+	s.completeCheckout(c, true)
+}
+
+func (s *Server) checkoutDecline(c *gin.Context) {
+	s.completeCheckout(c, false)
+}
+
+func (s *Server) completeCheckout(c *gin.Context, paid bool) {
+	accessCode := c.Param("access_code")
+
+	s.mu.Lock()
+	txn, ok := s.transactionByAccessCode(accessCode)
+	if !ok || txn.Status != "ongoing" {
+		s.mu.Unlock()
+		s.renderCheckout(c, accessCode, "This checkout is no longer open.")
+		return
+	}
+
+	// Pay with the first channel the checkout was limited to
+	txn.Channel = "card"
+	if len(txn.Channels) > 0 {
+		txn.Channel = txn.Channels[0]
+	}
+	var data transaction
+	if paid {
+		if txn.Channel == "card" {
+			txn.Authorization = s.issueCard(txn.Email)
+		}
+		data = s.approve(txn)
+	} else {
+		txn.Status = "failed"
+		txn.Gateway = "Declined"
+		data = *txn
+	}
+	s.mu.Unlock()
+
+	message := "Payment declined."
+	if paid {
+		message = "Payment successful."
+		if err := s.sendWebhook("charge.success", data); err != nil {
+			message += " Webhook delivery failed: " + err.Error()
+		} else {
+			message += " Webhook delivered."
+		}
+	}
+
+	if data.CallbackURL != "" {
+		separator := "?"
+		if strings.Contains(data.CallbackURL, "?") {
+			separator = "&"
+		}
+		c.Redirect(http.StatusSeeOther, data.CallbackURL+separator+"trxref="+data.Reference+"&reference="+data.Reference)
+		return
+	}
+
+	s.renderCheckout(c, accessCode, message)
+}
+
+func (s *Server) renderCheckout(c *gin.Context, accessCode, message string) {
+	s.mu.Lock()
+	txn, ok := s.transactionByAccessCode(accessCode)
+	var data transaction
+	if ok {
+		data = *txn
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		c.String(http.StatusNotFound, "Unknown checkout")
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	checkoutTemplate.Execute(c.Writer, map[string]interface{}{
+		"Email":      data.Email,
+		"Currency":   data.Currency,
+		"Major":      fmt.Sprintf("%.2f", float64(data.Amount)/100),
+		"Reference":  data.Reference,
+		"Status":     data.Status,
+		"AccessCode": accessCode,
+		"Open":       data.Status == "ongoing",
+		"Message":    message,
+	})
+}
+
+// transactionByAccessCode must be called with s.mu held.
+func (s *Server) transactionByAccessCode(accessCode string) (*transaction, bool) {
+	reference, ok := s.accessCodes[accessCode]
+	if !ok {
+		return nil, false
+	}
+	txn, ok := s.transactions[reference]
+	return txn, ok
+}
+
+// sendWebhook posts an event to the configured webhook URL, signed with
+// HMAC-SHA512 of the body the same way Paystack signs x-paystack-signature.
+func (s *Server) sendWebhook(event string, data interface{}) error {
+	if s.webhookURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"event": event, "data": data})
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha512.New, []byte(s.secretKey))
+	mac.Write(body)
+
+	req, err := http.NewRequest("POST", s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-paystack-signature", hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Printf("paystacksim: %s webhook failed: %v", event, err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("paystacksim: %s webhook returned %d", event, resp.StatusCode)
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// paystackFees approximates Paystack's local card pricing: 1.5% plus ₦100 for
+// amounts of ₦2,500 and above, capped at ₦2,000.
+func paystackFees(amount int64) int64 {
+	fees := amount * 15 / 1000
+	if amount >= 250000 {
+		fees += 10000
+	}
+	if fees > 200000 {
+		fees = 200000
+	}
+	return fees
+}
+
+func randomCode(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)[:n]
+}
@@ -0,0 +1,277 @@
+package reconcile
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"wallet-service/models"
+
+	"gorm.io/gorm"
+)
+
+type IssueKind string
+
+const (
+	IssueMissingDeposit  IssueKind = "missing_deposit"   // Paystack settled a reference we have no deposit for
+	IssueNotCredited     IssueKind = "not_credited"      // Paystack settled it, but we did not credit the wallet
+	IssueAmountMismatch  IssueKind = "amount_mismatch"   // Settled amount or currency differs from what we credited
+	IssueFeeMismatch     IssueKind = "fee_mismatch"      // Fees differ from what the webhook reported
+	IssueUnsettled       IssueKind = "unsettled"         // We credited it, but Paystack never settled it
+	IssueDuplicateInFile IssueKind = "duplicate_in_file" // The same reference appears more than once
+)
+
+// Options limit the check for unsettled deposits to a period. When From and
+// To are zero, the period spans the dates found in the file. To is exclusive.
+type Options struct {
+	From time.Time
+	To   time.Time
+}
+
+type Issue struct {
+	Kind      IssueKind `json:"kind"`
+	Reference string    `json:"reference"`
+	Line      int       `json:"line,omitempty"`     // Line in the file, when the issue comes from one
+	Expected  int64     `json:"expected,omitempty"` // What we recorded, in kobo
+	Actual    int64     `json:"actual,omitempty"`   // What Paystack reported, in kobo
+	Detail    string    `json:"detail"`
+}
+
+// Report is the outcome of reconciling one export file. Amounts are in kobo.
+type Report struct {
+	From          *time.Time        `json:"from,omitempty"`
+	To            *time.Time        `json:"to,omitempty"`
+	Lines         int               `json:"lines"`
+	Matched       int               `json:"matched"`
+	SettledAmount int64             `json:"settled_amount"`
+	Fees          int64             `json:"fees"`
+	NetSettled    int64             `json:"net_settled"`
+	Counts        map[IssueKind]int `json:"counts"`
+	Issues        []Issue           `json:"issues"`
+}
+
+// referenceBatchSize keeps IN clauses well below Postgres' parameter limit.
+const referenceBatchSize = 1000
+
+// Reconcile matches the export lines against Paystack deposits by reference.
+func Reconcile(db *gorm.DB, lines []SettlementLine, opts Options) (*Report, error) {
+	var references []string
+	seen := make(map[string]bool, len(lines))
+	for _, line := range lines {
+		if !seen[line.Reference] {
+			seen[line.Reference] = true
+			references = append(references, line.Reference)
+		}
+	}
+
+	deposits, err := findDeposits(db, references)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := opts.From, opts.To
+	if from.IsZero() && to.IsZero() {
+		from, to = filePeriod(lines)
+	}
+
+	var credited []models.Transaction
+	if !from.IsZero() && !to.IsZero() {
+		if err := db.Where("type = ? AND provider = ? AND status = ?", models.TransactionTypeDeposit, "paystack", models.TransactionStatusSuccess).
+			Where("COALESCE(paid_at, created_at) >= ? AND COALESCE(paid_at, created_at) < ?", from, to).
+			Order("created_at").Find(&credited).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return buildReport(lines, deposits, credited, from, to), nil
+}
+
+// buildReport compares the export lines with the deposits found for their
+// references, and with the deposits credited between from and to. Without a
+// period there is no telling which credited deposits the file should have
+// covered, so from and to may be zero to skip that check.
+func buildReport(lines []SettlementLine, deposits map[string]models.Transaction, credited []models.Transaction, from, to time.Time) *Report {
+	report := &Report{
+		Lines:  len(lines),
+		Counts: make(map[IssueKind]int),
+		Issues: []Issue{},
+	}
+	addIssue := func(issue Issue) {
+		report.Issues = append(report.Issues, issue)
+		report.Counts[issue.Kind]++
+	}
+
+	var references []string
+	inFile := make(map[string]SettlementLine, len(lines))
+	for _, line := range lines {
+		if first, seen := inFile[line.Reference]; seen {
+			addIssue(Issue{
+				Kind:      IssueDuplicateInFile,
+				Reference: line.Reference,
+				Line:      line.Line,
+				Detail:    fmt.Sprintf("also on line %d", first.Line),
+			})
+			continue
+		}
+		inFile[line.Reference] = line
+		references = append(references, line.Reference)
+	}
+
+	for _, reference := range references {
+		line := inFile[reference]
+		deposit, found := deposits[reference]
+
+		if !line.Settled() {
+			if found && deposit.Status == models.TransactionStatusSuccess {
+				addIssue(Issue{
+					Kind:      IssueUnsettled,
+					Reference: reference,
+					Line:      line.Line,
+					Expected:  deposit.Amount,
+					Detail:    fmt.Sprintf("credited, but Paystack reports %s", line.Status),
+				})
+			}
+			continue
+		}
+
+		report.SettledAmount += line.Amount
+		report.Fees += line.Fees
+
+		if !found {
+			addIssue(Issue{
+				Kind:      IssueMissingDeposit,
+				Reference: reference,
+				Line:      line.Line,
+				Actual:    line.Amount,
+				Detail:    "no deposit with this reference",
+			})
+			continue
+		}
+
+		report.Matched++
+
+		if deposit.Status != models.TransactionStatusSuccess {
+			addIssue(Issue{
+				Kind:      IssueNotCredited,
+				Reference: reference,
+				Line:      line.Line,
+				Actual:    line.Amount,
+				Detail:    fmt.Sprintf("deposit is %s", deposit.Status),
+			})
+			continue
+		}
+
+		if line.Amount != deposit.Amount || (line.Currency != "" && !strings.EqualFold(line.Currency, deposit.Currency)) {
+			addIssue(Issue{
+				Kind:      IssueAmountMismatch,
+				Reference: reference,
+				Line:      line.Line,
+				Expected:  deposit.Amount,
+				Actual:    line.Amount,
+				Detail:    fmt.Sprintf("credited %d %s, settled %d %s", deposit.Amount, deposit.Currency, line.Amount, line.Currency),
+			})
+		}
+
+		// Fees are only known for deposits whose webhook reported them
+		if deposit.Fees != 0 && line.Fees != deposit.Fees {
+			addIssue(Issue{
+				Kind:      IssueFeeMismatch,
+				Reference: reference,
+				Line:      line.Line,
+				Expected:  deposit.Fees,
+				Actual:    line.Fees,
+				Detail:    fmt.Sprintf("recorded fees %d, settled fees %d", deposit.Fees, line.Fees),
+			})
+		}
+	}
+	report.NetSettled = report.SettledAmount - report.Fees
+
+	if from.IsZero() || to.IsZero() {
+		return report
+	}
+	report.From, report.To = &from, &to
+
+	for _, deposit := range credited {
+		if _, ok := inFile[deposit.Reference]; ok {
+			continue
+		}
+		addIssue(Issue{
+			Kind:      IssueUnsettled,
+			Reference: deposit.Reference,
+			Expected:  deposit.Amount,
+			Detail:    "credited, but missing from the file",
+		})
+	}
+
+	return report
+}
+
+func findDeposits(db *gorm.DB, references []string) (map[string]models.Transaction, error) {
+	deposits := make(map[string]models.Transaction, len(references))
+
+	for start := 0; start < len(references); start += referenceBatchSize {
+		end := start + referenceBatchSize
+		if end > len(references) {
+			end = len(references)
+		}
+
+		var batch []models.Transaction
+		if err := db.Where("type = ? AND reference IN ?", models.TransactionTypeDeposit, references[start:end]).
+			Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		for _, deposit := range batch {
+			deposits[deposit.Reference] = deposit
+		}
+	}
+
+	return deposits, nil
+}
+
+// filePeriod returns the days the file covers, from the start of the first
+// day to the end of the last one.
+func filePeriod(lines []SettlementLine) (time.Time, time.Time) {
+	var first, last time.Time
+	for _, line := range lines {
+		if line.PaidAt == nil {
+			continue
+		}
+		if first.IsZero() || line.PaidAt.Before(first) {
+			first = *line.PaidAt
+		}
+		if last.IsZero() || line.PaidAt.After(last) {
+			last = *line.PaidAt
+		}
+	}
+	if first.IsZero() {
+		return time.Time{}, time.Time{}
+	}
+
+	from := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, first.Location())
+	to := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, last.Location()).AddDate(0, 0, 1)
+	return from, to
+}
+
+// ParsePeriod builds Options from inclusive YYYY-MM-DD dates. Both may be
+// left empty to use the file's own period.
+func ParsePeriod(from, to string) (Options, error) {
+	var opts Options
+	if from == "" && to == "" {
+		return opts, nil
+	}
+	if from == "" || to == "" {
+		return opts, fmt.Errorf("both from and to are required")
+	}
+
+	var err error
+	if opts.From, err = time.Parse("2006-01-02", from); err != nil {
+		return opts, fmt.Errorf("invalid from date %q", from)
+	}
+	if opts.To, err = time.Parse("2006-01-02", to); err != nil {
+		return opts, fmt.Errorf("invalid to date %q", to)
+	}
+	if opts.To.Before(opts.From) {
+		return opts, fmt.Errorf("to is before from")
+	}
+	opts.To = opts.To.AddDate(0, 0, 1)
+	return opts, nil
+}
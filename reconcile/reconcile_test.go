@@ -0,0 +1,204 @@
+package reconcile
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"wallet-service/models"
+)
+
+func TestBuildReport(t *testing.T) {
+	from := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	deposit := func(reference string, status models.TransactionStatus, amount, fees int64) models.Transaction {
+		return models.Transaction{Reference: reference, Type: models.TransactionTypeDeposit, Status: status, Amount: amount, Fees: fees, Currency: "NGN"}
+	}
+	success := models.TransactionStatusSuccess
+
+	tests := []struct {
+		name        string
+		lines       []SettlementLine
+		deposits    []models.Transaction
+		credited    []models.Transaction
+		noPeriod    bool
+		wantIssues  []Issue
+		wantMatched int
+		wantSettled int64
+		wantFees    int64
+	}{
+		{
+			name: "everything matches",
+			lines: []SettlementLine{
+				{Line: 2, Reference: "TXN_1", Amount: 500000, Fees: 7500, Currency: "NGN", Status: "success"},
+				{Line: 3, Reference: "TXN_2", Amount: 100000, Fees: 1500},
+			},
+			deposits:    []models.Transaction{deposit("TXN_1", success, 500000, 7500), deposit("TXN_2", success, 100000, 0)},
+			credited:    []models.Transaction{deposit("TXN_1", success, 500000, 7500), deposit("TXN_2", success, 100000, 0)},
+			wantMatched: 2,
+			wantSettled: 600000,
+			wantFees:    9000,
+		},
+		{
+			name:        "missing deposit",
+			lines:       []SettlementLine{{Line: 2, Reference: "TXN_1", Amount: 500000}},
+			wantIssues:  []Issue{{Kind: IssueMissingDeposit, Reference: "TXN_1", Line: 2, Actual: 500000, Detail: "no deposit with this reference"}},
+			wantSettled: 500000,
+		},
+		{
+			name:        "settled but not credited",
+			lines:       []SettlementLine{{Line: 2, Reference: "TXN_1", Amount: 500000}},
+			deposits:    []models.Transaction{deposit("TXN_1", models.TransactionStatusPending, 500000, 0)},
+			wantIssues:  []Issue{{Kind: IssueNotCredited, Reference: "TXN_1", Line: 2, Actual: 500000, Detail: "deposit is pending"}},
+			wantMatched: 1,
+			wantSettled: 500000,
+		},
+		{
+			name:        "amount mismatch",
+			lines:       []SettlementLine{{Line: 2, Reference: "TXN_1", Amount: 400000, Currency: "NGN"}},
+			deposits:    []models.Transaction{deposit("TXN_1", success, 500000, 0)},
+			credited:    []models.Transaction{deposit("TXN_1", success, 500000, 0)},
+			wantIssues:  []Issue{{Kind: IssueAmountMismatch, Reference: "TXN_1", Line: 2, Expected: 500000, Actual: 400000, Detail: "credited 500000 NGN, settled 400000 NGN"}},
+			wantMatched: 1,
+			wantSettled: 400000,
+		},
+		{
+			name:        "currency mismatch",
+			lines:       []SettlementLine{{Line: 2, Reference: "TXN_1", Amount: 500000, Currency: "USD"}},
+			deposits:    []models.Transaction{deposit("TXN_1", success, 500000, 0)},
+			credited:    []models.Transaction{deposit("TXN_1", success, 500000, 0)},
+			wantIssues:  []Issue{{Kind: IssueAmountMismatch, Reference: "TXN_1", Line: 2, Expected: 500000, Actual: 500000, Detail: "credited 500000 NGN, settled 500000 USD"}},
+			wantMatched: 1,
+			wantSettled: 500000,
+		},
+		{
+			name:        "fee mismatch",
+			lines:       []SettlementLine{{Line: 2, Reference: "TXN_1", Amount: 500000, Fees: 8000}},
+			deposits:    []models.Transaction{deposit("TXN_1", success, 500000, 7500)},
+			credited:    []models.Transaction{deposit("TXN_1", success, 500000, 7500)},
+			wantIssues:  []Issue{{Kind: IssueFeeMismatch, Reference: "TXN_1", Line: 2, Expected: 7500, Actual: 8000, Detail: "recorded fees 7500, settled fees 8000"}},
+			wantMatched: 1,
+			wantSettled: 500000,
+			wantFees:    8000,
+		},
+		{
+			name:       "credited deposit Paystack did not settle",
+			lines:      []SettlementLine{{Line: 2, Reference: "TXN_1", Amount: 500000, Status: "failed"}},
+			deposits:   []models.Transaction{deposit("TXN_1", success, 500000, 0)},
+			credited:   []models.Transaction{deposit("TXN_1", success, 500000, 0)},
+			wantIssues: []Issue{{Kind: IssueUnsettled, Reference: "TXN_1", Line: 2, Expected: 500000, Detail: "credited, but Paystack reports failed"}},
+		},
+		{
+			name:     "failed line for a deposit that was not credited",
+			lines:    []SettlementLine{{Line: 2, Reference: "TXN_1", Amount: 500000, Status: "failed"}},
+			deposits: []models.Transaction{deposit("TXN_1", models.TransactionStatusFailed, 500000, 0)},
+		},
+		{
+			name:        "credited deposit missing from the file",
+			lines:       []SettlementLine{{Line: 2, Reference: "TXN_1", Amount: 500000}},
+			deposits:    []models.Transaction{deposit("TXN_1", success, 500000, 0)},
+			credited:    []models.Transaction{deposit("TXN_1", success, 500000, 0), deposit("TXN_2", success, 100000, 0)},
+			wantIssues:  []Issue{{Kind: IssueUnsettled, Reference: "TXN_2", Expected: 100000, Detail: "credited, but missing from the file"}},
+			wantMatched: 1,
+			wantSettled: 500000,
+		},
+		{
+			name:        "no period skips the check for missing deposits",
+			lines:       []SettlementLine{{Line: 2, Reference: "TXN_1", Amount: 500000}},
+			deposits:    []models.Transaction{deposit("TXN_1", success, 500000, 0)},
+			credited:    []models.Transaction{deposit("TXN_2", success, 100000, 0)},
+			noPeriod:    true,
+			wantMatched: 1,
+			wantSettled: 500000,
+		},
+		{
+			name: "duplicate reference is counted once",
+			lines: []SettlementLine{
+				{Line: 2, Reference: "TXN_1", Amount: 500000},
+				{Line: 3, Reference: "TXN_1", Amount: 500000},
+			},
+			deposits:    []models.Transaction{deposit("TXN_1", success, 500000, 0)},
+			credited:    []models.Transaction{deposit("TXN_1", success, 500000, 0)},
+			wantIssues:  []Issue{{Kind: IssueDuplicateInFile, Reference: "TXN_1", Line: 3, Detail: "also on line 2"}},
+			wantMatched: 1,
+			wantSettled: 500000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deposits := make(map[string]models.Transaction, len(tt.deposits))
+			for _, d := range tt.deposits {
+				deposits[d.Reference] = d
+			}
+			reportFrom, reportTo := from, to
+			if tt.noPeriod {
+				reportFrom, reportTo = time.Time{}, time.Time{}
+			}
+
+			report := buildReport(tt.lines, deposits, tt.credited, reportFrom, reportTo)
+
+			wantIssues := tt.wantIssues
+			if wantIssues == nil {
+				wantIssues = []Issue{}
+			}
+			if !reflect.DeepEqual(report.Issues, wantIssues) {
+				t.Errorf("issues = %+v, want %+v", report.Issues, wantIssues)
+			}
+			wantCounts := make(map[IssueKind]int)
+			for _, issue := range wantIssues {
+				wantCounts[issue.Kind]++
+			}
+			if !reflect.DeepEqual(report.Counts, wantCounts) {
+				t.Errorf("counts = %v, want %v", report.Counts, wantCounts)
+			}
+			if report.Lines != len(tt.lines) || report.Matched != tt.wantMatched {
+				t.Errorf("lines, matched = %d, %d; want %d, %d", report.Lines, report.Matched, len(tt.lines), tt.wantMatched)
+			}
+			if report.SettledAmount != tt.wantSettled || report.Fees != tt.wantFees || report.NetSettled != tt.wantSettled-tt.wantFees {
+				t.Errorf("settled, fees, net = %d, %d, %d; want %d, %d, %d",
+					report.SettledAmount, report.Fees, report.NetSettled, tt.wantSettled, tt.wantFees, tt.wantSettled-tt.wantFees)
+			}
+			if (report.From == nil) != tt.noPeriod {
+				t.Errorf("report period = %v to %v, want one only when a period is given", report.From, report.To)
+			}
+		})
+	}
+}
+
+func TestFilePeriod(t *testing.T) {
+	at := func(value string) *time.Time {
+		parsed, _ := time.Parse(time.RFC3339, value)
+		return &parsed
+	}
+	day := func(value string) time.Time {
+		parsed, _ := time.Parse("2006-01-02", value)
+		return parsed
+	}
+
+	tests := []struct {
+		name     string
+		lines    []SettlementLine
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{
+			name:     "whole days from the first to the last line",
+			lines:    []SettlementLine{{PaidAt: at("2026-03-15T18:00:00Z")}, {}, {PaidAt: at("2026-03-14T09:26:53Z")}},
+			wantFrom: day("2026-03-14"),
+			wantTo:   day("2026-03-16"),
+		},
+		{
+			name:  "no dates",
+			lines: []SettlementLine{{Reference: "TXN_1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := filePeriod(tt.lines)
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+				t.Errorf("filePeriod() = %v, %v; want %v, %v", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Package reconcile checks Paystack settlement and transaction export files
+// against the deposits recorded in the wallet service.
+package reconcile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SettlementLine is one transaction from a Paystack export. Amounts are in
+// kobo.
+type SettlementLine struct {
+	Line      int // Line number in the file, for reporting
+	Reference string
+	Amount    int64
+	Fees      int64
+	Currency  string
+	Status    string // Empty when the export has no status column
+	Channel   string
+	PaidAt    *time.Time
+}
+
+// Settled reports whether Paystack collected the money for this line.
+func (l SettlementLine) Settled() bool {
+	return l.Status == "" || l.Status == "success"
+}
+
+// Column names used by the different Paystack exports, after lowercasing
+// and replacing spaces with underscores.
+var columnAliases = map[string][]string{
+	"reference": {"reference", "transaction_reference", "ref"},
+	"amount":    {"amount", "amount_paid", "transaction_amount"},
+	"fees":      {"fees", "fee", "paystack_fees", "charges"},
+	"currency":  {"currency"},
+	"status":    {"status"},
+	"channel":   {"channel", "payment_channel"},
+	"paid_at":   {"paid_at", "transaction_date", "date", "created_at"},
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"02/01/2006 15:04:05",
+	"02/01/2006",
+	"Jan 2, 2006 15:04",
+	"Jan 2, 2006",
+}
+
+// ParseSettlementCSV reads a Paystack export. Only the reference and amount
+// columns are required. Paystack exports amounts in major units (naira);
+// set amountsInKobo for files that already hold kobo.
+func ParseSettlementCSV(r io.Reader, amountsInKobo bool) ([]SettlementLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := mapColumns(header)
+	for _, required := range []string{"reference", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	var lines []SettlementLine
+	for lineNumber := 2; ; lineNumber++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			index, ok := columns[name]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		reference := field("reference")
+		if reference == "" {
+			// Exports end with blank and total rows
+			continue
+		}
+
+		amount, err := parseAmount(field("amount"), amountsInKobo)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount: %w", lineNumber, err)
+		}
+
+		var fees int64
+		if value := field("fees"); value != "" {
+			if fees, err = parseAmount(value, amountsInKobo); err != nil {
+				return nil, fmt.Errorf("line %d: invalid fees: %w", lineNumber, err)
+			}
+		}
+
+		var paidAt *time.Time
+		if value := field("paid_at"); value != "" {
+			parsed, err := parseDate(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			paidAt = &parsed
+		}
+
+		lines = append(lines, SettlementLine{
+			Line:      lineNumber,
+			Reference: reference,
+			Amount:    amount,
+			Fees:      fees,
+			Currency:  strings.ToUpper(field("currency")),
+			Status:    strings.ToLower(field("status")),
+			Channel:   field("channel"),
+			PaidAt:    paidAt,
+		})
+	}
+
+	return lines, nil
+}
+
+func mapColumns(header []string) map[string]int {
+	normalized := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		name = strings.ReplaceAll(name, " ", "_")
+		if _, exists := normalized[name]; !exists {
+			normalized[name] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for column, aliases := range columnAliases {
+		for _, alias := range aliases {
+			if index, ok := normalized[alias]; ok {
+				columns[column] = index
+				break
+			}
+		}
+	}
+	return columns
+}
+
+func parseAmount(value string, inKobo bool) (int64, error) {
+	value = strings.NewReplacer(",", "", "₦", "", "NGN", "", " ", "").Replace(value)
+	if inKobo {
+		return strconv.ParseInt(value, 10, 64)
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(math.Round(amount * 100)), nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
@@ -0,0 +1,114 @@
+package reconcile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSettlementCSV(t *testing.T) {
+	paidAt := func(value string) *time.Time {
+		parsed, err := parseDate(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &parsed
+	}
+
+	tests := []struct {
+		name          string
+		data          string
+		amountsInKobo bool
+		want          []SettlementLine
+		wantErr       string
+	}{
+		{
+			name: "transaction export in naira",
+			data: "Reference,Amount,Fees,Currency,Status,Channel,Paid At\n" +
+				"TXN_1,5000.00,75.00,ngn,Success,card,2026-03-14 09:26:53\n" +
+				"TXN_2,\"1,250.50\",18.76,NGN,failed,bank,2026-03-14\n",
+			want: []SettlementLine{
+				{Line: 2, Reference: "TXN_1", Amount: 500000, Fees: 7500, Currency: "NGN", Status: "success", Channel: "card", PaidAt: paidAt("2026-03-14 09:26:53")},
+				{Line: 3, Reference: "TXN_2", Amount: 125050, Fees: 1876, Currency: "NGN", Status: "failed", Channel: "bank", PaidAt: paidAt("2026-03-14")},
+			},
+		},
+		{
+			name: "settlement export column aliases",
+			data: "\ufeffTransaction Reference, Amount Paid ,Paystack Fees,Payment Channel,Transaction Date\n" +
+				"TXN_1,₦5000,75,card,14/03/2026\n",
+			want: []SettlementLine{
+				{Line: 2, Reference: "TXN_1", Amount: 500000, Fees: 7500, Channel: "card", PaidAt: paidAt("14/03/2026")},
+			},
+		},
+		{
+			name: "first matching alias wins",
+			data: "ref,reference,amount,transaction_amount\n" +
+				"ignored,TXN_1,10,99\n",
+			want: []SettlementLine{
+				{Line: 2, Reference: "TXN_1", Amount: 1000},
+			},
+		},
+		{
+			name:          "amounts in kobo",
+			data:          "reference,amount,fees\nTXN_1,\"500,000\",7500\n",
+			amountsInKobo: true,
+			want: []SettlementLine{
+				{Line: 2, Reference: "TXN_1", Amount: 500000, Fees: 7500},
+			},
+		},
+		{
+			name:          "fractional kobo",
+			data:          "reference,amount\nTXN_1,5000.50\n",
+			amountsInKobo: true,
+			wantErr:       "line 2: invalid amount",
+		},
+		{
+			name: "naira rounded to the nearest kobo",
+			data: "reference,amount\nTXN_1,19.999\nTXN_2,0.1\n",
+			want: []SettlementLine{
+				{Line: 2, Reference: "TXN_1", Amount: 2000},
+				{Line: 3, Reference: "TXN_2", Amount: 10},
+			},
+		},
+		{
+			name: "blank and total rows are skipped",
+			data: "reference,amount\nTXN_1,10\n,,\n,20\n",
+			want: []SettlementLine{
+				{Line: 2, Reference: "TXN_1", Amount: 1000},
+			},
+		},
+		{
+			name: "duplicate references are kept",
+			data: "reference,amount\nTXN_1,10\nTXN_1,10\n",
+			want: []SettlementLine{
+				{Line: 2, Reference: "TXN_1", Amount: 1000},
+				{Line: 3, Reference: "TXN_1", Amount: 1000},
+			},
+		},
+		{name: "empty file", data: "", wantErr: "file is empty"},
+		{name: "missing reference column", data: "id,amount\n1,10\n", wantErr: "missing reference column"},
+		{name: "missing amount column", data: "reference,total\nTXN_1,10\n", wantErr: "missing amount column"},
+		{name: "invalid amount", data: "reference,amount\nTXN_1,ten\n", wantErr: "line 2: invalid amount"},
+		{name: "invalid fees", data: "reference,amount,fees\nTXN_1,10,free\n", wantErr: "line 2: invalid fees"},
+		{name: "invalid date", data: "reference,amount,paid_at\nTXN_1,10,yesterday\n", wantErr: `line 2: unrecognized date "yesterday"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := ParseSettlementCSV(strings.NewReader(tt.data), tt.amountsInKobo)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseSettlementCSV() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSettlementCSV() error = %v", err)
+			}
+			if !reflect.DeepEqual(lines, tt.want) {
+				t.Errorf("ParseSettlementCSV() = %+v, want %+v", lines, tt.want)
+			}
+		})
+	}
+}
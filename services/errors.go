@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCircuitOpen is returned without calling the provider while its circuit
+// breaker is open after repeated failures.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// NetworkError means the provider could not be reached or did not answer in
+// time. The request may or may not have been processed.
+type NetworkError struct {
+	Provider string
+	Err      error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("%s network error: %v", e.Provider, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError means the provider rejected the request (4xx). Retrying the
+// same request will not help.
+type ValidationError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s rejected request (%d): %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// ProviderError means the provider failed on its side (5xx).
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s error (%d): %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// IsUnavailable reports whether err means the provider is down or unreachable,
+// as opposed to it rejecting the request.
+func IsUnavailable(err error) bool {
+	var networkErr *NetworkError
+	var providerErr *ProviderError
+	return errors.As(err, &networkErr) || errors.As(err, &providerErr)
+}
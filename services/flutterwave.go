@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-service/config"
+)
+
+const flutterwaveBaseURL = "https://api.flutterwave.com/v3"
+
+// FlutterwaveService talks to Flutterwave's v3 API. Flutterwave works in major
+// currency units, so amounts are converted to and from kobo at the boundary.
+type FlutterwaveService struct {
+	client *apiClient
+}
+
+type flutterwaveTransaction struct {
+	ID                int64      `json:"id"`
+	TxRef             string     `json:"tx_ref"`
+	Amount            float64    `json:"amount"`
+	Currency          string     `json:"currency"`
+	Status            string     `json:"status"`
+	PaymentType       string     `json:"payment_type"`
+	ProcessorResponse string     `json:"processor_response"`
+	AppFee            float64    `json:"app_fee"`
+	CreatedAt         *time.Time `json:"created_at"`
+}
+
+type flutterwaveResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type flutterwaveWebhookEvent struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+func NewFlutterwaveService() *FlutterwaveService {
+	return &FlutterwaveService{client: newAPIClient("flutterwave")}
+}
+
+func (fs *FlutterwaveService) Name() string {
+	return "flutterwave"
+}
+
+func (fs *FlutterwaveService) SupportsCurrency(currency string) bool {
+	return containsCurrency([]string{"NGN", "GHS", "KES", "ZAR", "USD", "EUR", "GBP"}, currency)
+}
+
+func (fs *FlutterwaveService) InitializeCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error) {
+	redirectURL := req.CallbackURL
+	if redirectURL == "" {
+		redirectURL = config.AppConfig.FrontendURL
+	}
+
+	payload := map[string]interface{}{
+		"tx_ref":       req.Reference,
+		"amount":       toMajorUnits(req.Amount),
+		"currency":     req.Currency,
+		"redirect_url": redirectURL,
+		"customer": map[string]string{
+			"email": req.Email,
+		},
+	}
+	if options := flutterwavePaymentOptions(req.Channels); options != "" {
+		payload["payment_options"] = options
+	}
+	if len(req.Metadata) > 0 {
+		payload["meta"] = req.Metadata
+	}
+
+	var data struct {
+		Link string `json:"link"`
+	}
+	if err := fs.do(ctx, "POST", "/payments", payload, &data); err != nil {
+		return nil, err
+	}
+
+	return &Checkout{
+		AuthorizationURL: data.Link,
+		Reference:        req.Reference,
+	}, nil
+}
+
+func (fs *FlutterwaveService) VerifyCharge(ctx context.Context, reference string) (*Charge, error) {
+	transaction, err := fs.verify(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	return fs.toCharge(*transaction), nil
+}
+
+// Refund refunds a charge. Flutterwave refunds by its own transaction id, so
+// the reference is looked up first.
+func (fs *FlutterwaveService) Refund(ctx context.Context, reference string, amount int64) (*Refund, error) {
+	transaction, err := fs.verify(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := map[string]interface{}{
+		"amount": toMajorUnits(amount),
+	}
+
+	var data struct {
+		ID     int64  `json:"id"`
+		Status string `json:"status"`
+	}
+	path := fmt.Sprintf("/transactions/%d/refund", transaction.ID)
+	if err := fs.do(ctx, "POST", path, payload, &data); err != nil {
+		return nil, err
+	}
+
+	status := RefundStatusPending
+	switch data.Status {
+	case "completed":
+		status = RefundStatusProcessed
+	case "failed":
+		status = RefundStatusFailed
+	}
+
+	return &Refund{
+		ID:        strconv.FormatInt(data.ID, 10),
+		Reference: reference,
+		Amount:    amount,
+		Status:    status,
+	}, nil
+}
+
+func (fs *FlutterwaveService) Payout(ctx context.Context, req PayoutRequest) (*Payout, error) {
+	payload := map[string]interface{}{
+		"account_bank":   req.BankCode,
+		"account_number": req.AccountNumber,
+		"amount":         toMajorUnits(req.Amount),
+		"currency":       req.Currency,
+		"narration":      req.Narration,
+		"reference":      req.Reference,
+	}
+
+	var data struct {
+		ID        int64  `json:"id"`
+		Reference string `json:"reference"`
+		Status    string `json:"status"`
+	}
+	if err := fs.do(ctx, "POST", "/transfers", payload, &data); err != nil {
+		return nil, err
+	}
+
+	status := PayoutStatusPending
+	switch data.Status {
+	case "SUCCESSFUL":
+		status = PayoutStatusSuccess
+	case "FAILED":
+		status = PayoutStatusFailed
+	}
+
+	return &Payout{
+		ID:        strconv.FormatInt(data.ID, 10),
+		Reference: data.Reference,
+		Amount:    req.Amount,
+		Status:    status,
+	}, nil
+}
+
+// VerifyWebhook compares the verif-hash header against the secret hash
+// configured on the Flutterwave dashboard.
+func (fs *FlutterwaveService) VerifyWebhook(header http.Header, body []byte) bool {
+	hash := header.Get("verif-hash")
+	expected := config.AppConfig.FlutterwaveWebhookHash
+	if hash == "" || expected == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(expected)) == 1
+}
+
+func (fs *FlutterwaveService) ParseWebhook(body []byte) (*WebhookEvent, error) {
+	var event flutterwaveWebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+
+	result := &WebhookEvent{Type: event.Event, Data: event.Data}
+
+	if event.Event == "charge.completed" {
+		var data flutterwaveTransaction
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, err
+		}
+		charge := fs.toCharge(data)
+		switch charge.Status {
+		case ChargeStatusSuccess:
+			result.Type = EventChargeSuccess
+		case ChargeStatusFailed:
+			result.Type = EventChargeFailed
+		}
+		result.Charge = charge
+	}
+
+	return result, nil
+}
+
+func (fs *FlutterwaveService) verify(ctx context.Context, reference string) (*flutterwaveTransaction, error) {
+	var transaction flutterwaveTransaction
+	path := "/transactions/verify_by_reference?tx_ref=" + url.QueryEscape(reference)
+	if err := fs.do(ctx, "GET", path, nil, &transaction); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func (fs *FlutterwaveService) toCharge(data flutterwaveTransaction) *Charge {
+	status := ChargeStatusPending
+	switch data.Status {
+	case "successful":
+		status = ChargeStatusSuccess
+	case "failed":
+		status = ChargeStatusFailed
+	}
+
+	return &Charge{
+		Provider:        fs.Name(),
+		Reference:       data.TxRef,
+		Amount:          toMinorUnits(data.Amount),
+		Currency:        data.Currency,
+		Status:          status,
+		Channel:         data.PaymentType,
+		GatewayResponse: data.ProcessorResponse,
+		Fees:            toMinorUnits(data.AppFee),
+		PaidAt:          data.CreatedAt,
+	}
+}
+
+// do sends an authenticated request to the Flutterwave API and decodes the
+// data field of the response into out.
+func (fs *FlutterwaveService) do(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+config.AppConfig.FlutterwaveSecretKey)
+
+	body, err := fs.client.do(ctx, method, flutterwaveBaseURL+path, header, payload, flutterwaveErrorMessage)
+	if err != nil {
+		return err
+	}
+
+	var result flutterwaveResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return err
+	}
+
+	if result.Status != "success" {
+		return &ValidationError{Provider: fs.Name(), StatusCode: http.StatusOK, Message: result.Message}
+	}
+
+	return json.Unmarshal(result.Data, out)
+}
+
+// flutterwaveChannels maps checkout channels to Flutterwave payment options.
+var flutterwaveChannels = map[string]string{
+	"card":          "card",
+	"bank":          "account",
+	"ussd":          "ussd",
+	"qr":            "nqr",
+	"bank_transfer": "banktransfer",
+	"apple_pay":     "applepay",
+}
+
+// flutterwavePaymentOptions returns the payment_options for channels, or ""
+// to offer everything when none of them exist on Flutterwave.
+func flutterwavePaymentOptions(channels []string) string {
+	var options []string
+	for _, channel := range channels {
+		if option, ok := flutterwaveChannels[channel]; ok {
+			options = append(options, option)
+		}
+	}
+	return strings.Join(options, ",")
+}
+
+func flutterwaveErrorMessage(body []byte) string {
+	var result flutterwaveResponse
+	if err := json.Unmarshal(body, &result); err != nil || result.Message == "" {
+		return "unexpected response"
+	}
+	return result.Message
+}
+
+func toMajorUnits(amount int64) float64 {
+	return float64(amount) / 100
+}
+
+func toMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	providerRequestTimeout = 15 * time.Second
+	maxRetryAttempts       = 3
+	retryBaseDelay         = 200 * time.Millisecond
+	breakerFailureLimit    = 5
+	breakerCooldown        = 30 * time.Second
+)
+
+// sharedTransport is reused by every provider so connections are pooled.
+var sharedTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	TLSHandshakeTimeout:   5 * time.Second,
+	ResponseHeaderTimeout: 10 * time.Second,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   20,
+	IdleConnTimeout:       90 * time.Second,
+}
+
+// apiClient is the HTTP client payment providers share. It applies timeouts,
+// retries idempotent (GET) requests with jittered exponential backoff, trips a
+// circuit breaker after repeated failures and turns failures into
+// NetworkError, ValidationError or ProviderError.
+type apiClient struct {
+	provider string
+	http     *http.Client
+	breaker  *circuitBreaker
+}
+
+func newAPIClient(provider string) *apiClient {
+	return &apiClient{
+		provider: provider,
+		http: &http.Client{
+			Transport: sharedTransport,
+			Timeout:   providerRequestTimeout,
+		},
+		breaker: &circuitBreaker{},
+	}
+}
+
+// do sends the request and returns the response body of a 2xx response.
+// errorMessage extracts the provider's error message from a failed response.
+func (ac *apiClient) do(ctx context.Context, method, url string, header http.Header, payload interface{}, errorMessage func([]byte) string) ([]byte, error) {
+	var jsonData []byte
+	if payload != nil {
+		var err error
+		if jsonData, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+
+	attempts := 1
+	if method == http.MethodGet {
+		attempts = maxRetryAttempts
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepWithJitter(ctx, attempt); err != nil {
+				return nil, &NetworkError{Provider: ac.provider, Err: err}
+			}
+		}
+
+		body, retryable, err := ac.attempt(ctx, method, url, header, jsonData, errorMessage)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+		if !retryable {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (ac *apiClient) attempt(ctx context.Context, method, url string, header http.Header, jsonData []byte, errorMessage func([]byte) string) ([]byte, bool, error) {
+	var reqBody io.Reader
+	if jsonData != nil {
+		reqBody = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, false, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if jsonData != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	allowed, trial := ac.breaker.allow()
+	if !allowed {
+		return nil, false, &NetworkError{Provider: ac.provider, Err: ErrCircuitOpen}
+	}
+
+	resp, err := ac.http.Do(req)
+	if err != nil {
+		// A cancelled caller is not the provider's fault and is not worth retrying
+		if ctx.Err() != nil {
+			ac.breaker.release(trial)
+			return nil, false, &NetworkError{Provider: ac.provider, Err: err}
+		}
+		ac.breaker.failure()
+		return nil, true, &NetworkError{Provider: ac.provider, Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			ac.breaker.release(trial)
+			return nil, false, &NetworkError{Provider: ac.provider, Err: err}
+		}
+		ac.breaker.failure()
+		return nil, true, &NetworkError{Provider: ac.provider, Err: err}
+	}
+
+	switch {
+	case resp.StatusCode >= 500:
+		ac.breaker.failure()
+		return nil, true, &ProviderError{Provider: ac.provider, StatusCode: resp.StatusCode, Message: errorMessage(body)}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		ac.breaker.success()
+		return nil, true, &ProviderError{Provider: ac.provider, StatusCode: resp.StatusCode, Message: errorMessage(body)}
+	case resp.StatusCode >= 400:
+		ac.breaker.success()
+		return nil, false, &ValidationError{Provider: ac.provider, StatusCode: resp.StatusCode, Message: errorMessage(body)}
+	}
+
+	ac.breaker.success()
+	return body, false, nil
+}
+
+// sleepWithJitter waits a random duration up to retryBaseDelay * 2^attempt
+// ("full jitter"), returning early if ctx is done.
+func sleepWithJitter(ctx context.Context, attempt int) error {
+	maxDelay := retryBaseDelay << uint(attempt)
+	delay := time.Duration(rand.Int63n(int64(maxDelay)))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// circuitBreaker opens after breakerFailureLimit consecutive failures and
+// rejects calls until breakerCooldown has passed, then lets a single trial
+// call through to decide whether to close again.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trialSent bool
+}
+
+// allow reports whether a call may go through, and whether it is the trial
+// call sent once the breaker has cooled down.
+func (cb *circuitBreaker) allow() (allowed, trial bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.failures < breakerFailureLimit {
+		return true, false
+	}
+	if time.Now().Before(cb.openUntil) || cb.trialSent {
+		return false, false
+	}
+	cb.trialSent = true
+	return true, true
+}
+
+func (cb *circuitBreaker) success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+	cb.trialSent = false
+}
+
+// release gives up a call that ended without saying anything about the
+// provider, such as one its caller cancelled. If it was the trial, another
+// trial can be sent; any other call leaves an outstanding trial alone.
+func (cb *circuitBreaker) release(trial bool) {
+	if !trial {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.trialSent = false
+}
+
+func (cb *circuitBreaker) failure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	cb.trialSent = false
+	if cb.failures >= breakerFailureLimit {
+		cb.openUntil = time.Now().Add(breakerCooldown)
+	}
+}
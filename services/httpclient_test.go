@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	// Each step records an outcome, lets the open period pass ("cooldown")
+	// or asks whether a call may go through and whether it is the trial.
+	// A release gives up the trial when trial is set, another call otherwise.
+	type step struct {
+		op    string
+		allow bool
+		trial bool
+	}
+	failures := func(n int, then ...step) []step {
+		steps := make([]step, n, n+len(then))
+		for i := range steps {
+			steps[i] = step{op: "failure"}
+		}
+		return append(steps, then...)
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name:  "closed until the failure limit",
+			steps: failures(breakerFailureLimit-1, step{op: "allow", allow: true}),
+		},
+		{
+			name:  "opens at the failure limit",
+			steps: failures(breakerFailureLimit, step{op: "allow", allow: false}),
+		},
+		{
+			name: "success resets the failure count",
+			steps: append(failures(breakerFailureLimit-1, step{op: "success"}),
+				failures(breakerFailureLimit-1, step{op: "allow", allow: true})...),
+		},
+		{
+			name: "one trial after the cooldown",
+			steps: failures(breakerFailureLimit,
+				step{op: "cooldown"},
+				step{op: "allow", allow: true, trial: true},
+				step{op: "allow", allow: false},
+			),
+		},
+		{
+			name: "successful trial closes",
+			steps: failures(breakerFailureLimit,
+				step{op: "cooldown"},
+				step{op: "allow", allow: true, trial: true},
+				step{op: "success"},
+				step{op: "allow", allow: true},
+				step{op: "allow", allow: true},
+			),
+		},
+		{
+			name: "failed trial opens again",
+			steps: failures(breakerFailureLimit,
+				step{op: "cooldown"},
+				step{op: "allow", allow: true, trial: true},
+				step{op: "failure"},
+				step{op: "allow", allow: false},
+			),
+		},
+		{
+			name: "released trial can be sent again",
+			steps: failures(breakerFailureLimit,
+				step{op: "cooldown"},
+				step{op: "allow", allow: true, trial: true},
+				step{op: "release", trial: true},
+				step{op: "allow", allow: true, trial: true},
+				step{op: "allow", allow: false},
+			),
+		},
+		{
+			name: "released call that was not the trial keeps the trial",
+			steps: failures(breakerFailureLimit,
+				step{op: "cooldown"},
+				step{op: "allow", allow: true, trial: true},
+				step{op: "release", trial: false},
+				step{op: "allow", allow: false},
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := &circuitBreaker{}
+			for i, s := range tt.steps {
+				switch s.op {
+				case "failure":
+					cb.failure()
+				case "success":
+					cb.success()
+				case "release":
+					cb.release(s.trial)
+				case "cooldown":
+					cb.openUntil = time.Now().Add(-time.Second)
+				case "allow":
+					if allowed, trial := cb.allow(); allowed != s.allow || trial != s.trial {
+						t.Fatalf("step %d: allow() = (%v, %v), want (%v, %v)", i, allowed, trial, s.allow, s.trial)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAPIClientBreaker(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ac := newAPIClient("test")
+	noMessage := func([]byte) string { return "" }
+
+	for i := 0; i < breakerFailureLimit; i++ {
+		_, err := ac.do(context.Background(), http.MethodPost, server.URL, http.Header{}, nil, noMessage)
+		var providerErr *ProviderError
+		if !errors.As(err, &providerErr) {
+			t.Fatalf("call %d: err = %v, want ProviderError", i, err)
+		}
+	}
+
+	_, err := ac.do(context.Background(), http.MethodPost, server.URL, http.Header{}, nil, noMessage)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if n := calls.Load(); n != breakerFailureLimit {
+		t.Errorf("provider called %d times, want %d", n, breakerFailureLimit)
+	}
+}
+
+func TestAPIClientCancelledCaller(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ac := newAPIClient("test")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < breakerFailureLimit; i++ {
+		_, err := ac.do(ctx, http.MethodPost, server.URL, http.Header{}, nil, func([]byte) string { return "" })
+		var networkErr *NetworkError
+		if !errors.As(err, &networkErr) {
+			t.Fatalf("call %d: err = %v, want NetworkError", i, err)
+		}
+	}
+
+	if allowed, _ := ac.breaker.allow(); !allowed {
+		t.Error("breaker opened for calls the caller cancelled")
+	}
+}
@@ -1,20 +1,31 @@
 package services
 
 import (
-	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 	"wallet-service/config"
 )
 
-type PaystackService struct{}
+type PaystackService struct {
+	client *apiClient
+}
 
 type InitializeTransactionRequest struct {
-	Email     string `json:"email"`
-	Amount    int64  `json:"amount"` // In kobo
-	Reference string `json:"reference"`
+	Email       string                 `json:"email"`
+	Amount      int64                  `json:"amount"` // In kobo
+	Currency    string                 `json:"currency,omitempty"`
+	Reference   string                 `json:"reference"`
+	CallbackURL string                 `json:"callback_url,omitempty"`
+	Channels    []string               `json:"channels,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type InitializeTransactionResponse struct {
@@ -27,96 +38,855 @@ type InitializeTransactionResponse struct {
 	} `json:"data"`
 }
 
+type PaystackTransaction struct {
+	Reference       string                 `json:"reference"`
+	Amount          int64                  `json:"amount"` // In kobo
+	Currency        string                 `json:"currency"`
+	Status          string                 `json:"status"`
+	Channel         string                 `json:"channel"`
+	GatewayResponse string                 `json:"gateway_response"`
+	DisplayText     string                 `json:"display_text"` // Set on send_otp / pay_offline challenges
+	Fees            int64                  `json:"fees"`         // In kobo
+	PaidAt          *time.Time             `json:"paid_at"`
+	Authorization   *PaystackAuthorization `json:"authorization"`
+	Customer        struct {
+		Email        string `json:"email"`
+		CustomerCode string `json:"customer_code"`
+	} `json:"customer"`
+}
+
+type PaystackAuthorization struct {
+	AuthorizationCode string `json:"authorization_code"`
+	Last4             string `json:"last4"`
+	ExpMonth          string `json:"exp_month"`
+	ExpYear           string `json:"exp_year"`
+	Channel           string `json:"channel"`
+	CardType          string `json:"card_type"`
+	Bank              string `json:"bank"`
+	Brand             string `json:"brand"`
+	Reusable          bool   `json:"reusable"`
+	Signature         string `json:"signature"`
+
+	// Set for bank transfers into a dedicated virtual account
+	ReceiverBankAccountNumber string `json:"receiver_bank_account_number"`
+	SenderName                string `json:"sender_name"`
+	SenderBank                string `json:"sender_bank"`
+}
+
+type AuthorizationChargeRequest struct {
+	AuthorizationCode string `json:"authorization_code"`
+	Email             string `json:"email"`
+	Amount            int64  `json:"amount"` // In kobo
+	Currency          string `json:"currency,omitempty"`
+	Reference         string `json:"reference"`
+}
+
+// AuthorizationChargeResult is the outcome of charging a saved card. Status
+// is Paystack's raw status: "success" and "failed" are final, "send_otp"
+// needs SubmitOTP, and "pay_offline" is completed by the customer on their
+// phone and reported by webhook.
+type AuthorizationChargeResult struct {
+	Status      string
+	DisplayText string
+	Charge      *Charge
+}
+
 type VerifyTransactionResponse struct {
+	Status  bool                `json:"status"`
+	Message string              `json:"message"`
+	Data    PaystackTransaction `json:"data"`
+}
+
+type paystackRefundResponse struct {
 	Status  bool   `json:"status"`
 	Message string `json:"message"`
 	Data    struct {
+		ID          int64  `json:"id"`
+		Amount      int64  `json:"amount"`
+		Status      string `json:"status"`
+		Transaction struct {
+			Reference string `json:"reference"`
+		} `json:"transaction"`
+	} `json:"data"`
+}
+
+// paystackRefundEvent is the data of refund.* webhooks. The refund id has
+// been sent both as a number and as a string.
+type paystackRefundEvent struct {
+	ID                   json.Number `json:"id"`
+	TransactionReference string      `json:"transaction_reference"`
+	Amount               int64       `json:"amount"`
+	Status               string      `json:"status"`
+}
+
+// paystackDispute is a dispute as Paystack returns it from the API and in
+// charge.dispute.* webhooks. Resolution is merchant-accepted or
+// auto-accepted (the customer is refunded) or declined (the dispute is
+// rejected in our favour).
+type paystackDispute struct {
+	ID           json.Number `json:"id"`
+	RefundAmount int64       `json:"refund_amount"` // In kobo
+	Currency     string      `json:"currency"`
+	Status       string      `json:"status"`
+	Resolution   string      `json:"resolution"`
+	Category     string      `json:"category"`
+	DueAt        *time.Time  `json:"dueAt"`
+	Transaction  struct {
 		Reference string `json:"reference"`
 		Amount    int64  `json:"amount"`
-		Status    string `json:"status"`
+		Currency  string `json:"currency"`
+	} `json:"transaction"`
+}
+
+type paystackDisputeResponse struct {
+	Status  bool            `json:"status"`
+	Message string          `json:"message"`
+	Data    paystackDispute `json:"data"`
+}
+
+// DisputeEvidence is what we tell Paystack about the service the customer
+// paid for when contesting a dispute. DeliveryDate is YYYY-MM-DD.
+type DisputeEvidence struct {
+	CustomerEmail   string `json:"customer_email"`
+	CustomerName    string `json:"customer_name"`
+	CustomerPhone   string `json:"customer_phone"`
+	ServiceDetails  string `json:"service_details"`
+	DeliveryAddress string `json:"delivery_address,omitempty"`
+	DeliveryDate    string `json:"delivery_date,omitempty"`
+}
+
+// Paystack's dispute resolutions. Only the first two can be sent; Paystack
+// auto-accepts disputes left unanswered past their due date.
+const (
+	PaystackDisputeAccept       = "merchant-accepted" // Refund the customer
+	PaystackDisputeDecline      = "declined"          // Contest the dispute with the evidence submitted
+	PaystackDisputeAutoAccepted = "auto-accepted"     // Accepted for us, the customer is refunded
+)
+
+type DisputeResolutionRequest struct {
+	Resolution       string `json:"resolution"`
+	Message          string `json:"message"`
+	RefundAmount     int64  `json:"refund_amount"` // In kobo
+	UploadedFilename string `json:"uploaded_filename,omitempty"`
+	Evidence         int64  `json:"evidence,omitempty"` // Evidence id, required to decline
+}
+
+// paystackTransferEvent is the data of transfer.* webhooks.
+type paystackTransferEvent struct {
+	Reference    string `json:"reference"`
+	TransferCode string `json:"transfer_code"`
+	Amount       int64  `json:"amount"`
+	Status       string `json:"status"`
+}
+
+type paystackRecipientResponse struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		RecipientCode string `json:"recipient_code"`
+	} `json:"data"`
+}
+
+type paystackTransferResponse struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		TransferCode string `json:"transfer_code"`
+		Reference    string `json:"reference"`
+		Status       string `json:"status"`
+	} `json:"data"`
+}
+
+type paystackCustomerResponse struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		CustomerCode string `json:"customer_code"`
+	} `json:"data"`
+}
+
+type paystackDedicatedAccountResponse struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		ID            int64  `json:"id"`
+		AccountName   string `json:"account_name"`
+		AccountNumber string `json:"account_number"`
+		Currency      string `json:"currency"`
+		Active        bool   `json:"active"`
+		Bank          struct {
+			Name string `json:"name"`
+			Slug string `json:"slug"`
+		} `json:"bank"`
 	} `json:"data"`
 }
 
+type DedicatedAccountRequest struct {
+	Email         string
+	FirstName     string
+	LastName      string
+	Phone         string
+	PreferredBank string
+}
+
+// DedicatedAccount is a bank account number assigned to a single customer.
+// Transfers into it arrive as charge.success webhooks on the
+// dedicated_nuban channel.
+type DedicatedAccount struct {
+	ID            string
+	CustomerCode  string
+	AccountName   string
+	AccountNumber string
+	BankName      string
+	BankSlug      string
+	Currency      string
+	Active        bool
+}
+
+type paystackWebhookEvent struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
 func NewPaystackService() *PaystackService {
-	return &PaystackService{}
+	return &PaystackService{client: newAPIClient("paystack")}
+}
+
+func (ps *PaystackService) Name() string {
+	return "paystack"
 }
 
-func (ps *PaystackService) InitializeTransaction(email string, amount int64, reference string) (*InitializeTransactionResponse, error) {
-	url := "https://api.paystack.co/transaction/initialize"
+func (ps *PaystackService) SupportsCurrency(currency string) bool {
+	return containsCurrency([]string{"NGN", "GHS", "ZAR", "KES", "USD"}, currency)
+}
 
-	payload := InitializeTransactionRequest{
-		Email:     email,
-		Amount:    amount,
-		Reference: reference,
+func (ps *PaystackService) InitializeTransaction(ctx context.Context, payload InitializeTransactionRequest) (*InitializeTransactionResponse, error) {
+	var result InitializeTransactionResponse
+	if err := ps.do(ctx, "POST", "/transaction/initialize", payload, &result); err != nil {
+		return nil, err
 	}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
+	if !result.Status {
+		return nil, ps.rejected(result.Message)
+	}
+
+	return &result, nil
+}
+
+func (ps *PaystackService) VerifyTransaction(ctx context.Context, reference string) (*VerifyTransactionResponse, error) {
+	var result VerifyTransactionResponse
+	if err := ps.do(ctx, "GET", "/transaction/verify/"+reference, nil, &result); err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if !result.Status {
+		return nil, ps.rejected(result.Message)
+	}
+
+	return &result, nil
+}
+
+func (ps *PaystackService) InitializeCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error) {
+	result, err := ps.InitializeTransaction(ctx, InitializeTransactionRequest{
+		Email:       req.Email,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Reference:   req.Reference,
+		CallbackURL: req.CallbackURL,
+		Channels:    req.Channels,
+		Metadata:    checkoutMetadata(req),
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+config.AppConfig.PaystackSecretKey)
-	req.Header.Set("Content-Type", "application/json")
+	return &Checkout{
+		AuthorizationURL: result.Data.AuthorizationURL,
+		AccessCode:       result.Data.AccessCode,
+		Reference:        result.Data.Reference,
+	}, nil
+}
+
+// paystackReservedMetadata are metadata keys Paystack acts on at checkout.
+// Callers' metadata must not set them: cancel_action would redirect the
+// customer anywhere, and custom_fields and referrer show on the receipt.
+var paystackReservedMetadata = []string{"cancel_action", "custom_fields", "referrer"}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// checkoutMetadata is the caller's metadata without Paystack's reserved keys,
+// plus a cancel_action back to our callback.
+func checkoutMetadata(req CheckoutRequest) map[string]interface{} {
+	metadata := make(map[string]interface{}, len(req.Metadata)+1)
+	for key, value := range req.Metadata {
+		metadata[key] = value
+	}
+	for _, key := range paystackReservedMetadata {
+		delete(metadata, key)
+	}
+	// Paystack sends customers who close the checkout to cancel_action
+	if req.CallbackURL != "" {
+		metadata["cancel_action"] = req.CallbackURL + "?reference=" + url.QueryEscape(req.Reference)
+	}
+	return metadata
+}
+
+func (ps *PaystackService) VerifyCharge(ctx context.Context, reference string) (*Charge, error) {
+	result, err := ps.VerifyTransaction(ctx, reference)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	return ps.toCharge(result.Data), nil
+}
+
+func (ps *PaystackService) Refund(ctx context.Context, reference string, amount int64) (*Refund, error) {
+	payload := map[string]interface{}{
+		"transaction": reference,
+		"amount":      amount,
+	}
+
+	var result paystackRefundResponse
+	if err := ps.do(ctx, "POST", "/refund", payload, &result); err != nil {
+		return nil, err
+	}
+
+	if !result.Status {
+		return nil, ps.rejected(result.Message)
+	}
+
+	return &Refund{
+		ID:        strconv.FormatInt(result.Data.ID, 10),
+		Reference: result.Data.Transaction.Reference,
+		Amount:    result.Data.Amount,
+		Status:    paystackRefundStatus(result.Data.Status),
+	}, nil
+}
+
+// Payout sends money to a bank account. Paystack needs a transfer recipient
+// to exist first, so one is created for every payout.
+func (ps *PaystackService) Payout(ctx context.Context, req PayoutRequest) (*Payout, error) {
+	recipientCode, err := ps.CreateTransferRecipient(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	var result InitializeTransactionResponse
-	if err := json.Unmarshal(body, &result); err != nil {
+	transferPayload := map[string]interface{}{
+		"source":    "balance",
+		"amount":    req.Amount,
+		"recipient": recipientCode,
+		"reason":    req.Narration,
+		"reference": req.Reference,
+	}
+
+	var result paystackTransferResponse
+	if err := ps.do(ctx, "POST", "/transfer", transferPayload, &result); err != nil {
 		return nil, err
 	}
 
 	if !result.Status {
-		return nil, fmt.Errorf("paystack error: %s", result.Message)
+		return nil, ps.rejected(result.Message)
 	}
 
-	return &result, nil
+	return &Payout{
+		ID:        result.Data.TransferCode,
+		Reference: result.Data.Reference,
+		Amount:    req.Amount,
+		Status:    paystackTransferStatus(result.Data.Status),
+	}, nil
 }
 
-func (ps *PaystackService) VerifyTransaction(reference string) (*VerifyTransactionResponse, error) {
-	url := fmt.Sprintf("https://api.paystack.co/transaction/verify/%s", reference)
+// CreateTransferRecipient registers the bank account in req as a transfer
+// recipient and returns its recipient code.
+func (ps *PaystackService) CreateTransferRecipient(ctx context.Context, req PayoutRequest) (string, error) {
+	payload := map[string]string{
+		"type":           "nuban",
+		"name":           req.AccountName,
+		"account_number": req.AccountNumber,
+		"bank_code":      req.BankCode,
+		"currency":       req.Currency,
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
+	var recipient paystackRecipientResponse
+	if err := ps.do(ctx, "POST", "/transferrecipient", payload, &recipient); err != nil {
+		return "", err
+	}
+
+	if !recipient.Status {
+		return "", ps.rejected(recipient.Message)
+	}
+
+	return recipient.Data.RecipientCode, nil
+}
+
+// ResolveAccount looks up the name on a bank account, failing with
+// ValidationError when the account does not exist.
+func (ps *PaystackService) ResolveAccount(ctx context.Context, accountNumber, bankCode string) (string, error) {
+	query := url.Values{}
+	query.Set("account_number", accountNumber)
+	query.Set("bank_code", bankCode)
+
+	var result struct {
+		Status  bool   `json:"status"`
+		Message string `json:"message"`
+		Data    struct {
+			AccountName string `json:"account_name"`
+		} `json:"data"`
+	}
+	if err := ps.do(ctx, "GET", "/bank/resolve?"+query.Encode(), nil, &result); err != nil {
+		return "", err
+	}
+
+	if !result.Status {
+		return "", ps.rejected(result.Message)
+	}
+
+	return result.Data.AccountName, nil
+}
+
+// MaxBulkTransfers is the most transfers Paystack accepts in one bulk
+// transfer request.
+const MaxBulkTransfers = 100
+
+// BulkTransfer is one transfer to an existing recipient in a bulk transfer.
+type BulkTransfer struct {
+	Recipient string `json:"recipient"`
+	Amount    int64  `json:"amount"` // In kobo
+	Reference string `json:"reference"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// BulkTransfer queues up to MaxBulkTransfers transfers from the Paystack
+// balance in one request. Outcomes arrive as transfer webhooks.
+func (ps *PaystackService) BulkTransfer(ctx context.Context, currency string, transfers []BulkTransfer) ([]Payout, error) {
+	payload := map[string]interface{}{
+		"source":    "balance",
+		"currency":  currency,
+		"transfers": transfers,
+	}
+
+	var result struct {
+		Status  bool   `json:"status"`
+		Message string `json:"message"`
+		Data    []struct {
+			Reference    string `json:"reference"`
+			Amount       int64  `json:"amount"`
+			TransferCode string `json:"transfer_code"`
+			Status       string `json:"status"`
+		} `json:"data"`
+	}
+	if err := ps.do(ctx, "POST", "/transfer/bulk", payload, &result); err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+config.AppConfig.PaystackSecretKey)
+	if !result.Status {
+		return nil, ps.rejected(result.Message)
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
+	payouts := make([]Payout, 0, len(result.Data))
+	for _, data := range result.Data {
+		payouts = append(payouts, Payout{
+			ID:        data.TransferCode,
+			Reference: data.Reference,
+			Amount:    data.Amount,
+			Status:    paystackTransferStatus(data.Status),
+		})
+	}
+	return payouts, nil
+}
+
+// VerifyTransfer looks up a transfer by its reference, failing with
+// ValidationError when Paystack has no transfer with that reference.
+func (ps *PaystackService) VerifyTransfer(ctx context.Context, reference string) (*Payout, error) {
+	var result struct {
+		Status  bool   `json:"status"`
+		Message string `json:"message"`
+		Data    struct {
+			Reference    string `json:"reference"`
+			Amount       int64  `json:"amount"`
+			TransferCode string `json:"transfer_code"`
+			Status       string `json:"status"`
+			Reason       string `json:"reason"`
+		} `json:"data"`
+	}
+	if err := ps.do(ctx, "GET", "/transfer/verify/"+url.PathEscape(reference), nil, &result); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
+	if !result.Status {
+		return nil, ps.rejected(result.Message)
+	}
+
+	payout := &Payout{
+		ID:        result.Data.TransferCode,
+		Reference: result.Data.Reference,
+		Amount:    result.Data.Amount,
+		Status:    paystackTransferStatus(result.Data.Status),
+	}
+	if payout.Status == PayoutStatusFailed {
+		payout.Message = "Transfer " + result.Data.Status
+	}
+	return payout, nil
+}
+
+// paystackTransferStatus maps Paystack's transfer statuses (pending,
+// received, otp, success, failed, reversed, ...) onto ours.
+func paystackTransferStatus(status string) PayoutStatus {
+	switch status {
+	case "success":
+		return PayoutStatusSuccess
+	case "failed", "reversed", "abandoned", "blocked", "rejected":
+		return PayoutStatusFailed
+	default:
+		return PayoutStatusPending
+	}
+}
+
+// VerifyWebhook checks the HMAC-SHA512 signature Paystack sends in
+// x-paystack-signature. Besides the current secret key, any secret in
+// PAYSTACK_WEBHOOK_SECRETS is accepted so webhooks signed with the old key
+// keep working while the key is being rotated.
+func (ps *PaystackService) VerifyWebhook(header http.Header, body []byte) bool {
+	signature := header.Get("x-paystack-signature")
+	if signature == "" {
+		return false
+	}
+
+	secrets := append([]string{config.AppConfig.PaystackSecretKey}, config.AppConfig.PaystackWebhookSecrets...)
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		mac := hmac.New(sha512.New, []byte(secret))
+		mac.Write(body)
+		expectedSignature := hex.EncodeToString(mac.Sum(nil))
+		if hmac.Equal([]byte(signature), []byte(expectedSignature)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ps *PaystackService) ParseWebhook(body []byte) (*WebhookEvent, error) {
+	var event paystackWebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
 		return nil, err
 	}
 
+	result := &WebhookEvent{Type: event.Event, Data: event.Data}
+
+	if event.Event == "charge.success" || event.Event == "charge.failed" {
+		var data PaystackTransaction
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, err
+		}
+		result.Charge = ps.toCharge(data)
+		if event.Event == "charge.success" {
+			result.Type = EventChargeSuccess
+		} else {
+			result.Type = EventChargeFailed
+			result.Charge.Status = ChargeStatusFailed
+		}
+	}
+
+	if event.Event == "refund.processed" || event.Event == "refund.failed" {
+		var data paystackRefundEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, err
+		}
+		result.Refund = &Refund{
+			ID:        data.ID.String(),
+			Reference: data.TransactionReference,
+			Amount:    data.Amount,
+			Status:    paystackRefundStatus(data.Status),
+		}
+		if event.Event == "refund.failed" {
+			result.Type = EventRefundFailed
+			result.Refund.Status = RefundStatusFailed
+			result.Refund.Message = "Refund failed at Paystack"
+		} else {
+			result.Type = EventRefundProcessed
+			result.Refund.Status = RefundStatusProcessed
+		}
+	}
+
+	if event.Event == "charge.dispute.create" || event.Event == "charge.dispute.remind" || event.Event == "charge.dispute.resolve" {
+		var data paystackDispute
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, err
+		}
+		result.Dispute = toDispute(data)
+		switch event.Event {
+		case "charge.dispute.create":
+			result.Type = EventDisputeCreated
+		case "charge.dispute.remind":
+			result.Type = EventDisputeUpdated
+		default:
+			result.Type = EventDisputeResolved
+		}
+	}
+
+	if event.Event == "transfer.success" || event.Event == "transfer.failed" || event.Event == "transfer.reversed" {
+		var data paystackTransferEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, err
+		}
+		result.Payout = &Payout{
+			ID:        data.TransferCode,
+			Reference: data.Reference,
+			Amount:    data.Amount,
+			Status:    paystackTransferStatus(data.Status),
+		}
+		if event.Event == "transfer.success" {
+			result.Type = EventPayoutSuccess
+			result.Payout.Status = PayoutStatusSuccess
+		} else {
+			result.Type = EventPayoutFailed
+			result.Payout.Status = PayoutStatusFailed
+			result.Payout.Message = "Transfer " + strings.TrimPrefix(event.Event, "transfer.")
+		}
+	}
+
+	return result, nil
+}
+
+func toDispute(data paystackDispute) *Dispute {
+	dispute := &Dispute{
+		ID:         data.ID.String(),
+		Reference:  data.Transaction.Reference,
+		Amount:     data.RefundAmount,
+		Currency:   data.Currency,
+		Category:   data.Category,
+		Status:     data.Status,
+		Resolution: data.Resolution,
+		DueAt:      data.DueAt,
+	}
+	// Paystack leaves the refund amount out until the customer's bank names one
+	if dispute.Amount == 0 {
+		dispute.Amount = data.Transaction.Amount
+	}
+	if dispute.Currency == "" {
+		dispute.Currency = data.Transaction.Currency
+	}
+
+	switch data.Resolution {
+	case PaystackDisputeAccept, PaystackDisputeAutoAccepted:
+		dispute.Outcome = DisputeOutcomeLost
+	case PaystackDisputeDecline:
+		dispute.Outcome = DisputeOutcomeWon
+	}
+	return dispute
+}
+
+// paystackRefundStatus maps Paystack's refund statuses (pending, processing,
+// processed, failed, needs-attention) onto ours.
+func paystackRefundStatus(status string) RefundStatus {
+	switch status {
+	case "processed":
+		return RefundStatusProcessed
+	case "failed":
+		return RefundStatusFailed
+	default:
+		return RefundStatusPending
+	}
+}
+
+func (ps *PaystackService) toCharge(data PaystackTransaction) *Charge {
+	status := ChargeStatusPending
+	switch data.Status {
+	case "success":
+		status = ChargeStatusSuccess
+	case "failed", "reversed":
+		status = ChargeStatusFailed
+	}
+
+	charge := &Charge{
+		Provider:        ps.Name(),
+		Reference:       data.Reference,
+		Amount:          data.Amount,
+		Currency:        data.Currency,
+		Status:          status,
+		Channel:         data.Channel,
+		GatewayResponse: data.GatewayResponse,
+		Fees:            data.Fees,
+		PaidAt:          data.PaidAt,
+	}
+
+	if auth := data.Authorization; auth != nil && auth.Reusable && auth.Channel == "card" {
+		brand := auth.Brand
+		if brand == "" {
+			brand = auth.CardType
+		}
+		charge.Card = &CardAuthorization{
+			AuthorizationCode: auth.AuthorizationCode,
+			Signature:         auth.Signature,
+			Email:             data.Customer.Email,
+			Last4:             auth.Last4,
+			Brand:             brand,
+			Bank:              auth.Bank,
+			ExpMonth:          auth.ExpMonth,
+			ExpYear:           auth.ExpYear,
+		}
+	}
+
+	if data.Channel == "dedicated_nuban" {
+		charge.VirtualAccount = true
+		charge.Customer = data.Customer.CustomerCode
+		if data.Authorization != nil {
+			charge.VirtualAccountNumber = data.Authorization.ReceiverBankAccountNumber
+			charge.SenderName = data.Authorization.SenderName
+		}
+	}
+
+	return charge
+}
+
+// CreateDedicatedAccount creates (or reuses, Paystack dedupes by email) a
+// customer and assigns them a dedicated virtual account.
+func (ps *PaystackService) CreateDedicatedAccount(ctx context.Context, req DedicatedAccountRequest) (*DedicatedAccount, error) {
+	customerPayload := map[string]string{
+		"email":      req.Email,
+		"first_name": req.FirstName,
+		"last_name":  req.LastName,
+		"phone":      req.Phone,
+	}
+
+	var customer paystackCustomerResponse
+	if err := ps.do(ctx, "POST", "/customer", customerPayload, &customer); err != nil {
+		return nil, err
+	}
+
+	if !customer.Status {
+		return nil, ps.rejected(customer.Message)
+	}
+
+	accountPayload := map[string]string{
+		"customer":       customer.Data.CustomerCode,
+		"preferred_bank": req.PreferredBank,
+	}
+
+	var result paystackDedicatedAccountResponse
+	if err := ps.do(ctx, "POST", "/dedicated_account", accountPayload, &result); err != nil {
+		return nil, err
+	}
+
+	if !result.Status {
+		return nil, ps.rejected(result.Message)
+	}
+
+	return &DedicatedAccount{
+		ID:            strconv.FormatInt(result.Data.ID, 10),
+		CustomerCode:  customer.Data.CustomerCode,
+		AccountName:   result.Data.AccountName,
+		AccountNumber: result.Data.AccountNumber,
+		BankName:      result.Data.Bank.Name,
+		BankSlug:      result.Data.Bank.Slug,
+		Currency:      result.Data.Currency,
+		Active:        result.Data.Active,
+	}, nil
+}
+
+// ChargeAuthorization charges a card saved from an earlier payment without
+// sending the customer through checkout.
+func (ps *PaystackService) ChargeAuthorization(ctx context.Context, req AuthorizationChargeRequest) (*AuthorizationChargeResult, error) {
 	var result VerifyTransactionResponse
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := ps.do(ctx, "POST", "/transaction/charge_authorization", req, &result); err != nil {
 		return nil, err
 	}
 
 	if !result.Status {
-		return nil, fmt.Errorf("paystack error: %s", result.Message)
+		return nil, ps.rejected(result.Message)
 	}
 
-	return &result, nil
+	return ps.toAuthorizationChargeResult(result.Data), nil
+}
+
+// SubmitOTP completes a charge that came back with a send_otp challenge.
+func (ps *PaystackService) SubmitOTP(ctx context.Context, reference, otp string) (*AuthorizationChargeResult, error) {
+	payload := map[string]string{
+		"reference": reference,
+		"otp":       otp,
+	}
+
+	var result VerifyTransactionResponse
+	if err := ps.do(ctx, "POST", "/charge/submit_otp", payload, &result); err != nil {
+		return nil, err
+	}
+
+	if !result.Status {
+		return nil, ps.rejected(result.Message)
+	}
+
+	return ps.toAuthorizationChargeResult(result.Data), nil
+}
+
+func (ps *PaystackService) toAuthorizationChargeResult(data PaystackTransaction) *AuthorizationChargeResult {
+	return &AuthorizationChargeResult{
+		Status:      data.Status,
+		DisplayText: data.DisplayText,
+		Charge:      ps.toCharge(data),
+	}
+}
+
+// AddDisputeEvidence submits evidence for contesting a dispute and returns
+// the evidence id to decline it with.
+func (ps *PaystackService) AddDisputeEvidence(ctx context.Context, disputeID string, evidence DisputeEvidence) (int64, error) {
+	var result struct {
+		Status  bool   `json:"status"`
+		Message string `json:"message"`
+		Data    struct {
+			ID int64 `json:"id"`
+		} `json:"data"`
+	}
+	if err := ps.do(ctx, "POST", "/dispute/"+url.PathEscape(disputeID)+"/evidence", evidence, &result); err != nil {
+		return 0, err
+	}
+
+	if !result.Status {
+		return 0, ps.rejected(result.Message)
+	}
+
+	return result.Data.ID, nil
+}
+
+// ResolveDispute accepts or declines a dispute. The final outcome may still
+// arrive later as a charge.dispute.resolve webhook.
+func (ps *PaystackService) ResolveDispute(ctx context.Context, disputeID string, req DisputeResolutionRequest) (*Dispute, error) {
+	var result paystackDisputeResponse
+	if err := ps.do(ctx, "PUT", "/dispute/"+url.PathEscape(disputeID)+"/resolve", req, &result); err != nil {
+		return nil, err
+	}
+
+	if !result.Status {
+		return nil, ps.rejected(result.Message)
+	}
+
+	return toDispute(result.Data), nil
+}
+
+// do sends an authenticated request to the Paystack API and decodes the JSON
+// response into out.
+func (ps *PaystackService) do(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+config.AppConfig.PaystackSecretKey)
+
+	body, err := ps.client.do(ctx, method, config.AppConfig.PaystackBaseURL+path, header, payload, paystackErrorMessage)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// rejected reports a response that came back with "status": false.
+func (ps *PaystackService) rejected(message string) error {
+	return &ValidationError{Provider: ps.Name(), StatusCode: http.StatusOK, Message: message}
+}
+
+func paystackErrorMessage(body []byte) string {
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Message == "" {
+		return "unexpected response"
+	}
+	return result.Message
 }
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"wallet-service/config"
+)
+
+func paystackSignature(secret string, body []byte) string {
+	mac := hmac.New(sha512.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestPaystackVerifyWebhook(t *testing.T) {
+	body := []byte(`{"event":"charge.success","data":{"reference":"ref_123"}}`)
+
+	tests := []struct {
+		name      string
+		secretKey string
+		secrets   []string
+		signature string
+		want      bool
+	}{
+		{
+			name:      "current secret key",
+			secretKey: "sk_new",
+			secrets:   []string{"sk_old"},
+			signature: paystackSignature("sk_new", body),
+			want:      true,
+		},
+		{
+			name:      "previous secret during rotation",
+			secretKey: "sk_new",
+			secrets:   []string{"sk_old"},
+			signature: paystackSignature("sk_old", body),
+			want:      true,
+		},
+		{
+			name:      "any of several previous secrets",
+			secretKey: "sk_new",
+			secrets:   []string{"sk_older", "sk_old"},
+			signature: paystackSignature("sk_old", body),
+			want:      true,
+		},
+		{
+			name:      "previous secret once rotation is over",
+			secretKey: "sk_new",
+			signature: paystackSignature("sk_old", body),
+			want:      false,
+		},
+		{
+			name:      "unknown secret",
+			secretKey: "sk_new",
+			secrets:   []string{"sk_old"},
+			signature: paystackSignature("sk_other", body),
+			want:      false,
+		},
+		{
+			name:      "signature of another body",
+			secretKey: "sk_new",
+			signature: paystackSignature("sk_new", []byte(`{"event":"charge.success"}`)),
+			want:      false,
+		},
+		{
+			name:      "missing signature",
+			secretKey: "sk_new",
+			secrets:   []string{"sk_old"},
+			want:      false,
+		},
+		{
+			name:      "empty secrets are skipped",
+			secrets:   []string{""},
+			signature: paystackSignature("", body),
+			want:      false,
+		},
+	}
+
+	ps := NewPaystackService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.AppConfig = &config.Config{PaystackSecretKey: tt.secretKey, PaystackWebhookSecrets: tt.secrets}
+
+			header := http.Header{}
+			if tt.signature != "" {
+				header.Set("x-paystack-signature", tt.signature)
+			}
+			if got := ps.VerifyWebhook(header, body); got != tt.want {
+				t.Errorf("VerifyWebhook() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaystackParseWebhookCharge(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantType   string
+		wantStatus ChargeStatus
+	}{
+		{
+			name:       "charge success",
+			body:       `{"event":"charge.success","data":{"reference":"ref_123","amount":500000,"currency":"NGN","status":"success"}}`,
+			wantType:   EventChargeSuccess,
+			wantStatus: ChargeStatusSuccess,
+		},
+		{
+			name:       "charge failed",
+			body:       `{"event":"charge.failed","data":{"reference":"ref_123","amount":500000,"currency":"NGN","status":"failed","gateway_response":"Declined"}}`,
+			wantType:   EventChargeFailed,
+			wantStatus: ChargeStatusFailed,
+		},
+		{
+			name:       "charge failed without a status",
+			body:       `{"event":"charge.failed","data":{"reference":"ref_123"}}`,
+			wantType:   EventChargeFailed,
+			wantStatus: ChargeStatusFailed,
+		},
+	}
+
+	ps := NewPaystackService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := ps.ParseWebhook([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("ParseWebhook() error = %v", err)
+			}
+			if event.Type != tt.wantType || event.Charge == nil {
+				t.Fatalf("ParseWebhook() = type %q, charge %v; want type %q with a charge", event.Type, event.Charge, tt.wantType)
+			}
+			if event.Charge.Reference != "ref_123" || event.Charge.Status != tt.wantStatus {
+				t.Errorf("ParseWebhook() charge = %+v, want reference ref_123 and status %s", event.Charge, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPaystackDisputeOutcome(t *testing.T) {
+	tests := []struct {
+		resolution string
+		want       DisputeOutcome
+	}{
+		{resolution: "merchant-accepted", want: DisputeOutcomeLost},
+		{resolution: "auto-accepted", want: DisputeOutcomeLost},
+		{resolution: "declined", want: DisputeOutcomeWon},
+		{resolution: "", want: ""},
+		{resolution: "something-new", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resolution, func(t *testing.T) {
+			dispute := toDispute(paystackDispute{Resolution: tt.resolution})
+			if dispute.Outcome != tt.want || dispute.Resolution != tt.resolution {
+				t.Errorf("toDispute() outcome = %q, resolution = %q; want %q, %q", dispute.Outcome, dispute.Resolution, tt.want, tt.resolution)
+			}
+		})
+	}
+}
+
+func TestPaystackCheckoutMetadata(t *testing.T) {
+	tests := []struct {
+		name        string
+		metadata    map[string]interface{}
+		callbackURL string
+		want        map[string]interface{}
+	}{
+		{
+			name:        "caller metadata is kept",
+			metadata:    map[string]interface{}{"order_id": "1234"},
+			callbackURL: "https://wallet.example.com/deposit/callback",
+			want: map[string]interface{}{
+				"order_id":      "1234",
+				"cancel_action": "https://wallet.example.com/deposit/callback?reference=ref_123",
+			},
+		},
+		{
+			name: "reserved keys are dropped",
+			metadata: map[string]interface{}{
+				"order_id":      "1234",
+				"cancel_action": "https://phishing.example.com",
+				"custom_fields": "[]",
+				"referrer":      "https://phishing.example.com",
+			},
+			callbackURL: "https://wallet.example.com/deposit/callback",
+			want: map[string]interface{}{
+				"order_id":      "1234",
+				"cancel_action": "https://wallet.example.com/deposit/callback?reference=ref_123",
+			},
+		},
+		{
+			name:     "reserved keys are dropped without a callback",
+			metadata: map[string]interface{}{"cancel_action": "https://phishing.example.com"},
+			want:     map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkoutMetadata(CheckoutRequest{Reference: "ref_123", CallbackURL: tt.callbackURL, Metadata: tt.metadata})
+			if len(got) != len(tt.want) {
+				t.Fatalf("checkoutMetadata() = %v, want %v", got, tt.want)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("checkoutMetadata()[%q] = %v, want %v", key, got[key], value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-service/config"
+)
+
+// PaymentProvider is implemented by every payment gateway that deposits can be
+// taken through and payouts sent with. Amounts are always in kobo (the
+// smallest currency unit); implementations convert as their API requires.
+// Calls fail with NetworkError, ValidationError or ProviderError.
+type PaymentProvider interface {
+	Name() string
+	SupportsCurrency(currency string) bool
+	InitializeCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error)
+	VerifyCharge(ctx context.Context, reference string) (*Charge, error)
+	Refund(ctx context.Context, reference string, amount int64) (*Refund, error)
+	Payout(ctx context.Context, req PayoutRequest) (*Payout, error)
+	VerifyWebhook(header http.Header, body []byte) bool
+	ParseWebhook(body []byte) (*WebhookEvent, error)
+}
+
+type ChargeStatus string
+
+const (
+	ChargeStatusSuccess ChargeStatus = "success"
+	ChargeStatusFailed  ChargeStatus = "failed"
+	ChargeStatusPending ChargeStatus = "pending" // Not paid yet (ongoing, abandoned, ...)
+)
+
+// Normalized webhook event types. Events we have no normalized form for keep
+// the provider's own event name.
+const (
+	EventChargeSuccess   = "charge.success"
+	EventChargeFailed    = "charge.failed"
+	EventRefundProcessed = "refund.processed"
+	EventRefundFailed    = "refund.failed"
+	EventDisputeCreated  = "dispute.created"
+	EventDisputeUpdated  = "dispute.updated" // Reminders and status changes before resolution
+	EventDisputeResolved = "dispute.resolved"
+	EventPayoutSuccess   = "payout.success"
+	EventPayoutFailed    = "payout.failed" // Failed or reversed
+)
+
+var ErrNoPaymentProvider = errors.New("no payment provider available")
+
+type CheckoutRequest struct {
+	Email       string
+	Amount      int64 // In kobo
+	Currency    string
+	Reference   string
+	CallbackURL string                 // Where the customer is sent back to after checkout
+	Channels    []string               // CheckoutChannels to offer; all when empty
+	Metadata    map[string]interface{} // Passed through to the provider
+}
+
+// CheckoutChannels are the payment channels a checkout can be restricted to,
+// named as Paystack names them. Providers map them to their own names and
+// ignore the ones they do not offer.
+var CheckoutChannels = []string{"card", "bank", "ussd", "qr", "mobile_money", "bank_transfer", "eft", "apple_pay"}
+
+func IsCheckoutChannel(channel string) bool {
+	for _, c := range CheckoutChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+type Checkout struct {
+	AuthorizationURL string
+	AccessCode       string
+	Reference        string
+}
+
+// Charge is a provider's view of a payment against one of our references.
+type Charge struct {
+	Provider        string
+	Reference       string
+	Amount          int64 // In kobo
+	Currency        string
+	Status          ChargeStatus
+	Channel         string
+	GatewayResponse string
+	Fees            int64 // In kobo
+	PaidAt          *time.Time
+	Card            *CardAuthorization // Set when the card can be charged again
+
+	// Set for unsolicited bank transfers into a dedicated virtual account,
+	// which arrive under a provider-generated reference. The account number
+	// and sender are filled in when the provider sends them.
+	VirtualAccount       bool
+	Customer             string
+	VirtualAccountNumber string
+	SenderName           string
+}
+
+// CardAuthorization is a reusable token for a card the customer paid with.
+type CardAuthorization struct {
+	AuthorizationCode string
+	Signature         string // Identifies the card across authorizations
+	Email             string // The customer email the authorization is tied to
+	Last4             string
+	Brand             string
+	Bank              string
+	ExpMonth          string
+	ExpYear           string
+}
+
+type RefundStatus string
+
+const (
+	RefundStatusProcessed RefundStatus = "processed"
+	RefundStatusFailed    RefundStatus = "failed"
+	RefundStatusPending   RefundStatus = "pending" // Accepted, not completed yet
+)
+
+// Refund is a provider's view of a refund. Reference is the reference of the
+// charge being refunded.
+type Refund struct {
+	ID        string
+	Reference string
+	Amount    int64 // In kobo
+	Status    RefundStatus
+	Message   string // Why the refund failed, when it did
+}
+
+type DisputeOutcome string
+
+const (
+	DisputeOutcomeWon  DisputeOutcome = "won"  // Decided in our favour, the customer keeps nothing
+	DisputeOutcomeLost DisputeOutcome = "lost" // The customer gets the disputed amount back
+)
+
+// Dispute is a provider's view of a customer disputing a charge (a
+// chargeback). Reference is the reference of the disputed charge.
+type Dispute struct {
+	ID         string
+	Reference  string
+	Amount     int64 // In kobo
+	Currency   string
+	Category   string         // e.g. chargeback, fraud
+	Status     string         // The provider's own status
+	Resolution string         // The provider's own resolution, once resolved
+	Outcome    DisputeOutcome // Set once the dispute is resolved
+	DueAt      *time.Time     // When our response is due
+}
+
+type PayoutRequest struct {
+	Reference     string
+	AccountNumber string
+	BankCode      string
+	AccountName   string
+	Amount        int64 // In kobo
+	Currency      string
+	Narration     string
+}
+
+type PayoutStatus string
+
+const (
+	PayoutStatusSuccess PayoutStatus = "success"
+	PayoutStatusFailed  PayoutStatus = "failed"  // Failed or reversed; the money is back with the provider
+	PayoutStatusPending PayoutStatus = "pending" // Queued or being processed
+)
+
+type Payout struct {
+	ID        string
+	Reference string
+	Amount    int64 // In kobo
+	Status    PayoutStatus
+	Message   string // Why the payout failed, when it did
+}
+
+// WebhookEvent is a provider notification translated into our terms. Charge
+// is only set for charge events, Refund for refund events, Dispute for
+// dispute events and Payout for payout events; Data holds the raw event
+// payload.
+type WebhookEvent struct {
+	Type    string
+	Charge  *Charge
+	Refund  *Refund
+	Dispute *Dispute
+	Payout  *Payout
+	Data    []byte
+}
+
+// ProviderRegistry holds the enabled payment providers and decides which of
+// them a deposit should go through.
+type ProviderRegistry struct {
+	providers       map[string]PaymentProvider
+	order           []string
+	routes          []config.PaymentRoute
+	defaultProvider string
+}
+
+// NewProviderRegistry registers providers in failover order.
+func NewProviderRegistry(routes []config.PaymentRoute, defaultProvider string, providers ...PaymentProvider) *ProviderRegistry {
+	registry := &ProviderRegistry{
+		providers:       make(map[string]PaymentProvider),
+		routes:          routes,
+		defaultProvider: defaultProvider,
+	}
+	for _, provider := range providers {
+		registry.providers[provider.Name()] = provider
+		registry.order = append(registry.order, provider.Name())
+	}
+	return registry
+}
+
+func (r *ProviderRegistry) Get(name string) (PaymentProvider, bool) {
+	provider, ok := r.providers[name]
+	return provider, ok
+}
+
+// Candidates returns the providers to try for a deposit, most preferred
+// first. An explicit user choice is honoured without failover; otherwise the
+// first matching route wins, then the default provider, then every other
+// provider that supports the currency.
+func (r *ProviderRegistry) Candidates(currency string, amount int64, preferred string) ([]PaymentProvider, error) {
+	if preferred != "" {
+		provider, ok := r.providers[preferred]
+		if !ok || !provider.SupportsCurrency(currency) {
+			return nil, ErrNoPaymentProvider
+		}
+		return []PaymentProvider{provider}, nil
+	}
+
+	var names []string
+	for _, route := range r.routes {
+		if route.Matches(currency, amount) {
+			names = append(names, route.Provider)
+			break
+		}
+	}
+	names = append(names, r.defaultProvider)
+	names = append(names, r.order...)
+
+	var candidates []PaymentProvider
+	seen := make(map[string]bool)
+	for _, name := range names {
+		provider, ok := r.providers[name]
+		if !ok || seen[name] || !provider.SupportsCurrency(currency) {
+			continue
+		}
+		seen[name] = true
+		candidates = append(candidates, provider)
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrNoPaymentProvider
+	}
+	return candidates, nil
+}
+
+func containsCurrency(currencies []string, currency string) bool {
+	for _, c := range currencies {
+		if strings.EqualFold(c, currency) {
+			return true
+		}
+	}
+	return false
+}
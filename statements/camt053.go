@@ -0,0 +1,169 @@
+package statements
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// The subset of camt.053.001.02 a wallet statement fills in, in schema order.
+type camtDocument struct {
+	XMLName   xml.Name      `xml:"Document"`
+	Namespace string        `xml:"xmlns,attr"`
+	Statement camtStatement `xml:"BkToCstmrStmt"`
+}
+
+type camtStatement struct {
+	GroupHeader struct {
+		MessageID string `xml:"MsgId"`
+		Created   string `xml:"CreDtTm"`
+	} `xml:"GrpHdr"`
+	Statement struct {
+		ID      string `xml:"Id"`
+		Created string `xml:"CreDtTm"`
+		Period  struct {
+			From string `xml:"FrDtTm"`
+			To   string `xml:"ToDtTm"`
+		} `xml:"FrToDt"`
+		Account struct {
+			ID       string `xml:"Id>Othr>Id"`
+			Currency string `xml:"Ccy"`
+			Owner    string `xml:"Ownr>Nm,omitempty"`
+		} `xml:"Acct"`
+		Balances []camtBalance `xml:"Bal"`
+		Summary  struct {
+			Entries camtEntryCount `xml:"TtlNtries"`
+			Credits camtEntryCount `xml:"TtlCdtNtries"`
+			Debits  camtEntryCount `xml:"TtlDbtNtries"`
+		} `xml:"TxsSummry"`
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"Stmt"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type   string     `xml:"Tp>CdOrPrtry>Cd"` // OPBD opening, CLBD closing
+	Amount camtAmount `xml:"Amt"`
+	Sign   string     `xml:"CdtDbtInd"`
+	Date   string     `xml:"Dt>Dt"`
+}
+
+type camtEntryCount struct {
+	Count int    `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camtEntry struct {
+	Reference   string     `xml:"NtryRef"`
+	Amount      camtAmount `xml:"Amt"`
+	Sign        string     `xml:"CdtDbtInd"` // CRDT or DBIT
+	Status      string     `xml:"Sts"`
+	Booked      string     `xml:"BookgDt>DtTm"`
+	Value       string     `xml:"ValDt>Dt"`
+	Code        string     `xml:"BkTxCd>Prtry>Cd"`
+	EndToEndID  string     `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+	Information *string    `xml:"NtryDtls>TxDtls>RmtInf>Ustrd"` // Left out, with RmtInf, when nil
+}
+
+// WriteCamt053 writes the statement as an ISO 20022 camt.053 bank-to-customer
+// statement.
+func WriteCamt053(w io.Writer, s *Statement) error {
+	created := s.GeneratedAt.UTC().Format(time.RFC3339)
+
+	doc := camtDocument{Namespace: camt053Namespace}
+	doc.Statement.GroupHeader.MessageID = s.WalletNumber + "-" + s.GeneratedAt.UTC().Format("20060102150405")
+	doc.Statement.GroupHeader.Created = created
+
+	stmt := &doc.Statement.Statement
+	stmt.ID = s.WalletNumber + "-" + s.From.Format("20060102") + "-" + s.To.Format("20060102")
+	stmt.Created = created
+	stmt.Period.From = s.From.UTC().Format(time.RFC3339)
+	stmt.Period.To = s.To.AddDate(0, 0, 1).Add(-time.Second).UTC().Format(time.RFC3339)
+	stmt.Account.ID = s.WalletNumber
+	stmt.Account.Currency = s.Currency
+	stmt.Account.Owner = camtText(s.AccountName, 140)
+
+	stmt.Balances = []camtBalance{
+		camtBalanceOf("OPBD", s.OpeningBalance, s.Currency, s.From),
+		camtBalanceOf("CLBD", s.ClosingBalance, s.Currency, s.To),
+	}
+
+	var credits, debits int
+	for _, line := range s.Lines {
+		entry := camtEntry{
+			Reference:  camtText(line.Reference, 35),
+			Status:     "BOOK",
+			Booked:     line.Date.UTC().Format(time.RFC3339),
+			Value:      line.Date.UTC().Format(time.DateOnly),
+			Code:       camtText(line.Type, 35),
+			EndToEndID: camtText(line.Reference, 35),
+		}
+		if line.Description != "" {
+			information := camtText(line.Description, 140)
+			entry.Information = &information
+		}
+		if line.Debit > 0 {
+			entry.Amount = camtAmount{Currency: s.Currency, Value: decimalAmount(line.Debit)}
+			entry.Sign = "DBIT"
+			debits++
+		} else {
+			entry.Amount = camtAmount{Currency: s.Currency, Value: decimalAmount(line.Credit)}
+			entry.Sign = "CRDT"
+			credits++
+		}
+		stmt.Entries = append(stmt.Entries, entry)
+	}
+	stmt.Summary.Entries = camtEntryCount{Count: len(s.Lines), Sum: decimalAmount(s.TotalCredits + s.TotalDebits)}
+	stmt.Summary.Credits = camtEntryCount{Count: credits, Sum: decimalAmount(s.TotalCredits)}
+	stmt.Summary.Debits = camtEntryCount{Count: debits, Sum: decimalAmount(s.TotalDebits)}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// camtBalanceOf expresses a balance the ISO 20022 way: an unsigned amount
+// marked as credit or debit.
+func camtBalanceOf(kind string, kobo int64, currency string, date time.Time) camtBalance {
+	sign := "CRDT"
+	if kobo < 0 {
+		sign = "DBIT"
+		kobo = -kobo
+	}
+	return camtBalance{
+		Type:   kind,
+		Amount: camtAmount{Currency: currency, Value: decimalAmount(kobo)},
+		Sign:   sign,
+		Date:   date.Format(time.DateOnly),
+	}
+}
+
+// camtText puts text on one line and cuts it to a field's maximum length.
+func camtText(text string, limit int) string {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, text)
+	if utf8.RuneCountInString(text) > limit {
+		return string([]rune(text)[:limit])
+	}
+	return text
+}
@@ -0,0 +1,61 @@
+package statements
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteCSV writes the account details and summary, a blank row, then one row
+// per line.
+func WriteCSV(w io.Writer, s *Statement) error {
+	writer := csv.NewWriter(w)
+
+	rows := [][]string{
+		{"Account name", cell(s.AccountName)},
+		{"Email", cell(s.Email)},
+		{"Wallet number", s.WalletNumber},
+		{"Currency", s.Currency},
+		{"Period", s.From.Format(time.DateOnly) + " to " + s.To.Format(time.DateOnly)},
+		{"Generated", s.GeneratedAt.UTC().Format(time.RFC3339)},
+		{"Opening balance", FormatAmount(s.OpeningBalance)},
+		{"Total credits", FormatAmount(s.TotalCredits)},
+		{"Total debits", FormatAmount(s.TotalDebits)},
+		{"Closing balance", FormatAmount(s.ClosingBalance)},
+		{},
+		{"Date", "Reference", "Type", "Description", "Debit", "Credit", "Balance"},
+	}
+	for _, line := range s.Lines {
+		rows = append(rows, []string{
+			line.Date.UTC().Format(time.RFC3339),
+			cell(line.Reference),
+			line.Type,
+			cell(line.Description),
+			optionalAmount(line.Debit),
+			optionalAmount(line.Credit),
+			FormatAmount(line.Balance),
+		})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// cell keeps text that other users can set, such as a sender's narration,
+// from being run as a formula by spreadsheet software.
+func cell(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+func optionalAmount(kobo int64) string {
+	if kobo == 0 {
+		return ""
+	}
+	return FormatAmount(kobo)
+}
@@ -0,0 +1,97 @@
+package statements
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ofxTransactionTypes maps wallet transaction types to OFX TRNTYPEs; other
+// types are CREDIT or DEBIT by direction.
+var ofxTransactionTypes = map[string]string{
+	"deposit":  "DEP",
+	"transfer": "XFER",
+	"credit":   "XFER",
+	"payout":   "PAYMENT",
+}
+
+// WriteOFX writes an OFX 1.0.2 bank statement, the dialect personal finance
+// apps import most reliably. Quicken takes the same file as QFX.
+func WriteOFX(w io.Writer, s *Statement) error {
+	out := bufio.NewWriter(w)
+	now := ofxTime(s.GeneratedAt)
+
+	out.WriteString("OFXHEADER:100\r\nDATA:OFXSGML\r\nVERSION:102\r\nSECURITY:NONE\r\nENCODING:USASCII\r\n" +
+		"CHARSET:1252\r\nCOMPRESSION:NONE\r\nOLDFILEUID:NONE\r\nNEWFILEUID:NONE\r\n\r\n")
+
+	out.WriteString("<OFX>\r\n<SIGNONMSGSRSV1><SONRS>\r\n")
+	out.WriteString("<STATUS><CODE>0<SEVERITY>INFO</STATUS>\r\n")
+	fmt.Fprintf(out, "<DTSERVER>%s\r\n<LANGUAGE>ENG\r\n", now)
+	out.WriteString("</SONRS></SIGNONMSGSRSV1>\r\n")
+
+	out.WriteString("<BANKMSGSRSV1><STMTTRNRS>\r\n<TRNUID>0\r\n<STATUS><CODE>0<SEVERITY>INFO</STATUS>\r\n<STMTRS>\r\n")
+	fmt.Fprintf(out, "<CURDEF>%s\r\n", ofxText(s.Currency, 3))
+	fmt.Fprintf(out, "<BANKACCTFROM><BANKID>WALLET<ACCTID>%s<ACCTTYPE>CHECKING</BANKACCTFROM>\r\n", ofxText(s.WalletNumber, 22))
+
+	fmt.Fprintf(out, "<BANKTRANLIST>\r\n<DTSTART>%s\r\n<DTEND>%s\r\n", ofxTime(s.From), ofxTime(s.To.AddDate(0, 0, 1)))
+	for _, line := range s.Lines {
+		amount := line.Credit - line.Debit
+		kind, ok := ofxTransactionTypes[line.Type]
+		if !ok {
+			kind = "CREDIT"
+			if amount < 0 {
+				kind = "DEBIT"
+			}
+		}
+
+		out.WriteString("<STMTTRN>\r\n")
+		fmt.Fprintf(out, "<TRNTYPE>%s\r\n<DTPOSTED>%s\r\n<TRNAMT>%s\r\n<FITID>%s\r\n",
+			kind, ofxTime(line.Date), decimalAmount(amount), ofxText(line.Reference, 255))
+		fmt.Fprintf(out, "<NAME>%s\r\n", ofxText(lineDescription(line), 32))
+		if line.Description != "" {
+			fmt.Fprintf(out, "<MEMO>%s\r\n", ofxText(line.Description, 255))
+		}
+		out.WriteString("</STMTTRN>\r\n")
+	}
+	out.WriteString("</BANKTRANLIST>\r\n")
+
+	fmt.Fprintf(out, "<LEDGERBAL><BALAMT>%s<DTASOF>%s</LEDGERBAL>\r\n", decimalAmount(s.ClosingBalance), ofxTime(s.To.AddDate(0, 0, 1)))
+	out.WriteString("</STMTRS>\r\n</STMTTRNRS></BANKMSGSRSV1>\r\n</OFX>\r\n")
+
+	return out.Flush()
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405") + "[0:GMT]"
+}
+
+// ofxText makes text safe for an OFX element: one line of ASCII (the header
+// promises USASCII), markup escaped, cut to the element's length limit.
+func ofxText(text string, limit int) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r < 0x20:
+			r = ' '
+		case r >= 0x7f:
+			r = '?'
+		}
+		b.WriteRune(r)
+	}
+	text = b.String()
+	if utf8.RuneCountInString(text) > limit {
+		text = text[:limit]
+	}
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// lineDescription is a line's description, or its type when it has none.
+func lineDescription(line Line) string {
+	if line.Description != "" {
+		return line.Description
+	}
+	return strings.ReplaceAll(line.Type, "_", " ")
+}
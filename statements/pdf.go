@@ -0,0 +1,271 @@
+package statements
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// A4 in points, and the layout of the statement on it.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	marginLeft   = 40
+	marginRight  = pageWidth - 40
+	tableBottom  = 60
+	rowHeight    = 11
+	tableSize    = 7.5
+	headerHeight = 170 // Account details and summary on the first page
+)
+
+// Standard PDF fonts, so nothing has to be embedded.
+const (
+	fontRegular = "F1" // Helvetica
+	fontBold    = "F2" // Helvetica-Bold
+	fontMono    = "F3" // Courier, for right-aligned amounts
+)
+
+type column struct {
+	x, width float64
+	numeric  bool // Right-aligned at x + width
+}
+
+var (
+	dateColumn        = column{x: marginLeft, width: 62}
+	referenceColumn   = column{x: 102, width: 112}
+	descriptionColumn = column{x: 218, width: 150}
+	debitColumn       = column{x: 372, width: 58, numeric: true}
+	creditColumn      = column{x: 434, width: 58, numeric: true}
+	balanceColumn     = column{x: 496, width: 59, numeric: true}
+)
+
+// WritePDF renders the statement as a PDF: account details and a summary on
+// the first page, then the lines as a table across as many pages as needed.
+func WritePDF(w io.Writer, s *Statement) error {
+	pages := paginate(len(s.Lines))
+
+	var contents [][]byte
+	for i, lines := range pages {
+		page := &pdfPage{}
+		top := float64(pageHeight - 50)
+		if i == 0 {
+			writeStatementHeader(page, s, top)
+			top -= headerHeight
+		}
+		y := writeTableHeader(page, top)
+		for _, index := range lines {
+			y -= rowHeight
+			writeLine(page, s.Lines[index], y)
+		}
+		if i == len(pages)-1 {
+			y -= rowHeight
+			if len(s.Lines) == 0 {
+				page.text(fontRegular, tableSize, marginLeft, y, "No transactions in this period.")
+				y -= rowHeight
+			}
+			page.rule(y+rowHeight-3, 0.5)
+			writeTotals(page, s, y-2)
+		}
+		page.text(fontRegular, 7, marginLeft, 30,
+			fmt.Sprintf("%s  |  Wallet %s  |  Generated %s", s.AccountName, s.WalletNumber, s.GeneratedAt.UTC().Format("02 Jan 2006 15:04 MST")))
+		page.text(fontRegular, 7, marginRight-40, 30, fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+		contents = append(contents, page.buf.Bytes())
+	}
+
+	return writePDFDocument(w, "Statement "+s.WalletNumber, contents)
+}
+
+// paginate splits line indexes over pages, keeping room for the header on
+// the first page. Every page leaves two rows spare, so the totals always fit
+// under the last line.
+func paginate(count int) [][]int {
+	firstPage := int((pageHeight-50-headerHeight-rowHeight-tableBottom)/rowHeight) - 2
+	otherPages := int((pageHeight-50-rowHeight-tableBottom)/rowHeight) - 2
+
+	pages := [][]int{{}}
+	capacity := firstPage
+	for i := 0; i < count; i++ {
+		if len(pages[len(pages)-1]) == capacity {
+			pages = append(pages, []int{})
+			capacity = otherPages
+		}
+		pages[len(pages)-1] = append(pages[len(pages)-1], i)
+	}
+	return pages
+}
+
+func writeStatementHeader(page *pdfPage, s *Statement, top float64) {
+	page.text(fontBold, 16, marginLeft, top, "Account Statement")
+	page.text(fontRegular, 9, marginLeft, top-18,
+		s.From.Format("02 Jan 2006")+" to "+s.To.Format("02 Jan 2006"))
+
+	details := [][2]string{
+		{"Account name", s.AccountName},
+		{"Email", s.Email},
+		{"Wallet number", s.WalletNumber},
+		{"Currency", s.Currency},
+	}
+	summary := [][2]string{
+		{"Opening balance", FormatAmount(s.OpeningBalance)},
+		{"Total credits", FormatAmount(s.TotalCredits)},
+		{"Total debits", FormatAmount(s.TotalDebits)},
+		{"Closing balance", FormatAmount(s.ClosingBalance)},
+	}
+	y := top - 48
+	for i := range details {
+		page.text(fontBold, 9, marginLeft, y, details[i][0])
+		page.text(fontRegular, 9, marginLeft+80, y, details[i][1])
+		page.text(fontBold, 9, 340, y, summary[i][0])
+		page.textRight(fontMono, 9, marginRight, y, summary[i][1])
+		y -= 16
+	}
+	page.rule(top-headerHeight+24, 1)
+}
+
+func writeTableHeader(page *pdfPage, top float64) float64 {
+	headings := []struct {
+		column column
+		label  string
+	}{
+		{dateColumn, "Date"},
+		{referenceColumn, "Reference"},
+		{descriptionColumn, "Description"},
+		{debitColumn, "Debit"},
+		{creditColumn, "Credit"},
+		{balanceColumn, "Balance"},
+	}
+	for _, h := range headings {
+		page.cell(fontBold, h.column, top, h.label)
+	}
+	page.rule(top-4, 0.5)
+	return top - 4
+}
+
+func writeLine(page *pdfPage, line Line, y float64) {
+	page.cell(fontRegular, dateColumn, y, line.Date.UTC().Format("02 Jan 06 15:04"))
+	page.cell(fontRegular, referenceColumn, y, line.Reference)
+	page.cell(fontRegular, descriptionColumn, y, lineDescription(line))
+	page.cell(fontMono, debitColumn, y, optionalAmount(line.Debit))
+	page.cell(fontMono, creditColumn, y, optionalAmount(line.Credit))
+	page.cell(fontMono, balanceColumn, y, FormatAmount(line.Balance))
+}
+
+func writeTotals(page *pdfPage, s *Statement, y float64) {
+	page.cell(fontBold, descriptionColumn, y, "Totals")
+	page.cell(fontMono, debitColumn, y, FormatAmount(s.TotalDebits))
+	page.cell(fontMono, creditColumn, y, FormatAmount(s.TotalCredits))
+	page.cell(fontBold, descriptionColumn, y-rowHeight, "Closing balance")
+	page.cell(fontMono, balanceColumn, y-rowHeight, FormatAmount(s.ClosingBalance))
+}
+
+// pdfPage collects a page's content stream.
+type pdfPage struct {
+	buf bytes.Buffer
+}
+
+func (p *pdfPage) text(font string, size, x, y float64, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(&p.buf, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(text))
+}
+
+// textRight right-aligns monospaced text at x.
+func (p *pdfPage) textRight(font string, size, x, y float64, text string) {
+	p.text(font, size, x-float64(utf8.RuneCountInString(text))*size*0.6, y, text)
+}
+
+// cell writes text into a table column, cut to fit its width.
+func (p *pdfPage) cell(font string, c column, y float64, text string) {
+	if c.numeric {
+		p.textRight(font, tableSize, c.x+c.width, y, text)
+		return
+	}
+	// Helvetica averages a little over half the font size per character
+	maxRunes := int(c.width / (tableSize * 0.52))
+	if utf8.RuneCountInString(text) > maxRunes {
+		text = string([]rune(text)[:maxRunes-2]) + ".."
+	}
+	p.text(font, tableSize, c.x, y, text)
+}
+
+func (p *pdfPage) rule(y, width float64) {
+	fmt.Fprintf(&p.buf, "%.1f w %d %.2f m %d %.2f l S\n", width, marginLeft, y, marginRight, y)
+}
+
+// pdfString escapes text for a PDF string in WinAnsiEncoding, which covers
+// ASCII and Latin-1; anything else becomes '?'.
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// writePDFDocument writes a PDF with one page per content stream.
+func writePDFDocument(w io.Writer, title string, contents [][]byte) error {
+	// Objects 1-6 are the catalog, page tree, three fonts and the document
+	// info; each page then takes two objects, the page and its content stream
+	pageRefs := make([]string, len(contents))
+	for i := range contents {
+		pageRefs[i] = fmt.Sprintf("%d 0 R", 7+2*i)
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageRefs, " "), len(contents)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title (%s) /CreationDate (D:%s) >>", pdfString(title), time.Now().UTC().Format("20060102150405Z")),
+	}
+
+	for i, content := range contents {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(content); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /%s 3 0 R /%s 4 0 R /%s 5 0 R >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, fontRegular, fontBold, fontMono, 8+2*i),
+			fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
@@ -0,0 +1,34 @@
+package statements
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteQIF writes the lines as a QIF bank account register. QIF has no
+// notion of opening balance or account details; apps take those from the
+// account the file is imported into.
+func WriteQIF(w io.Writer, s *Statement) error {
+	out := bufio.NewWriter(w)
+
+	out.WriteString("!Type:Bank\n")
+	for _, line := range s.Lines {
+		fmt.Fprintf(out, "D%s\n", line.Date.UTC().Format("01/02/2006"))
+		fmt.Fprintf(out, "T%s\n", decimalAmount(line.Credit-line.Debit))
+		fmt.Fprintf(out, "N%s\n", qifText(line.Reference))
+		fmt.Fprintf(out, "P%s\n", qifText(lineDescription(line)))
+		if line.Description != "" {
+			fmt.Fprintf(out, "M%s\n", qifText(strings.ReplaceAll(line.Type, "_", " ")))
+		}
+		out.WriteString("^\n")
+	}
+
+	return out.Flush()
+}
+
+// qifText keeps a field on one line, since QIF fields end at the newline.
+func qifText(text string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(text)
+}
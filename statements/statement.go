@@ -0,0 +1,141 @@
+// Package statements renders account statements: the transactions that moved
+// a wallet's balance over a period, with opening and closing balances.
+package statements
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatPDF     = "pdf"
+	FormatOFX     = "ofx"     // Open Financial Exchange, for personal finance apps
+	FormatQFX     = "qfx"     // OFX as Quicken expects it
+	FormatQIF     = "qif"     // Quicken Interchange Format
+	FormatCamt053 = "camt053" // ISO 20022 bank-to-customer statement, for ERPs
+)
+
+// formats holds the MIME type and file extension of each format.
+var formats = map[string]struct{ contentType, extension string }{
+	FormatCSV:     {"text/csv; charset=utf-8", "csv"},
+	FormatPDF:     {"application/pdf", "pdf"},
+	FormatOFX:     {"application/x-ofx", "ofx"},
+	FormatQFX:     {"application/vnd.intu.qfx", "qfx"},
+	FormatQIF:     {"application/qif", "qif"},
+	FormatCamt053: {"application/xml", "xml"},
+}
+
+// IsFormat reports whether format is one Write can render.
+func IsFormat(format string) bool {
+	_, ok := formats[format]
+	return ok
+}
+
+// Statement is everything shown on a statement. Amounts are in kobo.
+type Statement struct {
+	AccountName    string
+	Email          string
+	WalletNumber   string
+	Currency       string
+	From           time.Time // Inclusive
+	To             time.Time // Inclusive
+	GeneratedAt    time.Time
+	OpeningBalance int64
+	ClosingBalance int64
+	TotalCredits   int64
+	TotalDebits    int64
+	Lines          []Line
+}
+
+// Line is one balance change. Exactly one of Debit and Credit is set.
+type Line struct {
+	Date        time.Time
+	Reference   string
+	Type        string
+	Description string
+	Debit       int64
+	Credit      int64
+	Balance     int64 // Running balance after the line
+}
+
+// New starts a statement with no lines, closing at its opening balance.
+func New(opening int64) *Statement {
+	return &Statement{OpeningBalance: opening, ClosingBalance: opening}
+}
+
+// AddLine appends a balance change of delta (negative for debits) and keeps
+// the running balance and totals up to date.
+func (s *Statement) AddLine(date time.Time, reference, kind, description string, delta int64) {
+	s.ClosingBalance += delta
+
+	line := Line{Date: date, Reference: reference, Type: kind, Description: description, Balance: s.ClosingBalance}
+	if delta < 0 {
+		line.Debit = -delta
+		s.TotalDebits += -delta
+	} else {
+		line.Credit = delta
+		s.TotalCredits += delta
+	}
+	s.Lines = append(s.Lines, line)
+}
+
+// Write renders the statement in format, one of the Format constants.
+func Write(w io.Writer, format string, s *Statement) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, s)
+	case FormatPDF:
+		return WritePDF(w, s)
+	case FormatOFX, FormatQFX:
+		return WriteOFX(w, s)
+	case FormatQIF:
+		return WriteQIF(w, s)
+	case FormatCamt053:
+		return WriteCamt053(w, s)
+	}
+	return fmt.Errorf("unknown statement format %q", format)
+}
+
+// ContentType is the MIME type of a statement format.
+func ContentType(format string) string {
+	return formats[format].contentType
+}
+
+// Extension is the file extension for a statement format.
+func Extension(format string) string {
+	return formats[format].extension
+}
+
+// FormatAmount writes kobo as major units with thousands separators, e.g.
+// 123456789 as 1,234,567.89.
+func FormatAmount(kobo int64) string {
+	sign := ""
+	if kobo < 0 {
+		sign = "-"
+		kobo = -kobo
+	}
+
+	whole := strconv.FormatInt(kobo/100, 10)
+	grouped := make([]byte, 0, len(whole)+len(whole)/3)
+	for i := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped = append(grouped, ',')
+		}
+		grouped = append(grouped, whole[i])
+	}
+	return fmt.Sprintf("%s%s.%02d", sign, grouped, kobo%100)
+}
+
+// decimalAmount writes kobo as major units without separators, e.g.
+// -123456 as -1234.56, as exchange formats expect.
+func decimalAmount(kobo int64) string {
+	sign := ""
+	if kobo < 0 {
+		sign = "-"
+		kobo = -kobo
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, kobo/100, kobo%100)
+}
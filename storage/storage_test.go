@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoragePath(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir)
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "user/receipt", want: filepath.Join(dir, "user", "receipt")},
+		{key: "user/./receipt", want: filepath.Join(dir, "user", "receipt")},
+		{key: "user/../other/receipt", want: filepath.Join(dir, "other", "receipt")},
+		{key: "", wantErr: true},
+		{key: "..", wantErr: true},
+		{key: "../receipt", wantErr: true},
+		{key: "user/../../receipt", wantErr: true},
+		{key: "/etc/passwd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := s.path(tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("path(%q) = %q, want an error", tt.key, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("path(%q) error = %v", tt.key, err)
+			}
+			if got != tt.want {
+				t.Errorf("path(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocalStorage(t.TempDir())
+
+	if err := s.Put(ctx, "user/receipt", strings.NewReader("paid")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	file, err := s.Open(ctx, "user/receipt")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	content, err := io.ReadAll(file)
+	file.Close()
+	if err != nil || string(content) != "paid" {
+		t.Fatalf("Open() read %q, %v; want %q", content, err, "paid")
+	}
+
+	if err := s.Delete(ctx, "user/receipt"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Open(ctx, "user/receipt"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Open() after Delete error = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "user/receipt"); err != nil {
+		t.Fatalf("Delete() of a missing file error = %v", err)
+	}
+
+	if err := s.Put(ctx, "../escape", strings.NewReader("x")); err == nil {
+		t.Fatal("Put() outside the directory succeeded")
+	}
+}
@@ -72,6 +72,16 @@ func GenerateReference() string {
 	return fmt.Sprintf("TXN_%d", time.Now().UnixNano())
 }
 
+// GenerateTransferGroupID generates the ID shared by both legs of a wallet
+// transfer
+func GenerateTransferGroupID() (string, error) {
+	bytes := make([]byte, 12)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "TRF_" + hex.EncodeToString(bytes), nil
+}
+
 // HashAPIKey generates a SHA-256 hash of an API key for secure storage
 func HashAPIKey(key string) string {
 	hash := sha256.Sum256([]byte(key))